@@ -43,6 +43,42 @@ type accountAuthor struct {
 	indexWeight           map[uint64]uint64
 }
 
+// weight returns the sum of the weights of the signed author indexes.
+func (a *accountAuthor) weight() uint64 {
+	var count uint64
+	for _, weight := range a.indexWeight {
+		count += weight
+	}
+	return count
+}
+
+// actionThreshold returns the threshold the account must reach to authorize
+// the action signed on behalf of signSender.
+func (a *accountAuthor) actionThreshold(name, signSender common.Name, action *types.Action) uint64 {
+	if name.String() == signSender.String() && (isAuthorAction(action.Type()) || signSender != action.Sender()) {
+		return a.updateAuthorThreshold
+	}
+	return a.threshold
+}
+
+// isAuthorAction reports whether the action changes who controls the account,
+// such actions need the update author threshold.
+func isAuthorAction(aType types.ActionType) bool {
+	switch aType {
+	case types.UpdateAccountAuthor, types.SetRecovery, types.CancelRecovery:
+		return true
+	}
+	return false
+}
+
+// AuthorWeight is the weight reached by the signatures of an account taking
+// part in the authorization of an action.
+type AuthorWeight struct {
+	Account   common.Name `json:"account"`
+	Weight    uint64      `json:"weight"`
+	Threshold uint64      `json:"threshold"`
+}
+
 func newAssetBalance(assetID uint64, amount *big.Int) *AssetBalance {
 	ab := AssetBalance{
 		AssetID: assetID,
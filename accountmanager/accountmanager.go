@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -60,6 +61,12 @@ type CreateAccountAction struct {
 	Description string        `json:"description,omitempty"`
 }
 
+// DeleteAccountAction is the payload of the DeleteAccount action, the
+// remaining balances of the account are swept to the beneficiary.
+type DeleteAccountAction struct {
+	Beneficiary common.Name `json:"beneficiary,omitempty"`
+}
+
 type UpdataAccountAction struct {
 	Founder common.Name `json:"founder,omitempty"`
 }
@@ -108,6 +115,28 @@ type UpdateAssetContract struct {
 	Contract common.Name `json:"contract"`
 }
 
+// FreezeAsset is the payload of the FreezeAsset action.
+type FreezeAsset struct {
+	AssetID uint64      `json:"assetId,omitempty"`
+	Account common.Name `json:"account"`
+	Frozen  bool        `json:"frozen"`
+}
+
+// PauseAsset is the payload of the PauseAsset action.
+type PauseAsset struct {
+	AssetID uint64 `json:"assetId,omitempty"`
+	Paused  bool   `json:"paused"`
+}
+
+// AssetWhitelist is the payload of the SetAssetWhitelist action, it switches
+// the whitelist mode of the asset and adds or removes whitelisted accounts.
+type AssetWhitelist struct {
+	AssetID  uint64        `json:"assetId,omitempty"`
+	Enabled  bool          `json:"enabled"`
+	Accounts []common.Name `json:"accounts,omitempty"`
+	Remove   []common.Name `json:"remove,omitempty"`
+}
+
 //AccountManager represents account management model.
 type AccountManager struct {
 	sdb *state.StateDB
@@ -372,6 +401,7 @@ func (am *AccountManager) CreateAccount(fromName common.Name, accountName common
 	}
 	acctObj.SetAccountNumber(number)
 	//acctObj.SetChargeRatio(0)
+
 	am.SetAccount(acctObj)
 	am.sdb.Put(acctManagerName, accountNameIDPrefix+accountName.String(), aid)
 	am.sdb.Put(acctManagerName, counterPrefix, aid)
@@ -561,6 +591,99 @@ func (am *AccountManager) DeleteAccountByName(accountName common.Name) error {
 	return nil
 }
 
+// CheckDeleteAccount checks that the account can be deleted, its balances
+// being swept to the beneficiary. Accounts with code or with a balance still
+// locked by vesting schedules can't be deleted.
+func (am *AccountManager) CheckDeleteAccount(accountName, beneficiary common.Name) error {
+	acct, err := am.GetAccountByName(accountName)
+	if err != nil {
+		return err
+	}
+	if acct == nil {
+		return ErrAccountNotExist
+	}
+	if acct.IsDestroyed() {
+		return ErrAccountIsDestroy
+	}
+	if acct.HaveCode() {
+		return ErrAccountHasCode
+	}
+	for _, balance := range acct.GetBalancesList() {
+		locked, err := am.GetLockedBalance(accountName, balance.AssetID)
+		if err != nil {
+			return err
+		}
+		if locked.Sign() > 0 {
+			return ErrAccountLocked
+		}
+	}
+	if beneficiary == accountName {
+		return ErrInvalidBeneficiary
+	}
+	ben, err := am.GetAccountByName(beneficiary)
+	if err != nil {
+		return err
+	}
+	if ben == nil {
+		return fmt.Errorf("beneficiary %v: %v", beneficiary, ErrAccountNotExist)
+	}
+	if ben.IsDestroyed() {
+		return fmt.Errorf("beneficiary %v: %v", beneficiary, ErrAccountIsDestroy)
+	}
+	return nil
+}
+
+// DestroyAccount sweeps the balances of the account to the beneficiary and
+// marks it destroyed. The name stays reserved, as the asset roles, contract
+// authors and dpos records keyed by the name would otherwise pass to a new
+// account created with it.
+func (am *AccountManager) DestroyAccount(accountName, beneficiary common.Name, curForkID uint64) ([]*types.InternalAction, error) {
+	if err := am.CheckDeleteAccount(accountName, beneficiary); err != nil {
+		return nil, err
+	}
+	acct, err := am.GetAccountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	var internalActions []*types.InternalAction
+	for _, balance := range acct.GetBalancesList() {
+		if balance.Balance.Sign() == 0 {
+			continue
+		}
+		if err := am.CheckTransferControl(accountName, beneficiary, balance.AssetID, balance.Balance, curForkID); err != nil {
+			return nil, err
+		}
+		if err := am.TransferAsset(accountName, beneficiary, balance.AssetID, balance.Balance); err != nil {
+			return nil, err
+		}
+		actionX := types.NewAction(types.Transfer, accountName, beneficiary, 0, balance.AssetID, 0, balance.Balance, nil, nil)
+		internalAction := &types.InternalAction{Action: actionX.NewRPCAction(0), ActionType: "", GasUsed: 0, GasLimit: 0, Depth: 0, Error: ""}
+		internalActions = append(internalActions, internalAction)
+	}
+
+	// the balances have been swept, read the account again
+	acct, err = am.GetAccountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+	acct.SetDestroy()
+	b, err := rlp.EncodeToBytes(acct)
+	if err != nil {
+		return nil, err
+	}
+	am.sdb.Put(acctManagerName, acctInfoPrefix+strconv.FormatUint(acct.GetAccountID(), 10), b)
+	am.sdb.Delete(acctManagerName, sponsorshipPrefix+accountName.String())
+	if err := am.clearRecovery(accountName); err != nil {
+		return nil, err
+	}
+	am.sdb.Delete(acctManagerName, recoveryConfigPrefix+accountName.String())
+	for _, balance := range acct.GetBalancesList() {
+		am.sdb.Delete(acctManagerName, vestingKey(accountName, balance.AssetID))
+	}
+	return internalActions, nil
+}
+
 // GetNonce get nonce
 func (am *AccountManager) GetNonce(accountName common.Name) (uint64, error) {
 	acct, err := am.GetAccountByName(accountName)
@@ -619,43 +742,40 @@ func (am *AccountManager) getParentAccount(accountName common.Name, parentIndex
 
 // RecoverTx Make sure the transaction is signed properly and validate account authorization.
 func (am *AccountManager) RecoverTx(signer types.Signer, tx *types.Transaction) error {
-	for _, action := range tx.GetActions() {
-		pubs, err := types.RecoverMultiKey(signer, action, tx)
+	payerVersion := make(map[common.Name]common.Hash)
+	if payer := tx.Payer(); payer != "" {
+		signSender, recoverRes, err := am.recoverPayer(signer, tx)
 		if err != nil {
 			return err
 		}
-
-		if uint64(len(pubs)) > params.MaxSignLength {
-			return fmt.Errorf("exceed max sign length, want most %d, actual is %d", params.MaxSignLength, len(pubs))
+		for name, acctAuthor := range recoverRes.acctAuthors {
+			count := acctAuthor.weight()
+			threshold := acctAuthor.threshold
+			if name.String() == signSender.String() && signSender != payer {
+				threshold = acctAuthor.updateAuthorThreshold
+			}
+			if count < threshold {
+				return fmt.Errorf("payer %s want threshold %d, but actual is %d", name, threshold, count)
+			}
+			payerVersion[name] = acctAuthor.version
 		}
+	}
 
-		parentIndex := action.GetSignParent()
-		signSender, err := am.getParentAccount(action.Sender(), parentIndex)
+	for _, action := range tx.GetActions() {
+		signSender, recoverRes, err := am.recoverAction(signer, action, tx)
 		if err != nil {
 			return err
 		}
-		recoverRes := &recoverActionResult{make(map[common.Name]*accountAuthor)}
-		for i, pub := range pubs {
-			index := action.GetSignIndex(uint64(i))
-			if uint64(len(index)) > params.MaxSignDepth {
-				return fmt.Errorf("exceed max sign depth, want most %d, actual is %d", params.MaxSignDepth, len(index))
-			}
-
-			if err := am.ValidSign(signSender, pub, index, recoverRes); err != nil {
-				return err
-			}
-		}
 
+		// the payer authors are part of the cache of every action, the
+		// signatures are checked again once any of them is modified.
 		authorVersion := make(map[common.Name]common.Hash)
+		for name, version := range payerVersion {
+			authorVersion[name] = version
+		}
 		for name, acctAuthor := range recoverRes.acctAuthors {
-			var count uint64
-			for _, weight := range acctAuthor.indexWeight {
-				count += weight
-			}
-			threshold := acctAuthor.threshold
-			if name.String() == signSender.String() && (action.Type() == types.UpdateAccountAuthor || signSender != action.Sender()) {
-				threshold = acctAuthor.updateAuthorThreshold
-			}
+			count := acctAuthor.weight()
+			threshold := acctAuthor.actionThreshold(name, signSender, action)
 			if count < threshold {
 				return fmt.Errorf("account %s want threshold %d, but actual is %d", name, threshold, count)
 			}
@@ -667,6 +787,103 @@ func (am *AccountManager) RecoverTx(signer types.Signer, tx *types.Transaction)
 	return nil
 }
 
+// SignWeights returns the weight reached by the signatures of the action for
+// every account on the signature paths. Unlike RecoverTx it doesn't require
+// the thresholds to be met, it is used to follow the collection of the
+// signatures of a partially signed transaction.
+func (am *AccountManager) SignWeights(signer types.Signer, action *types.Action, tx *types.Transaction) ([]*AuthorWeight, error) {
+	if len(action.GetSign()) == 0 {
+		acct, err := am.GetAccountByName(action.Sender())
+		if err != nil {
+			return nil, err
+		}
+		if acct == nil {
+			return nil, ErrAccountNotExist
+		}
+		threshold := acct.GetThreshold()
+		if action.Type() == types.UpdateAccountAuthor {
+			threshold = acct.GetUpdateAuthorThreshold()
+		}
+		return []*AuthorWeight{{Account: acct.GetName(), Threshold: threshold}}, nil
+	}
+
+	signSender, recoverRes, err := am.recoverAction(signer, action, tx)
+	if err != nil {
+		return nil, err
+	}
+	weights := make([]*AuthorWeight, 0, len(recoverRes.acctAuthors))
+	for name, acctAuthor := range recoverRes.acctAuthors {
+		weights = append(weights, &AuthorWeight{
+			Account:   name,
+			Weight:    acctAuthor.weight(),
+			Threshold: acctAuthor.actionThreshold(name, signSender, action),
+		})
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i].Account < weights[j].Account })
+	return weights, nil
+}
+
+// recoverAction recovers the public keys of the signatures of the action and
+// validates them against the authors of the accounts on their index paths.
+func (am *AccountManager) recoverAction(signer types.Signer, action *types.Action, tx *types.Transaction) (common.Name, *recoverActionResult, error) {
+	pubs, err := types.RecoverMultiKey(signer, action, tx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if uint64(len(pubs)) > params.MaxSignLength {
+		return "", nil, fmt.Errorf("exceed max sign length, want most %d, actual is %d", params.MaxSignLength, len(pubs))
+	}
+
+	parentIndex := action.GetSignParent()
+	signSender, err := am.getParentAccount(action.Sender(), parentIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	recoverRes := &recoverActionResult{make(map[common.Name]*accountAuthor)}
+	for i, pub := range pubs {
+		index := action.GetSignIndex(uint64(i))
+		if uint64(len(index)) > params.MaxSignDepth {
+			return "", nil, fmt.Errorf("exceed max sign depth, want most %d, actual is %d", params.MaxSignDepth, len(index))
+		}
+
+		if err := am.ValidSign(signSender, pub, index, recoverRes); err != nil {
+			return "", nil, err
+		}
+	}
+	return signSender, recoverRes, nil
+}
+
+// recoverPayer recovers the public keys of the payer signatures of the
+// transaction and validates them against the authors of the payer.
+func (am *AccountManager) recoverPayer(signer types.Signer, tx *types.Transaction) (common.Name, *recoverActionResult, error) {
+	pubs, err := types.RecoverPayerMultiKey(signer, tx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if uint64(len(pubs)) > params.MaxSignLength {
+		return "", nil, fmt.Errorf("exceed max sign length, want most %d, actual is %d", params.MaxSignLength, len(pubs))
+	}
+
+	signSender, err := am.getParentAccount(tx.Payer(), tx.GetPayerSignParent())
+	if err != nil {
+		return "", nil, err
+	}
+	recoverRes := &recoverActionResult{make(map[common.Name]*accountAuthor)}
+	for i, pub := range pubs {
+		index := tx.GetPayerSignIndex(uint64(i))
+		if uint64(len(index)) > params.MaxSignDepth {
+			return "", nil, fmt.Errorf("exceed max sign depth, want most %d, actual is %d", params.MaxSignDepth, len(index))
+		}
+
+		if err := am.ValidSign(signSender, pub, index, recoverRes); err != nil {
+			return "", nil, err
+		}
+	}
+	return signSender, recoverRes, nil
+}
+
 // IsValidSign
 func (am *AccountManager) IsValidSign(accountName common.Name, pub common.PubKey) error {
 	acct, err := am.GetAccountByName(accountName)
@@ -766,12 +983,19 @@ func (am *AccountManager) GetAssetInfoByName(assetName string) (*asset.AssetObje
 	if err != nil {
 		return nil, err
 	}
-	return am.ast.GetAssetObjectById(assetID)
+	return am.GetAssetInfoByID(assetID)
 }
 
-//GetAssetInfoByID get asset info by assetID
+//GetAssetInfoByID get asset info with its transfer controls by assetID
 func (am *AccountManager) GetAssetInfoByID(assetID uint64) (*asset.AssetObject, error) {
-	return am.ast.GetAssetObjectById(assetID)
+	assetObj, err := am.ast.GetAssetObjectById(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := am.ast.LoadAssetControl(assetObj); err != nil {
+		return nil, err
+	}
+	return assetObj, nil
 }
 
 // GetAllAssetbyAssetId get accout asset and subAsset Info
@@ -842,7 +1066,8 @@ func (am *AccountManager) GetAllBalancebyAssetID(acct *Account, assetID uint64)
 	return ba, nil
 }
 
-//GetBalanceByTime get account balance by Time
+//GetBalanceByTime get account balance by Time, the balance locked by vesting
+//schedules is counted, it is held by the account and only can't be spent.
 func (am *AccountManager) GetBalanceByTime(accountName common.Name, assetID uint64, typeID uint64, time uint64) (*big.Int, error) {
 	acct, err := am.GetAccountByTime(accountName, time)
 	if err != nil {
@@ -1021,7 +1246,18 @@ func (am *AccountManager) EnoughAccountBalance(accountName common.Name, assetID
 	if value.Cmp(big.NewInt(0)) < 0 {
 		return ErrAmountValueInvalid
 	}
-	return acct.EnoughAccountBalance(assetID, value)
+	balance, err := acct.GetBalanceByID(assetID)
+	if err != nil {
+		return err
+	}
+	spendable, err := am.spendableBalance(accountName, assetID, balance)
+	if err != nil {
+		return err
+	}
+	if spendable.Cmp(value) < 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
 }
 
 //
@@ -1099,19 +1335,26 @@ func (am *AccountManager) GetCodeSize(accountName common.Name) (uint64, error) {
 // 	return &acct, nil
 // }
 
-// CanTransfer check if can transfer.
+// CheckTransferControl checks the transfer against the asset controls from
+// ForkID4 on. The gas, fee and dpos payments of the chain are not checked,
+// nor are zero value transfers which move nothing.
+func (am *AccountManager) CheckTransferControl(fromAccount common.Name, toAccount common.Name, assetID uint64, value *big.Int, curForkID uint64) error {
+	if curForkID < params.ForkID4 || value.Sign() == 0 {
+		return nil
+	}
+	return am.ast.CheckTransferControl(assetID, fromAccount, toAccount)
+}
+
+// CanTransfer check if can transfer, the locked balance can't be transferred.
 func (am *AccountManager) CanTransfer(accountName common.Name, assetID uint64, value *big.Int) (bool, error) {
-	acct, err := am.GetAccountByName(accountName)
-	if err != nil {
+	if err := am.EnoughAccountBalance(accountName, assetID, value); err != nil {
 		return false, err
 	}
-	if err = acct.EnoughAccountBalance(assetID, value); err == nil {
-		return true, nil
-	}
-	return false, err
+	return true, nil
 }
 
-//TransferAsset transfer asset
+// TransferAsset transfer asset, the asset controls are checked by the callers
+// moving the asset on behalf of an account, see CheckTransferControl.
 func (am *AccountManager) TransferAsset(fromAccount common.Name, toAccount common.Name, assetID uint64, value *big.Int, fromAccountExtra ...common.Name) error {
 	if sign := value.Sign(); sign == 0 {
 		return nil
@@ -1146,6 +1389,13 @@ func (am *AccountManager) TransferAsset(fromAccount common.Name, toAccount commo
 	if val.Cmp(big.NewInt(0)) < 0 || val.Cmp(value) < 0 {
 		return ErrInsufficientBalance
 	}
+	spendable, err := am.spendableBalance(fromAccount, assetID, val)
+	if err != nil {
+		return err
+	}
+	if spendable.Cmp(value) < 0 {
+		return ErrInsufficientBalance
+	}
 
 	if fromAccount == toAccount || value.Cmp(big.NewInt(0)) == 0 {
 		return nil
@@ -1370,7 +1620,18 @@ func (am *AccountManager) process(accountManagerContext *types.AccountManagerCon
 	}
 
 	var internalActions []*types.InternalAction
-	//transfer
+	//transfer, the value of a new account is forwarded to it
+	recipient := action.Recipient()
+	if action.Type() == types.CreateAccount && action.Value().Sign() > 0 {
+		var acct CreateAccountAction
+		if err := rlp.DecodeBytes(action.Data(), &acct); err != nil {
+			return nil, err
+		}
+		recipient = acct.AccountName
+	}
+	if err := am.CheckTransferControl(action.Sender(), recipient, action.AssetID(), action.Value(), curForkID); err != nil {
+		return nil, err
+	}
 	if err := am.TransferAsset(action.Sender(), action.Recipient(), action.AssetID(), action.Value(), fromAccountExtra...); err != nil {
 		return nil, err
 	}
@@ -1406,6 +1667,70 @@ func (am *AccountManager) process(accountManagerContext *types.AccountManagerCon
 		if err := am.UpdateAccount(action.Sender(), &acct); err != nil {
 			return nil, err
 		}
+	case types.DeleteAccount:
+		if curForkID < params.ForkID3 {
+			break
+		}
+		var del DeleteAccountAction
+		if err := rlp.DecodeBytes(action.Data(), &del); err != nil {
+			return nil, err
+		}
+		// the account is destroyed once the transaction is done, see
+		// DestroyAccount, the gas must be refunded first.
+		if err := am.CheckDeleteAccount(action.Sender(), del.Beneficiary); err != nil {
+			return nil, err
+		}
+	case types.SetSponsorship:
+		if curForkID < params.ForkID3 {
+			return nil, ErrUnkownTxType
+		}
+		var sponsorship SponsorshipAction
+		if err := rlp.DecodeBytes(action.Data(), &sponsorship); err != nil {
+			return nil, err
+		}
+		if err := am.SetSponsorship(action.Sender(), &sponsorship); err != nil {
+			return nil, err
+		}
+	case types.SetRecovery:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var config RecoveryConfig
+		if err := rlp.DecodeBytes(action.Data(), &config); err != nil {
+			return nil, err
+		}
+		if err := am.SetRecovery(action.Sender(), &config); err != nil {
+			return nil, err
+		}
+	case types.ProposeRecovery:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var proposal RecoveryAction
+		if err := rlp.DecodeBytes(action.Data(), &proposal); err != nil {
+			return nil, err
+		}
+		if err := am.ProposeRecovery(action.Sender(), &proposal, accountManagerContext.Time); err != nil {
+			return nil, err
+		}
+	case types.CancelRecovery:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		if err := am.CancelRecovery(action.Sender()); err != nil {
+			return nil, err
+		}
+	case types.ExecuteRecovery:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var execute ExecuteRecoveryAction
+		if err := rlp.DecodeBytes(action.Data(), &execute); err != nil {
+			return nil, err
+		}
+		if err := am.ExecuteRecovery(execute.Account, accountManagerContext.Time); err != nil {
+			return nil, err
+		}
 	case types.UpdateAccountAuthor:
 		var acctAuth AccountAuthorAction
 		err := rlp.DecodeBytes(action.Data(), &acctAuth)
@@ -1470,6 +1795,9 @@ func (am *AccountManager) process(accountManagerContext *types.AccountManagerCon
 		internalActions = append(internalActions, internalAction)
 
 		fromAccountExtra = append(fromAccountExtra, action.Sender())
+		if err := am.CheckTransferControl(action.Sender(), inc.To, inc.AssetId, inc.Amount, curForkID); err != nil {
+			return nil, err
+		}
 		if err := am.TransferAsset(common.Name(accountManagerContext.ChainConfig.AssetName), inc.To, inc.AssetId, inc.Amount, fromAccountExtra...); err != nil {
 			return nil, err
 		}
@@ -1557,7 +1885,69 @@ func (am *AccountManager) process(accountManagerContext *types.AccountManagerCon
 		if err := am.ast.SetAssetNewContract(assetContract.AssetID, assetContract.Contract); err != nil {
 			return nil, err
 		}
-
+	case types.FreezeAsset:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var freeze FreezeAsset
+		if err := rlp.DecodeBytes(action.Data(), &freeze); err != nil {
+			return nil, err
+		}
+		if err := am.ast.CheckOwner(action.Sender(), freeze.AssetID); err != nil {
+			return nil, err
+		}
+		if err := am.ast.SetAccountFrozen(freeze.AssetID, freeze.Account, freeze.Frozen); err != nil {
+			return nil, err
+		}
+	case types.PauseAsset:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var pause PauseAsset
+		if err := rlp.DecodeBytes(action.Data(), &pause); err != nil {
+			return nil, err
+		}
+		if err := am.ast.CheckOwner(action.Sender(), pause.AssetID); err != nil {
+			return nil, err
+		}
+		if err := am.ast.SetAssetPaused(pause.AssetID, pause.Paused); err != nil {
+			return nil, err
+		}
+	case types.SetAssetWhitelist:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var whitelist AssetWhitelist
+		if err := rlp.DecodeBytes(action.Data(), &whitelist); err != nil {
+			return nil, err
+		}
+		if err := am.ast.CheckOwner(action.Sender(), whitelist.AssetID); err != nil {
+			return nil, err
+		}
+		if err := am.ast.SetAssetWhitelistMode(whitelist.AssetID, whitelist.Enabled); err != nil {
+			return nil, err
+		}
+		for _, name := range whitelist.Accounts {
+			if err := am.ast.SetAccountWhitelisted(whitelist.AssetID, name, true); err != nil {
+				return nil, err
+			}
+		}
+		for _, name := range whitelist.Remove {
+			if err := am.ast.SetAccountWhitelisted(whitelist.AssetID, name, false); err != nil {
+				return nil, err
+			}
+		}
+	case types.TransferLocked:
+		if curForkID < params.ForkID4 {
+			return nil, ErrUnkownTxType
+		}
+		var lock TransferLockedAction
+		if err := rlp.DecodeBytes(action.Data(), &lock); err != nil {
+			return nil, err
+		}
+		if err := am.AddVestingSchedule(action.Sender(), action.Recipient(), action.AssetID(), action.Value(), &lock, accountManagerContext.Time); err != nil {
+			return nil, err
+		}
 	case types.Transfer:
 	default:
 		return nil, ErrUnkownTxType
@@ -2159,3 +2159,271 @@ func Test_IssueAssetForkID1(t *testing.T) {
 		}
 	}
 }
+
+func TestAccountManager_DestroyAccount(t *testing.T) {
+	am, err := NewAccountManager(getStateDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, _ := GeneragePubKey()
+	parent, sub, ben := common.Name("deleteparent1"), common.Name("deleteparent1.sub"), common.Name("deletebenefit")
+	for _, name := range []common.Name{parent, sub, ben} {
+		from := common.Name("fractal.founder")
+		if name == sub {
+			from = parent
+		}
+		if err := am.CreateAccount(from, name, "", 0, params.ForkID1, pubkey, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := am.AddAccountBalanceByID(sub, 1, big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.AddAccountBalanceByID(ben, 1, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := am.CheckDeleteAccount(sub, sub); err != ErrInvalidBeneficiary {
+		t.Fatalf("want %v, got %v", ErrInvalidBeneficiary, err)
+	}
+	if err := am.CheckDeleteAccount(sub, "deletenotexist"); err == nil {
+		t.Fatal("beneficiary doesn't exist")
+	}
+
+	internalActions, err := am.DestroyAccount(sub, ben, params.ForkID3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(internalActions) != 1 || internalActions[0].Action.Amount.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("unexpected sweep %v", internalActions)
+	}
+	if balance, _ := am.GetAccountBalanceByID(ben, 1, 0); balance.Cmp(big.NewInt(101)) != 0 {
+		t.Fatalf("beneficiary balance want 101, got %v", balance)
+	}
+
+	// the names stay reserved
+	if acct, _ := am.GetAccountByName(sub); acct == nil || !acct.IsDestroyed() {
+		t.Fatal("sub account not destroyed")
+	}
+	if err := am.CreateAccount(parent, sub, "", 0, params.ForkID1, pubkey, ""); err != ErrAccountIsExist {
+		t.Fatalf("want %v, got %v", ErrAccountIsExist, err)
+	}
+	if _, err := am.DestroyAccount(sub, ben, params.ForkID3); err != ErrAccountIsDestroy {
+		t.Fatalf("want %v, got %v", ErrAccountIsDestroy, err)
+	}
+	if _, err := am.DestroyAccount(parent, ben, params.ForkID3); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CreateAccount("fractal.founder", parent, "", 0, params.ForkID1, pubkey, ""); err != ErrAccountIsExist {
+		t.Fatalf("want %v, got %v", ErrAccountIsExist, err)
+	}
+}
+
+func TestAccountManager_Sponsorship(t *testing.T) {
+	am, err := NewAccountManager(getStateDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderPub, senderKey := GeneragePubKey()
+	payerPub, payerKey := GeneragePubKey()
+	sender, payer := common.Name("sponsoredsender"), common.Name("sponsorpayer")
+	if err := am.CreateAccount("fractal.founder", sender, "", 0, params.ForkID1, senderPub, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CreateAccount("fractal.founder", payer, "", 0, params.ForkID1, payerPub, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	signer := types.NewSigner(big.NewInt(1))
+	newTx := func(to common.Name, key *ecdsa.PrivateKey) *types.Transaction {
+		action := types.NewAction(types.Transfer, sender, to, 0, 1, 100, big.NewInt(0), nil, nil)
+		tx := types.NewTransaction(1, big.NewInt(10), action)
+		tx.WithPayer(payer)
+		if err := types.SignActionWithMultiKey(action, tx, signer, 0, []*types.KeyPair{types.MakeKeyPair(senderKey, []uint64{0})}); err != nil {
+			t.Fatal(err)
+		}
+		if err := types.SignPayerWithMultiKey(tx, signer, 0, []*types.KeyPair{types.MakeKeyPair(key, []uint64{0})}); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	tx := newTx(payer, payerKey)
+	if err := am.RecoverTx(signer, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := types.GetAuthorCache(tx.GetActions()[0])[payer]; !ok {
+		t.Fatal("payer author version not cached")
+	}
+	if err := am.RecoverTx(signer, newTx(payer, senderKey)); err == nil {
+		t.Fatal("payer signature not checked")
+	}
+
+	// without a sponsorship record the payer signature is enough
+	if err := am.CheckSponsorship(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.SetSponsorship(payer, &SponsorshipAction{MaxFee: big.NewInt(999), Recipients: []common.Name{payer}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CheckSponsorship(tx); err != ErrSponsorshipFeeExceeded {
+		t.Fatalf("want %v, got %v", ErrSponsorshipFeeExceeded, err)
+	}
+	if err := am.SetSponsorship(payer, &SponsorshipAction{MaxFee: big.NewInt(1000), Recipients: []common.Name{payer}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CheckSponsorship(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CheckSponsorship(newTx(sender, payerKey)); err != ErrSponsorshipRecipient {
+		t.Fatalf("want %v, got %v", ErrSponsorshipRecipient, err)
+	}
+
+	// an empty record removes the limits
+	if err := am.SetSponsorship(payer, &SponsorshipAction{}); err != nil {
+		t.Fatal(err)
+	}
+	if sponsorship, err := am.GetSponsorship(payer); err != nil || sponsorship != nil {
+		t.Fatalf("sponsorship not removed %v %v", sponsorship, err)
+	}
+	if err := am.CheckSponsorship(newTx(sender, payerKey)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAccountManager_AssetTransferControl(t *testing.T) {
+	am, err := NewAccountManager(getStateDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, holder, other, fee := common.Name("controlowner"), common.Name("controlholder"), common.Name("controlother"), common.Name("controlsystem")
+	for _, name := range []common.Name{owner, holder, other, fee} {
+		pub, _ := GeneragePubKey()
+		if err := am.CreateAccount("fractal.founder", name, "", 0, params.ForkID1, pub, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assetID, err := am.ast.IssueAsset("controltoken", 0, params.ForkID1, "ctk", big.NewInt(1000), 0, owner, owner, big.NewInt(0), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.AddAccountBalanceByID(owner, assetID, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.TransferAsset(owner, holder, assetID, big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+
+	process := func(from common.Name, aType types.ActionType, payload interface{}, forkID uint64) error {
+		b, err := rlp.EncodeToBytes(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		action := types.NewAction(aType, from, common.Name(params.DefaultChainconfig.AssetName), 0, assetID, 0, big.NewInt(0), b, nil)
+		_, err = am.Process(&types.AccountManagerContext{Action: action, ChainConfig: params.DefaultChainconfig, CurForkID: forkID})
+		return err
+	}
+	transfer := func(from, to common.Name) error {
+		if err := am.CheckTransferControl(from, to, assetID, big.NewInt(1), params.ForkID4); err != nil {
+			return err
+		}
+		return am.TransferAsset(from, to, assetID, big.NewInt(1))
+	}
+
+	freeze := &FreezeAsset{AssetID: assetID, Account: holder, Frozen: true}
+	if err := process(owner, types.FreezeAsset, freeze, params.ForkID3); err != ErrUnkownTxType {
+		t.Fatalf("want %v, got %v", ErrUnkownTxType, err)
+	}
+	if err := process(holder, types.FreezeAsset, freeze, params.ForkID4); err != asset.ErrOwnerMismatch {
+		t.Fatalf("want %v, got %v", asset.ErrOwnerMismatch, err)
+	}
+	if err := process(owner, types.FreezeAsset, &FreezeAsset{AssetID: assetID, Account: owner, Frozen: true}, params.ForkID4); err != asset.ErrFreezeOwner {
+		t.Fatalf("want %v, got %v", asset.ErrFreezeOwner, err)
+	}
+	if err := process(owner, types.FreezeAsset, freeze, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(holder, other); err != asset.ErrAccountFrozen {
+		t.Fatalf("want %v, got %v", asset.ErrAccountFrozen, err)
+	}
+	if err := transfer(owner, holder); err != asset.ErrAccountFrozen {
+		t.Fatalf("want %v, got %v", asset.ErrAccountFrozen, err)
+	}
+	if err := am.CheckTransferControl(holder, other, assetID, big.NewInt(1), params.ForkID3); err != nil {
+		t.Fatal(err)
+	}
+	// gas payments and refunds of a frozen account are not checked
+	if err := am.TransferAsset(holder, fee, assetID, big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.TransferAsset(fee, holder, assetID, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	// the balance doesn't leave through a new account or the account deletion
+	pub, _ := GeneragePubKey()
+	create, err := rlp.EncodeToBytes(&CreateAccountAction{AccountName: "controlescape", PublicKey: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := types.NewAction(types.CreateAccount, holder, common.Name(params.DefaultChainconfig.AccountName), 0, assetID, 0, big.NewInt(1), create, nil)
+	if _, err := am.Process(&types.AccountManagerContext{Action: action, ChainConfig: params.DefaultChainconfig, CurForkID: params.ForkID4}); err != asset.ErrAccountFrozen {
+		t.Fatalf("want %v, got %v", asset.ErrAccountFrozen, err)
+	}
+	if _, err := am.DestroyAccount(holder, other, params.ForkID4); err != asset.ErrAccountFrozen {
+		t.Fatalf("want %v, got %v", asset.ErrAccountFrozen, err)
+	}
+	freeze.Frozen = false
+	if err := process(owner, types.FreezeAsset, freeze, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(holder, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// a paused asset only leaves the owner
+	if err := process(owner, types.PauseAsset, &PauseAsset{AssetID: assetID, Paused: true}, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := am.GetAssetInfoByID(assetID); err != nil || !info.Paused {
+		t.Fatalf("asset not paused %v %v", info, err)
+	}
+	if err := transfer(holder, other); err != asset.ErrAssetPaused {
+		t.Fatalf("want %v, got %v", asset.ErrAssetPaused, err)
+	}
+	if err := transfer(owner, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.TransferAsset(fee, holder, assetID, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := process(owner, types.PauseAsset, &PauseAsset{AssetID: assetID}, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+
+	whitelist := &AssetWhitelist{AssetID: assetID, Enabled: true, Accounts: []common.Name{holder}}
+	if err := process(owner, types.SetAssetWhitelist, whitelist, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := am.GetAssetInfoByName("controltoken"); err != nil || !info.WhitelistMode || info.Paused {
+		t.Fatalf("asset not in whitelist mode %v %v", info, err)
+	}
+	if err := transfer(holder, owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(holder, other); err != asset.ErrNotWhitelisted {
+		t.Fatalf("want %v, got %v", asset.ErrNotWhitelisted, err)
+	}
+	if err := transfer(other, owner); err != asset.ErrNotWhitelisted {
+		t.Fatalf("want %v, got %v", asset.ErrNotWhitelisted, err)
+	}
+	whitelist = &AssetWhitelist{AssetID: assetID, Enabled: true, Accounts: []common.Name{other}, Remove: []common.Name{holder}}
+	if err := process(owner, types.SetAssetWhitelist, whitelist, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(holder, other); err != asset.ErrNotWhitelisted {
+		t.Fatalf("want %v, got %v", asset.ErrNotWhitelisted, err)
+	}
+	if err := transfer(other, owner); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -52,4 +52,19 @@ var (
 	ErrNegativeAmount         = errors.New("negative amount")
 	ErrAmountMustBeZero       = errors.New("amount must be zero")
 	ErrAssetOwnerInvaild      = errors.New("asset owner invalid")
+	ErrAccountHasCode         = errors.New("account has code")
+	ErrInvalidBeneficiary     = errors.New("invalid beneficiary")
+	ErrSponsorshipFeeExceeded = errors.New("transaction fee exceeds sponsorship")
+	ErrSponsorshipRecipient   = errors.New("recipient not sponsored")
+	ErrInvalidVesting         = errors.New("invalid vesting schedule")
+	ErrVestingAmountTooSmall  = errors.New("vesting amount less than one unit of the asset")
+	ErrVestingLimit           = errors.New("too many vesting schedules")
+	ErrAccountLocked          = errors.New("account has locked balance")
+	ErrInvalidRecovery        = errors.New("invalid account recovery")
+	ErrInvalidGuardian        = errors.New("invalid recovery guardian")
+	ErrRecoveryNotSet         = errors.New("account recovery not set")
+	ErrRecoveryNotExist       = errors.New("account recovery request not exist")
+	ErrRecoveryPending        = errors.New("account recovery request is pending")
+	ErrRecoveryApproved       = errors.New("account recovery already approved")
+	ErrRecoveryNotReady       = errors.New("account recovery delay not over")
 )
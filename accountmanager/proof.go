@@ -0,0 +1,164 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// StorageProof is the merkle proof of a contract storage slot.
+type StorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value common.Hash     `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// AccountProof is the merkle proof of an account against a state root. The
+// account record carries the balances of every asset of the account.
+type AccountProof struct {
+	AccountName  common.Name     `json:"accountName"`
+	AccountID    uint64          `json:"accountID"`
+	IDProof      []hexutil.Bytes `json:"idProof"`
+	Account      hexutil.Bytes   `json:"account"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []*StorageProof `json:"storageProof"`
+}
+
+// AccountNameIDKey returns the key of the account id stored under the
+// account manager name.
+func AccountNameIDKey(accountName common.Name) string {
+	return accountNameIDPrefix + accountName.String()
+}
+
+// AccountInfoKey returns the key of the account record stored under the
+// account manager name.
+func AccountInfoKey(accountID uint64) string {
+	return acctInfoPrefix + strconv.FormatUint(accountID, 10)
+}
+
+// GetAccountProof returns the merkle proofs of the account and of its storage
+// slots, the state must not be modified since it was opened.
+func (am *AccountManager) GetAccountProof(accountName common.Name, keys []common.Hash) (*AccountProof, error) {
+	proof := &AccountProof{AccountName: accountName}
+	nodes, err := am.sdb.GetProof(state.DataKey(acctManagerName, AccountNameIDKey(accountName)))
+	if err != nil {
+		return nil, err
+	}
+	proof.IDProof = toHexBytes(nodes)
+
+	accountID, err := am.GetAccountIDByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+	if accountID == 0 {
+		// the id proof proves the absence of the account
+		return proof, nil
+	}
+	proof.AccountID = accountID
+	if proof.Account, err = am.sdb.Get(acctManagerName, AccountInfoKey(accountID)); err != nil {
+		return nil, err
+	}
+	if nodes, err = am.sdb.GetProof(state.DataKey(acctManagerName, AccountInfoKey(accountID))); err != nil {
+		return nil, err
+	}
+	proof.AccountProof = toHexBytes(nodes)
+
+	for _, key := range keys {
+		nodes, err := am.sdb.GetProof(state.StateKey(accountName.String(), key))
+		if err != nil {
+			return nil, err
+		}
+		proof.StorageProof = append(proof.StorageProof, &StorageProof{
+			Key:   key,
+			Value: am.sdb.GetState(accountName.String(), key),
+			Proof: toHexBytes(nodes),
+		})
+	}
+	return proof, nil
+}
+
+// VerifyAccountProof checks the proofs against the state root of a chain
+// whose account manager is accountManagerName. It returns the proved account,
+// nil if the proof shows the account doesn't exist.
+func VerifyAccountProof(root common.Hash, accountManagerName string, proof *AccountProof) (*Account, error) {
+	value, err := state.VerifyProof(root, state.DataKey(accountManagerName, AccountNameIDKey(proof.AccountName)), fromHexBytes(proof.IDProof))
+	if err != nil {
+		return nil, fmt.Errorf("invalid id proof: %v", err)
+	}
+	if len(value) == 0 {
+		if proof.AccountID != 0 {
+			return nil, errors.New("id proof shows the account doesn't exist")
+		}
+		return nil, nil
+	}
+	var accountID uint64
+	if err := rlp.DecodeBytes(value, &accountID); err != nil {
+		return nil, err
+	}
+	if accountID != proof.AccountID {
+		return nil, fmt.Errorf("account id mismatch, proved %v, got %v", accountID, proof.AccountID)
+	}
+
+	value, err = state.VerifyProof(root, state.DataKey(accountManagerName, AccountInfoKey(accountID)), fromHexBytes(proof.AccountProof))
+	if err != nil {
+		return nil, fmt.Errorf("invalid account proof: %v", err)
+	}
+	if len(value) == 0 || string(value) != string(proof.Account) {
+		return nil, errors.New("account record mismatch")
+	}
+	var account Account
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return nil, err
+	}
+	if account.AcctName != proof.AccountName {
+		return nil, fmt.Errorf("account name mismatch, proved %v, got %v", account.AcctName, proof.AccountName)
+	}
+
+	for _, sp := range proof.StorageProof {
+		value, err := state.VerifyProof(root, state.StateKey(proof.AccountName.String(), sp.Key), fromHexBytes(sp.Proof))
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage proof of %v: %v", sp.Key.Hex(), err)
+		}
+		if common.BytesToHash(value) != sp.Value {
+			return nil, fmt.Errorf("storage value mismatch of %v", sp.Key.Hex())
+		}
+	}
+	return &account, nil
+}
+
+func toHexBytes(nodes [][]byte) []hexutil.Bytes {
+	result := make([]hexutil.Bytes, len(nodes))
+	for i, node := range nodes {
+		result[i] = node
+	}
+	return result
+}
+
+func fromHexBytes(nodes []hexutil.Bytes) [][]byte {
+	result := make([][]byte, len(nodes))
+	for i, node := range nodes {
+		result[i] = node
+	}
+	return result
+}
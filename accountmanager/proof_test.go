@@ -0,0 +1,74 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/state"
+	memdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountProof(t *testing.T) {
+	db := memdb.NewMemDatabase()
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(db))
+	assert.NoError(t, err)
+	am, err := NewAccountManager(statedb)
+	assert.NoError(t, err)
+
+	pubkey, _ := GeneragePubKey()
+	name := common.Name("proofaccount")
+	key, value := common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0x02})
+	assert.NoError(t, am.CreateAccount(common.Name("fractal.founder"), name, common.Name(""), 0, 0, pubkey, ""))
+	statedb.SetState(name.String(), key, value)
+
+	batch := db.NewBatch()
+	root, err := statedb.Commit(batch, common.Hash{}, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, statedb.Database().TrieDB().Commit(root, false))
+	assert.NoError(t, batch.Write())
+
+	statedb, err = state.New(root, state.NewDatabase(db))
+	assert.NoError(t, err)
+	am, err = NewAccountManager(statedb)
+	assert.NoError(t, err)
+
+	proof, err := am.GetAccountProof(name, []common.Hash{key, common.BytesToHash([]byte{0x03})})
+	assert.NoError(t, err)
+	account, err := VerifyAccountProof(root, acctManagerName, proof)
+	assert.NoError(t, err)
+	assert.Equal(t, name, account.AcctName)
+	assert.Equal(t, value, proof.StorageProof[0].Value)
+	assert.Equal(t, common.Hash{}, proof.StorageProof[1].Value)
+
+	// a tampered value or another root is rejected
+	proof.StorageProof[0].Value = common.BytesToHash([]byte{0x04})
+	_, err = VerifyAccountProof(root, acctManagerName, proof)
+	assert.Error(t, err)
+	proof.StorageProof[0].Value = value
+	_, err = VerifyAccountProof(common.BytesToHash([]byte{0x05}), acctManagerName, proof)
+	assert.Error(t, err)
+
+	// the absence of an account is proved as well
+	proof, err = am.GetAccountProof(common.Name("notexistaccount"), nil)
+	assert.NoError(t, err)
+	account, err = VerifyAccountProof(root, acctManagerName, proof)
+	assert.NoError(t, err)
+	assert.Nil(t, account)
+}
@@ -0,0 +1,365 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+const (
+	recoveryConfigPrefix  = "recoveryConfig"
+	recoveryRequestPrefix = "recoveryRequest"
+	recoveryVotePrefix    = "recoveryVote"
+	// minRecoveryDelay leaves the current authors time to cancel a recovery.
+	minRecoveryDelay = uint64(24 * 3600)
+	// maxRecoveryDelay keeps the recovery ready time from overflowing.
+	maxRecoveryDelay = uint64(10 * 365 * 24 * 3600)
+)
+
+// RecoveryConfig is the payload of the SetRecovery action, Threshold of the
+// guardians can replace the authors of the account, the new authors taking
+// effect Delay seconds later unless the account cancels the recovery. Delay
+// is at least a day. An empty Guardians list removes the recovery.
+type RecoveryConfig struct {
+	Guardians []common.Name `json:"guardians,omitempty"`
+	Threshold uint64        `json:"threshold"`
+	Delay     uint64        `json:"delay"`
+}
+
+// RecoveryAction is the payload of the ProposeRecovery action, the new
+// author set proposed by a guardian of the account.
+type RecoveryAction struct {
+	Account               common.Name      `json:"account"`
+	Threshold             uint64           `json:"threshold"`
+	UpdateAuthorThreshold uint64           `json:"updateAuthorThreshold"`
+	Authors               []*common.Author `json:"authors"`
+}
+
+// ExecuteRecoveryAction is the payload of the ExecuteRecovery action.
+type ExecuteRecoveryAction struct {
+	Account common.Name `json:"account"`
+}
+
+// RecoveryRequest is the author set approved by the guardians threshold of
+// an account, it can be executed from ReadyTime on.
+type RecoveryRequest struct {
+	RecoveryAction
+	Approvals []common.Name `json:"approvals"`
+	ReadyTime uint64        `json:"readyTime"`
+}
+
+func (r *RecoveryAction) equal(other *RecoveryAction) bool {
+	if r.Threshold != other.Threshold || r.UpdateAuthorThreshold != other.UpdateAuthorThreshold || len(r.Authors) != len(other.Authors) {
+		return false
+	}
+	for i, author := range r.Authors {
+		if author.Owner.String() != other.Authors[i].Owner.String() || author.GetWeight() != other.Authors[i].GetWeight() {
+			return false
+		}
+	}
+	return true
+}
+
+func recoveryVoteKey(accountName common.Name, guardian common.Name) string {
+	return recoveryVotePrefix + accountName.String() + ":" + guardian.String()
+}
+
+func (am *AccountManager) checkRecoverable(accountName common.Name) (*Account, error) {
+	acct, err := am.GetAccountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+	if acct == nil {
+		return nil, ErrAccountNotExist
+	}
+	if acct.IsDestroyed() {
+		return nil, ErrAccountIsDestroy
+	}
+	return acct, nil
+}
+
+// clearRecovery drops the pending recovery request of the account and the
+// votes of its guardians.
+func (am *AccountManager) clearRecovery(accountName common.Name) error {
+	config, err := am.GetRecoveryConfig(accountName)
+	if err != nil {
+		return err
+	}
+	if config != nil {
+		for _, guardian := range config.Guardians {
+			am.sdb.Delete(acctManagerName, recoveryVoteKey(accountName, guardian))
+		}
+	}
+	am.sdb.Delete(acctManagerName, recoveryRequestPrefix+accountName.String())
+	return nil
+}
+
+// SetRecovery sets the guardians of the account, the pending recovery
+// request and votes of the account are dropped.
+func (am *AccountManager) SetRecovery(accountName common.Name, config *RecoveryConfig) error {
+	if _, err := am.checkRecoverable(accountName); err != nil {
+		return err
+	}
+	if err := am.clearRecovery(accountName); err != nil {
+		return err
+	}
+	if len(config.Guardians) == 0 {
+		am.sdb.Delete(acctManagerName, recoveryConfigPrefix+accountName.String())
+		return nil
+	}
+
+	if uint64(len(config.Guardians)) > params.MaxAuthorNum {
+		return fmt.Errorf("account guardians can not exceed %d", params.MaxAuthorNum)
+	}
+	if config.Threshold == 0 || config.Threshold > uint64(len(config.Guardians)) ||
+		config.Delay < minRecoveryDelay || config.Delay > maxRecoveryDelay {
+		return ErrInvalidRecovery
+	}
+	seen := make(map[common.Name]bool)
+	for _, guardian := range config.Guardians {
+		if guardian == accountName || seen[guardian] {
+			return ErrInvalidGuardian
+		}
+		seen[guardian] = true
+		if _, err := am.checkRecoverable(guardian); err != nil {
+			return fmt.Errorf("guardian %v: %v", guardian, err)
+		}
+	}
+
+	b, err := rlp.EncodeToBytes(config)
+	if err != nil {
+		return err
+	}
+	am.sdb.Put(acctManagerName, recoveryConfigPrefix+accountName.String(), b)
+	return nil
+}
+
+// GetRecoveryConfig returns the recovery config of the account, nil if the
+// account has no guardians.
+func (am *AccountManager) GetRecoveryConfig(accountName common.Name) (*RecoveryConfig, error) {
+	b, err := am.sdb.Get(acctManagerName, recoveryConfigPrefix+accountName.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var config RecoveryConfig
+	if err := rlp.DecodeBytes(b, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// GetRecoveryRequest returns the pending recovery request of the account, nil
+// if there is none.
+func (am *AccountManager) GetRecoveryRequest(accountName common.Name) (*RecoveryRequest, error) {
+	b, err := am.sdb.Get(acctManagerName, recoveryRequestPrefix+accountName.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var request RecoveryRequest
+	if err := rlp.DecodeBytes(b, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetRecoveryVote returns the author set the guardian last proposed for the
+// account, nil if it has no vote pending.
+func (am *AccountManager) GetRecoveryVote(accountName common.Name, guardian common.Name) (*RecoveryAction, error) {
+	b, err := am.sdb.Get(acctManagerName, recoveryVoteKey(accountName, guardian))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var vote RecoveryAction
+	if err := rlp.DecodeBytes(b, &vote); err != nil {
+		return nil, err
+	}
+	return &vote, nil
+}
+
+func (am *AccountManager) setRecoveryRequest(accountName common.Name, request *RecoveryRequest) error {
+	b, err := rlp.EncodeToBytes(request)
+	if err != nil {
+		return err
+	}
+	am.sdb.Put(acctManagerName, recoveryRequestPrefix+accountName.String(), b)
+	return nil
+}
+
+// ProposeRecovery records the vote of the guardian for the new author set
+// of the account, replacing its earlier vote. Once the guardians threshold
+// votes for the same author set it becomes the recovery request of the
+// account, ready Delay seconds after the block time.
+func (am *AccountManager) ProposeRecovery(guardian common.Name, proposal *RecoveryAction, blockTime uint64) error {
+	if _, err := am.checkRecoverable(proposal.Account); err != nil {
+		return err
+	}
+	config, err := am.GetRecoveryConfig(proposal.Account)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return ErrRecoveryNotSet
+	}
+	isGuardian := false
+	for _, name := range config.Guardians {
+		if name == guardian {
+			isGuardian = true
+			break
+		}
+	}
+	if !isGuardian {
+		return ErrInvalidGuardian
+	}
+	if err := checkRecoveryAuthors(proposal); err != nil {
+		return err
+	}
+
+	request, err := am.GetRecoveryRequest(proposal.Account)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		return ErrRecoveryPending
+	}
+	vote, err := am.GetRecoveryVote(proposal.Account, guardian)
+	if err != nil {
+		return err
+	}
+	if vote != nil && vote.equal(proposal) {
+		return ErrRecoveryApproved
+	}
+	b, err := rlp.EncodeToBytes(proposal)
+	if err != nil {
+		return err
+	}
+	am.sdb.Put(acctManagerName, recoveryVoteKey(proposal.Account, guardian), b)
+
+	var approvals []common.Name
+	for _, name := range config.Guardians {
+		vote, err := am.GetRecoveryVote(proposal.Account, name)
+		if err != nil {
+			return err
+		}
+		if vote != nil && vote.equal(proposal) {
+			approvals = append(approvals, name)
+		}
+	}
+	if uint64(len(approvals)) < config.Threshold {
+		return nil
+	}
+	for _, name := range config.Guardians {
+		am.sdb.Delete(acctManagerName, recoveryVoteKey(proposal.Account, name))
+	}
+	return am.setRecoveryRequest(proposal.Account, &RecoveryRequest{
+		RecoveryAction: *proposal,
+		Approvals:      approvals,
+		ReadyTime:      blockTime + config.Delay*uint64(time.Second),
+	})
+}
+
+func checkRecoveryAuthors(proposal *RecoveryAction) error {
+	if len(proposal.Authors) == 0 || uint64(len(proposal.Authors)) > params.MaxAuthorNum {
+		return fmt.Errorf("account author length must be between 1 and %d", params.MaxAuthorNum)
+	}
+	if proposal.Threshold == 0 || proposal.UpdateAuthorThreshold == 0 {
+		return ErrInvalidRecovery
+	}
+	var weight uint64
+	seen := make(map[string]bool)
+	for _, author := range proposal.Authors {
+		if author == nil || author.Owner == nil || seen[author.Owner.String()] {
+			return ErrInvalidRecovery
+		}
+		seen[author.Owner.String()] = true
+		weight += author.GetWeight()
+	}
+	// the recovered account must be usable by its new authors
+	if weight < proposal.Threshold || weight < proposal.UpdateAuthorThreshold {
+		return ErrInvalidRecovery
+	}
+	return nil
+}
+
+// CancelRecovery drops the pending recovery request and votes of the
+// account.
+func (am *AccountManager) CancelRecovery(accountName common.Name) error {
+	request, err := am.GetRecoveryRequest(accountName)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		config, err := am.GetRecoveryConfig(accountName)
+		if err != nil {
+			return err
+		}
+		if config == nil {
+			return ErrRecoveryNotExist
+		}
+		voted := false
+		for _, guardian := range config.Guardians {
+			vote, err := am.GetRecoveryVote(accountName, guardian)
+			if err != nil {
+				return err
+			}
+			voted = voted || vote != nil
+		}
+		if !voted {
+			return ErrRecoveryNotExist
+		}
+	}
+	return am.clearRecovery(accountName)
+}
+
+// ExecuteRecovery replaces the authors of the account by the recovered ones
+// once the recovery delay is over at the block time.
+func (am *AccountManager) ExecuteRecovery(accountName common.Name, blockTime uint64) error {
+	acct, err := am.checkRecoverable(accountName)
+	if err != nil {
+		return err
+	}
+	request, err := am.GetRecoveryRequest(accountName)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		return ErrRecoveryNotExist
+	}
+	if blockTime < request.ReadyTime {
+		return ErrRecoveryNotReady
+	}
+
+	acct.Authors = request.Authors
+	acct.SetThreshold(request.Threshold)
+	acct.SetUpdateAuthorThreshold(request.UpdateAuthorThreshold)
+	acct.SetAuthorVersion()
+	am.sdb.Delete(acctManagerName, recoveryRequestPrefix+accountName.String())
+	return am.SetAccount(acct)
+}
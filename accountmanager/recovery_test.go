@@ -0,0 +1,178 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+func TestAccountManager_Recovery(t *testing.T) {
+	statedb := getStateDB()
+	am, err := NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.Name("recoveryowner")
+	guardians := []common.Name{"recoveryguard1", "recoveryguard2", "recoveryguard3"}
+	for _, name := range append([]common.Name{owner}, guardians...) {
+		pub, _ := GeneragePubKey()
+		if err := am.CreateAccount("fractal.founder", name, "", 0, params.ForkID1, pub, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the delays run from the block time
+	start := uint64(1000 * time.Second)
+	blockTime := start
+	process := func(from common.Name, aType types.ActionType, payload interface{}, forkID uint64) error {
+		var b []byte
+		if payload != nil {
+			if b, err = rlp.EncodeToBytes(payload); err != nil {
+				t.Fatal(err)
+			}
+		}
+		action := types.NewAction(aType, from, common.Name(params.DefaultChainconfig.AccountName), 0, 0, 0, big.NewInt(0), b, nil)
+		_, err := am.Process(&types.AccountManagerContext{Action: action, ChainConfig: params.DefaultChainconfig, Time: blockTime, CurForkID: forkID})
+		return err
+	}
+
+	delay := time.Duration(minRecoveryDelay) * time.Second
+	config := &RecoveryConfig{Guardians: guardians, Threshold: 2, Delay: minRecoveryDelay}
+	if err := process(owner, types.SetRecovery, config, params.ForkID3); err != ErrUnkownTxType {
+		t.Fatalf("want %v, got %v", ErrUnkownTxType, err)
+	}
+	if err := process(owner, types.SetRecovery, &RecoveryConfig{Guardians: guardians, Threshold: 4, Delay: minRecoveryDelay}, params.ForkID4); err != ErrInvalidRecovery {
+		t.Fatalf("want %v, got %v", ErrInvalidRecovery, err)
+	}
+	if err := process(owner, types.SetRecovery, &RecoveryConfig{Guardians: guardians, Threshold: 2, Delay: minRecoveryDelay - 1}, params.ForkID4); err != ErrInvalidRecovery {
+		t.Fatalf("want %v, got %v", ErrInvalidRecovery, err)
+	}
+	if err := process(owner, types.SetRecovery, &RecoveryConfig{Guardians: []common.Name{owner}, Threshold: 1, Delay: minRecoveryDelay}, params.ForkID4); err != ErrInvalidGuardian {
+		t.Fatalf("want %v, got %v", ErrInvalidGuardian, err)
+	}
+	if err := process(owner, types.SetRecovery, config, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+
+	newPub, _ := GeneragePubKey()
+	proposal := &RecoveryAction{
+		Account:               owner,
+		Threshold:             1,
+		UpdateAuthorThreshold: 1,
+		Authors:               []*common.Author{common.NewAuthor(newPub, 1)},
+	}
+	if err := process(owner, types.ProposeRecovery, proposal, params.ForkID4); err != ErrInvalidGuardian {
+		t.Fatalf("want %v, got %v", ErrInvalidGuardian, err)
+	}
+	if err := process(guardians[0], types.ProposeRecovery, &RecoveryAction{Account: owner, Threshold: 2, UpdateAuthorThreshold: 1, Authors: proposal.Authors}, params.ForkID4); err != ErrInvalidRecovery {
+		t.Fatalf("want %v, got %v", ErrInvalidRecovery, err)
+	}
+	if err := process(guardians[0], types.ProposeRecovery, proposal, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if err := process(guardians[0], types.ProposeRecovery, proposal, params.ForkID4); err != ErrRecoveryApproved {
+		t.Fatalf("want %v, got %v", ErrRecoveryApproved, err)
+	}
+	if err := process(guardians[1], types.ExecuteRecovery, &ExecuteRecoveryAction{Account: owner}, params.ForkID4); err != ErrRecoveryNotExist {
+		t.Fatalf("want %v, got %v", ErrRecoveryNotExist, err)
+	}
+
+	// a differing proposal doesn't drop the votes for the first one
+	otherPub, _ := GeneragePubKey()
+	other := &RecoveryAction{Account: owner, Threshold: 1, UpdateAuthorThreshold: 1, Authors: []*common.Author{common.NewAuthor(otherPub, 1)}}
+	if err := process(guardians[2], types.ProposeRecovery, other, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if request, err := am.GetRecoveryRequest(owner); err != nil || request != nil {
+		t.Fatalf("recovery request %v %v", request, err)
+	}
+	if vote, err := am.GetRecoveryVote(owner, guardians[0]); err != nil || vote == nil || !vote.equal(proposal) {
+		t.Fatalf("recovery vote %v %v", vote, err)
+	}
+	blockTime = start + uint64(10*time.Second)
+	if err := process(guardians[1], types.ProposeRecovery, proposal, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	request, err := am.GetRecoveryRequest(owner)
+	if err != nil || request == nil || request.ReadyTime != blockTime+uint64(delay) || len(request.Approvals) != 2 {
+		t.Fatalf("recovery request %v %v", request, err)
+	}
+	if vote, err := am.GetRecoveryVote(owner, guardians[2]); err != nil || vote != nil {
+		t.Fatalf("recovery vote not removed %v %v", vote, err)
+	}
+	if err := process(guardians[2], types.ProposeRecovery, other, params.ForkID4); err != ErrRecoveryPending {
+		t.Fatalf("want %v, got %v", ErrRecoveryPending, err)
+	}
+
+	// the current keys cancel the recovery during the delay
+	if err := process(owner, types.CancelRecovery, nil, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if request, err := am.GetRecoveryRequest(owner); err != nil || request != nil {
+		t.Fatalf("recovery request not canceled %v %v", request, err)
+	}
+	if err := process(owner, types.CancelRecovery, nil, params.ForkID4); err != ErrRecoveryNotExist {
+		t.Fatalf("want %v, got %v", ErrRecoveryNotExist, err)
+	}
+
+	// the votes are canceled with the request
+	if err := process(guardians[0], types.ProposeRecovery, proposal, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if err := process(owner, types.CancelRecovery, nil, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if vote, err := am.GetRecoveryVote(owner, guardians[0]); err != nil || vote != nil {
+		t.Fatalf("recovery vote not canceled %v %v", vote, err)
+	}
+
+	blockTime = start
+	for _, guardian := range guardians[1:] {
+		if err := process(guardian, types.ProposeRecovery, proposal, params.ForkID4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	version, err := am.GetAuthorVersion(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockTime = start + uint64(delay) - 1
+	if err := process(guardians[0], types.ExecuteRecovery, &ExecuteRecoveryAction{Account: owner}, params.ForkID4); err != ErrRecoveryNotReady {
+		t.Fatalf("want %v, got %v", ErrRecoveryNotReady, err)
+	}
+	blockTime = start + uint64(delay)
+	if err := process(guardians[0], types.ExecuteRecovery, &ExecuteRecoveryAction{Account: owner}, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	acct, err := am.GetAccountByName(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acct.Authors) != 1 || acct.Authors[0].Owner.String() != newPub.String() || acct.AuthorVersion == version {
+		t.Fatalf("authors not recovered %v", acct.Authors)
+	}
+	if request, err := am.GetRecoveryRequest(owner); err != nil || request != nil {
+		t.Fatalf("recovery request not removed %v %v", request, err)
+	}
+}
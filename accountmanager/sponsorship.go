@@ -0,0 +1,125 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"math/big"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+const sponsorshipPrefix = "sponsorship"
+
+// SponsorshipAction is the payload of the SetSponsorship action, it limits
+// the transactions whose gas is paid by the sender. A zero MaxFee doesn't
+// limit the fee and an empty Recipients list allows any recipient, setting
+// both removes the sponsorship limits.
+type SponsorshipAction struct {
+	MaxFee     *big.Int      `json:"maxFee"`
+	Recipients []common.Name `json:"recipients,omitempty"`
+}
+
+// SetSponsorship sets the limits of the transactions sponsored by the account.
+func (am *AccountManager) SetSponsorship(accountName common.Name, sponsorship *SponsorshipAction) error {
+	acct, err := am.GetAccountByName(accountName)
+	if err != nil {
+		return err
+	}
+	if acct == nil {
+		return ErrAccountNotExist
+	}
+	if acct.IsDestroyed() {
+		return ErrAccountIsDestroy
+	}
+	if sponsorship.MaxFee == nil {
+		sponsorship.MaxFee = new(big.Int)
+	}
+	if sponsorship.MaxFee.Sign() < 0 {
+		return ErrNegativeValue
+	}
+	if sponsorship.MaxFee.Sign() == 0 && len(sponsorship.Recipients) == 0 {
+		am.sdb.Delete(acctManagerName, sponsorshipPrefix+accountName.String())
+		return nil
+	}
+	b, err := rlp.EncodeToBytes(sponsorship)
+	if err != nil {
+		return err
+	}
+	am.sdb.Put(acctManagerName, sponsorshipPrefix+accountName.String(), b)
+	return nil
+}
+
+// GetSponsorship returns the sponsorship limits of the account, nil if the
+// account doesn't limit the transactions it pays for.
+func (am *AccountManager) GetSponsorship(accountName common.Name) (*SponsorshipAction, error) {
+	b, err := am.sdb.Get(acctManagerName, sponsorshipPrefix+accountName.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var sponsorship SponsorshipAction
+	if err := rlp.DecodeBytes(b, &sponsorship); err != nil {
+		return nil, err
+	}
+	return &sponsorship, nil
+}
+
+// CheckSponsorship checks the transaction against the sponsorship limits of
+// its payer.
+func (am *AccountManager) CheckSponsorship(tx *types.Transaction) error {
+	payer := tx.Payer()
+	if payer == "" {
+		return nil
+	}
+	acct, err := am.GetAccountByName(payer)
+	if err != nil {
+		return err
+	}
+	if acct == nil {
+		return ErrAccountNotExist
+	}
+	if acct.IsDestroyed() {
+		return ErrAccountIsDestroy
+	}
+	sponsorship, err := am.GetSponsorship(payer)
+	if err != nil || sponsorship == nil {
+		return err
+	}
+	if sponsorship.MaxFee.Sign() > 0 && tx.Cost().Cmp(sponsorship.MaxFee) > 0 {
+		return ErrSponsorshipFeeExceeded
+	}
+	if len(sponsorship.Recipients) == 0 {
+		return nil
+	}
+	for _, action := range tx.GetActions() {
+		allowed := false
+		for _, recipient := range sponsorship.Recipients {
+			if action.Recipient() == recipient {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrSponsorshipRecipient
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/snapshot"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+const (
+	vestingPrefix = "vesting"
+	// maxVestingDuration keeps the schedule times from overflowing.
+	maxVestingDuration = uint64(100 * 365 * 24 * 3600)
+	// maxVestingSchedules bounds the schedules locked in the balance of an
+	// account in an asset, as anyone can add them.
+	maxVestingSchedules = 16
+)
+
+// TransferLockedAction is the payload of the TransferLocked action, the
+// value of the action is transferred to the recipient and released to it
+// linearly over Duration seconds, nothing being released before Cliff
+// seconds.
+type TransferLockedAction struct {
+	Cliff    uint64 `json:"cliff"`
+	Duration uint64 `json:"duration"`
+}
+
+// VestingSchedule is a locked amount of an asset held by the beneficiary.
+// The times are block times, the schedule starts at the block it's added in
+// and is evaluated against the last snapshot time, so the released amount
+// moves once per snapshot interval.
+type VestingSchedule struct {
+	Funder      common.Name `json:"funder"`
+	Beneficiary common.Name `json:"beneficiary"`
+	AssetID     uint64      `json:"assetId"`
+	Amount      *big.Int    `json:"amount"`
+	Start       uint64      `json:"start"`
+	Cliff       uint64      `json:"cliff"`
+	End         uint64      `json:"end"`
+}
+
+// Released returns the amount of the schedule released at the time.
+func (vs *VestingSchedule) Released(now uint64) *big.Int {
+	switch {
+	case now < vs.Cliff:
+		return big.NewInt(0)
+	case now >= vs.End:
+		return new(big.Int).Set(vs.Amount)
+	}
+	released := new(big.Int).Mul(vs.Amount, new(big.Int).SetUint64(now-vs.Start))
+	return released.Div(released, new(big.Int).SetUint64(vs.End-vs.Start))
+}
+
+// Locked returns the amount of the schedule still locked at the time.
+func (vs *VestingSchedule) Locked(now uint64) *big.Int {
+	return new(big.Int).Sub(vs.Amount, vs.Released(now))
+}
+
+func vestingKey(accountName common.Name, assetID uint64) string {
+	return vestingPrefix + accountName.String() + ":" + strconv.FormatUint(assetID, 10)
+}
+
+// snapshotTime returns the last snapshot time, the time vesting schedules
+// are evaluated at.
+func (am *AccountManager) snapshotTime() (uint64, error) {
+	return snapshot.NewSnapshotManager(am.sdb).GetLastSnapshotTime()
+}
+
+// GetVestingSchedules returns the vesting schedules of the account in the asset.
+func (am *AccountManager) GetVestingSchedules(accountName common.Name, assetID uint64) ([]*VestingSchedule, error) {
+	b, err := am.sdb.Get(acctManagerName, vestingKey(accountName, assetID))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var schedules []*VestingSchedule
+	if err := rlp.DecodeBytes(b, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetLockedBalance returns the balance of the account in the asset that is
+// not released yet.
+func (am *AccountManager) GetLockedBalance(accountName common.Name, assetID uint64) (*big.Int, error) {
+	schedules, err := am.GetVestingSchedules(accountName, assetID)
+	if err != nil || len(schedules) == 0 {
+		return big.NewInt(0), err
+	}
+	now, err := am.snapshotTime()
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	locked := big.NewInt(0)
+	for _, vs := range schedules {
+		locked.Add(locked, vs.Locked(now))
+	}
+	return locked, nil
+}
+
+// AddVestingSchedule locks the amount in the balance of the beneficiary from
+// the block time on, the fully released schedules of the beneficiary are
+// dropped. The amount is at least one unit of the asset and the beneficiary
+// holds at most maxVestingSchedules schedules in the asset.
+func (am *AccountManager) AddVestingSchedule(funder, beneficiary common.Name, assetID uint64, amount *big.Int, lock *TransferLockedAction, blockTime uint64) error {
+	if amount.Sign() <= 0 {
+		return ErrAmountValueInvalid
+	}
+	if lock.Duration == 0 || lock.Cliff > lock.Duration || lock.Duration > maxVestingDuration {
+		return ErrInvalidVesting
+	}
+	ao, err := am.ast.GetAssetObjectById(assetID)
+	if err != nil {
+		return err
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(ao.GetDecimals()), nil)
+	if amount.Cmp(unit) < 0 {
+		return ErrVestingAmountTooSmall
+	}
+	schedules, err := am.GetVestingSchedules(beneficiary, assetID)
+	if err != nil {
+		return err
+	}
+	var kept []*VestingSchedule
+	if len(schedules) > 0 {
+		now, err := am.snapshotTime()
+		if err != nil {
+			return err
+		}
+		for _, vs := range schedules {
+			if vs.Locked(now).Sign() > 0 {
+				kept = append(kept, vs)
+			}
+		}
+	}
+	if len(kept) >= maxVestingSchedules {
+		return ErrVestingLimit
+	}
+	now := blockTime
+	kept = append(kept, &VestingSchedule{
+		Funder:      funder,
+		Beneficiary: beneficiary,
+		AssetID:     assetID,
+		Amount:      new(big.Int).Set(amount),
+		Start:       now,
+		Cliff:       now + lock.Cliff*uint64(time.Second),
+		End:         now + lock.Duration*uint64(time.Second),
+	})
+	b, err := rlp.EncodeToBytes(kept)
+	if err != nil {
+		return err
+	}
+	am.sdb.Put(acctManagerName, vestingKey(beneficiary, assetID), b)
+	return nil
+}
+
+// spendableBalance returns the balance less the locked amount.
+func (am *AccountManager) spendableBalance(accountName common.Name, assetID uint64, balance *big.Int) (*big.Int, error) {
+	locked, err := am.GetLockedBalance(accountName, assetID)
+	if err != nil {
+		return nil, err
+	}
+	spendable := new(big.Int).Sub(balance, locked)
+	if spendable.Sign() < 0 {
+		return big.NewInt(0), nil
+	}
+	return spendable, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package accountmanager
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/snapshot"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+func TestAccountManager_TransferLocked(t *testing.T) {
+	statedb := getStateDB()
+	am, err := NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funder, holder := common.Name("vestingfunder"), common.Name("vestingholder")
+	for _, name := range []common.Name{funder, holder} {
+		pub, _ := GeneragePubKey()
+		if err := am.CreateAccount("fractal.founder", name, "", 0, params.ForkID1, pub, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assetID, err := am.ast.IssueAsset("vestingtoken", 0, params.ForkID1, "vtk", big.NewInt(1000), 0, funder, funder, big.NewInt(0), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.AddAccountBalanceByID(funder, assetID, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := uint64(1000 * time.Second)
+	setTime := func(offset time.Duration) {
+		if err := snapshot.NewSnapshotManager(statedb).SetSnapshot(start+uint64(offset), snapshot.BlockInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setTime(0)
+
+	// the schedules start at the block time, after the last snapshot
+	blockTime := start + uint64(5*time.Second)
+	transferLocked := func(lock *TransferLockedAction, forkID uint64) error {
+		b, err := rlp.EncodeToBytes(lock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		action := types.NewAction(types.TransferLocked, funder, holder, 0, assetID, 0, big.NewInt(100), b, nil)
+		_, err = am.Process(&types.AccountManagerContext{Action: action, ChainConfig: params.DefaultChainconfig, Time: blockTime, CurForkID: forkID})
+		return err
+	}
+	lock := &TransferLockedAction{Cliff: 10, Duration: 100}
+	if err := transferLocked(lock, params.ForkID3); err != ErrUnkownTxType {
+		t.Fatalf("want %v, got %v", ErrUnkownTxType, err)
+	}
+	if err := transferLocked(&TransferLockedAction{Cliff: 10, Duration: 5}, params.ForkID4); err != ErrInvalidVesting {
+		t.Fatalf("want %v, got %v", ErrInvalidVesting, err)
+	}
+	if err := transferLocked(lock, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance, err := am.GetAccountBalanceByID(holder, assetID, 0); err != nil || balance.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("holder balance %v %v", balance, err)
+	}
+	if schedules, err := am.GetVestingSchedules(holder, assetID); err != nil || len(schedules) != 1 || schedules[0].Start != blockTime {
+		t.Fatalf("vesting schedules %v %v", schedules, err)
+	}
+	if ok, _ := am.CanTransfer(holder, assetID, big.NewInt(1)); ok {
+		t.Fatal("locked balance can be transferred")
+	}
+	if err := am.TransferAsset(holder, funder, assetID, big.NewInt(1)); err != ErrInsufficientBalance {
+		t.Fatalf("want %v, got %v", ErrInsufficientBalance, err)
+	}
+
+	// the linear release starts at the cliff
+	setTime(25 * time.Second)
+	if locked, err := am.GetLockedBalance(holder, assetID); err != nil || locked.Cmp(big.NewInt(80)) != 0 {
+		t.Fatalf("locked balance %v %v", locked, err)
+	}
+	if err := am.TransferAsset(holder, funder, assetID, big.NewInt(21)); err != ErrInsufficientBalance {
+		t.Fatalf("want %v, got %v", ErrInsufficientBalance, err)
+	}
+	if err := am.TransferAsset(holder, funder, assetID, big.NewInt(20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.EnoughAccountBalance(holder, assetID, big.NewInt(1)); err != ErrInsufficientBalance {
+		t.Fatalf("want %v, got %v", ErrInsufficientBalance, err)
+	}
+
+	setTime(105 * time.Second)
+	if err := am.TransferAsset(holder, funder, assetID, big.NewInt(80)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAccountManager_VestingLimits(t *testing.T) {
+	statedb := getStateDB()
+	am, err := NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funder, holder, ben := common.Name("vestingfunder"), common.Name("vestingholder"), common.Name("vestingbenefit")
+	for _, name := range []common.Name{funder, holder, ben} {
+		pub, _ := GeneragePubKey()
+		if err := am.CreateAccount("fractal.founder", name, "", 0, params.ForkID1, pub, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assetID, err := am.ast.IssueAsset("vestingtoken", 0, params.ForkID1, "vtk", big.NewInt(100000), 2, funder, funder, big.NewInt(0), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := uint64(1000 * time.Second)
+	if err := snapshot.NewSnapshotManager(statedb).SetSnapshot(start, snapshot.BlockInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	lock := &TransferLockedAction{Duration: 100}
+
+	// at least one unit of the asset is locked
+	if err := am.AddVestingSchedule(funder, holder, assetID, big.NewInt(99), lock, start); err != ErrVestingAmountTooSmall {
+		t.Fatalf("want %v, got %v", ErrVestingAmountTooSmall, err)
+	}
+	for i := 0; i < maxVestingSchedules; i++ {
+		if err := am.AddVestingSchedule(funder, holder, assetID, big.NewInt(100), lock, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := am.AddVestingSchedule(funder, holder, assetID, big.NewInt(100), lock, start); err != ErrVestingLimit {
+		t.Fatalf("want %v, got %v", ErrVestingLimit, err)
+	}
+	if err := am.AddAccountBalanceByID(holder, assetID, big.NewInt(100*maxVestingSchedules)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the account isn't deleted while a balance is locked, the released
+	// schedules are deleted with it
+	if _, err := am.DestroyAccount(holder, ben, params.ForkID4); err != ErrAccountLocked {
+		t.Fatalf("want %v, got %v", ErrAccountLocked, err)
+	}
+	if err := snapshot.NewSnapshotManager(statedb).SetSnapshot(start+uint64(100*time.Second), snapshot.BlockInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.DestroyAccount(holder, ben, params.ForkID4); err != nil {
+		t.Fatal(err)
+	}
+	if schedules, err := am.GetVestingSchedules(holder, assetID); err != nil || len(schedules) != 0 {
+		t.Fatalf("vesting schedules %v %v", schedules, err)
+	}
+}
@@ -35,6 +35,10 @@ type AssetObject struct {
 	UpperLimit  *big.Int    `json:"upperLimit"`
 	Contract    common.Name `json:"contract"`
 	Description string      `json:"description"`
+
+	// transfer controls, stored apart from the object, see LoadAssetControl
+	Paused        bool `json:"paused" rlp:"-"`
+	WhitelistMode bool `json:"whitelistMode" rlp:"-"`
 }
 
 func NewAssetObject(assetName string, number uint64, symbol string, amount *big.Int, dec uint64, founder common.Name, owner common.Name, limit *big.Int, contract common.Name, description string) (*AssetObject, error) {
@@ -0,0 +1,192 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package asset
+
+import (
+	"strconv"
+
+	"github.com/fractalplatform/fractal/common"
+)
+
+// The transfer controls are kept apart from the asset object, so assets
+// without controls keep their encoding.
+var (
+	assetPausedPrefix      = "assetPaused"
+	assetWhitelistPrefix   = "assetWhitelistMode"
+	assetFrozenPrefix      = "assetFrozen"
+	assetWhitelistedPrefix = "assetWhitelisted"
+)
+
+func controlKey(prefix string, assetID uint64, accountName ...common.Name) string {
+	key := prefix + strconv.FormatUint(assetID, 10)
+	for _, name := range accountName {
+		key += ":" + name.String()
+	}
+	return key
+}
+
+func (a *Asset) getFlag(key string) (bool, error) {
+	b, err := a.sdb.Get(assetManagerName, key)
+	if err != nil {
+		return false, err
+	}
+	return len(b) != 0, nil
+}
+
+func (a *Asset) setFlag(key string, flag bool) {
+	if flag {
+		a.sdb.Put(assetManagerName, key, []byte{1})
+	} else {
+		a.sdb.Delete(assetManagerName, key)
+	}
+}
+
+// SetAssetPaused pauses or resumes all transfers of the asset.
+func (a *Asset) SetAssetPaused(assetID uint64, paused bool) error {
+	if _, err := a.GetAssetObjectById(assetID); err != nil {
+		return err
+	}
+	a.setFlag(controlKey(assetPausedPrefix, assetID), paused)
+	return nil
+}
+
+// IsAssetPaused returns whether the transfers of the asset are paused.
+func (a *Asset) IsAssetPaused(assetID uint64) (bool, error) {
+	return a.getFlag(controlKey(assetPausedPrefix, assetID))
+}
+
+// SetAssetWhitelistMode switches the asset in or out of whitelist mode, in
+// whitelist mode only whitelisted accounts send and receive the asset.
+func (a *Asset) SetAssetWhitelistMode(assetID uint64, enabled bool) error {
+	if _, err := a.GetAssetObjectById(assetID); err != nil {
+		return err
+	}
+	a.setFlag(controlKey(assetWhitelistPrefix, assetID), enabled)
+	return nil
+}
+
+// IsWhitelistMode returns whether the asset is in whitelist mode.
+func (a *Asset) IsWhitelistMode(assetID uint64) (bool, error) {
+	return a.getFlag(controlKey(assetWhitelistPrefix, assetID))
+}
+
+// SetAccountWhitelisted adds or removes the account from the asset whitelist.
+func (a *Asset) SetAccountWhitelisted(assetID uint64, accountName common.Name, whitelisted bool) error {
+	if accountName == "" {
+		return ErrAccountNameNull
+	}
+	if _, err := a.GetAssetObjectById(assetID); err != nil {
+		return err
+	}
+	a.setFlag(controlKey(assetWhitelistedPrefix, assetID, accountName), whitelisted)
+	return nil
+}
+
+// IsAccountWhitelisted returns whether the account is on the asset whitelist.
+func (a *Asset) IsAccountWhitelisted(assetID uint64, accountName common.Name) (bool, error) {
+	return a.getFlag(controlKey(assetWhitelistedPrefix, assetID, accountName))
+}
+
+// SetAccountFrozen freezes or unfreezes the balance of the account in the asset.
+func (a *Asset) SetAccountFrozen(assetID uint64, accountName common.Name, frozen bool) error {
+	if accountName == "" {
+		return ErrAccountNameNull
+	}
+	ao, err := a.GetAssetObjectById(assetID)
+	if err != nil {
+		return err
+	}
+	if frozen && accountName == ao.GetAssetOwner() {
+		return ErrFreezeOwner
+	}
+	a.setFlag(controlKey(assetFrozenPrefix, assetID, accountName), frozen)
+	return nil
+}
+
+// IsAccountFrozen returns whether the balance of the account in the asset is frozen.
+func (a *Asset) IsAccountFrozen(assetID uint64, accountName common.Name) (bool, error) {
+	return a.getFlag(controlKey(assetFrozenPrefix, assetID, accountName))
+}
+
+// LoadAssetControl fills the transfer control status of the asset object.
+func (a *Asset) LoadAssetControl(ao *AssetObject) error {
+	paused, err := a.IsAssetPaused(ao.GetAssetId())
+	if err != nil {
+		return err
+	}
+	whitelist, err := a.IsWhitelistMode(ao.GetAssetId())
+	if err != nil {
+		return err
+	}
+	ao.Paused, ao.WhitelistMode = paused, whitelist
+	return nil
+}
+
+// CheckTransferControl checks the transfer against the controls set by the
+// asset owner. Frozen accounts neither send nor receive the asset, the
+// owner is exempt from pause and whitelist mode so the owner can still
+// issue and recall the asset.
+func (a *Asset) CheckTransferControl(assetID uint64, from common.Name, to common.Name) error {
+	for _, name := range []common.Name{from, to} {
+		frozen, err := a.IsAccountFrozen(assetID, name)
+		if err != nil {
+			return err
+		}
+		if frozen {
+			return ErrAccountFrozen
+		}
+	}
+
+	paused, err := a.IsAssetPaused(assetID)
+	if err != nil {
+		return err
+	}
+	whitelist, err := a.IsWhitelistMode(assetID)
+	if err != nil {
+		return err
+	}
+	if !paused && !whitelist {
+		return nil
+	}
+
+	ao, err := a.GetAssetObjectById(assetID)
+	if err != nil {
+		return err
+	}
+	exempt := func(name common.Name) bool {
+		return name == ao.GetAssetOwner()
+	}
+	if paused {
+		if !exempt(from) {
+			return ErrAssetPaused
+		}
+		return nil
+	}
+	for _, name := range []common.Name{from, to} {
+		if exempt(name) {
+			continue
+		}
+		whitelisted, err := a.IsAccountWhitelisted(assetID, name)
+		if err != nil {
+			return err
+		}
+		if !whitelisted {
+			return ErrNotWhitelisted
+		}
+	}
+	return nil
+}
@@ -34,4 +34,8 @@ var (
 	ErrAssetManagerNotExist = errors.New("asset manager name not exist")
 	ErrDetailTooLong        = errors.New("detail info exceed maxmium")
 	ErrNegativeAmount       = errors.New("negative amount")
+	ErrAccountFrozen        = errors.New("account asset balance is frozen")
+	ErrAssetPaused          = errors.New("asset transfers are paused")
+	ErrNotWhitelisted       = errors.New("account not in asset whitelist")
+	ErrFreezeOwner          = errors.New("asset owner can not be frozen")
 )
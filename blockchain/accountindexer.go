@@ -0,0 +1,201 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
+)
+
+// accountIndexer maintains the account name -> action index in rawdb. Every
+// account keeps its entries numbered in chain order, so that a page of the
+// history can be read without iterating over the database. Writes go to a
+// batch the database can't see yet, the indexer keeps the pending entry
+// counts itself.
+type accountIndexer struct {
+	db     rawdb.DatabaseReader
+	counts map[common.Name]uint64
+}
+
+func newAccountIndexer(db rawdb.DatabaseReader) *accountIndexer {
+	return &accountIndexer{db: db, counts: make(map[common.Name]uint64)}
+}
+
+func (ai *accountIndexer) count(name common.Name) uint64 {
+	if count, ok := ai.counts[name]; ok {
+		return count
+	}
+	return rawdb.ReadAccountTxCount(ai.db, name)
+}
+
+// blockAccounts returns the accounts of every action of the block in the
+// order they appear. An account only reached by the internal actions of an
+// action is marked as internal.
+func blockAccounts(block *types.Block, detailTxs []*types.DetailTx) ([]common.Name, []*rawdb.AccountTxEntry) {
+	var (
+		names   []common.Name
+		entries []*rawdb.AccountTxEntry
+	)
+	for txIndex, tx := range block.Transactions() {
+		var detailTx *types.DetailTx
+		if txIndex < len(detailTxs) && detailTxs[txIndex] != nil && detailTxs[txIndex].TxHash == tx.Hash() {
+			detailTx = detailTxs[txIndex]
+		}
+		for actionIndex, action := range tx.GetActions() {
+			seen := make(map[common.Name]bool)
+			add := func(name common.Name, internal bool) {
+				if len(name) == 0 || seen[name] {
+					return
+				}
+				seen[name] = true
+				names = append(names, name)
+				entries = append(entries, &rawdb.AccountTxEntry{
+					BlockNumber: block.NumberU64(),
+					TxIndex:     uint64(txIndex),
+					ActionIndex: uint64(actionIndex),
+					TxHash:      tx.Hash(),
+					Internal:    internal,
+				})
+			}
+			add(action.Sender(), false)
+			add(action.Recipient(), false)
+			if detailTx == nil || actionIndex >= len(detailTx.Actions) {
+				continue
+			}
+			for _, ia := range detailTx.Actions[actionIndex].InternalActions {
+				if ia.Action != nil {
+					add(ia.Action.From, true)
+					add(ia.Action.To, true)
+				}
+			}
+		}
+	}
+	return names, entries
+}
+
+// indexBlock appends the entries of a canonical block.
+func (ai *accountIndexer) indexBlock(batch fdb.Batch, block *types.Block, detailTxs []*types.DetailTx) {
+	names, entries := blockAccounts(block, detailTxs)
+	for i, name := range names {
+		count := ai.count(name)
+		rawdb.WriteAccountTxEntry(batch, name, count, entries[i])
+		rawdb.WriteAccountTxCount(batch, name, count+1)
+		ai.counts[name] = count + 1
+	}
+	rawdb.WriteAccountIndexHead(batch, block.NumberU64(), block.Hash())
+}
+
+// unindexBlock removes the entries of a block dropped from the canonical
+// chain. Blocks must be unindexed from the head downwards.
+func (ai *accountIndexer) unindexBlock(batch fdb.Batch, block *types.Block, detailTxs []*types.DetailTx) {
+	names, _ := blockAccounts(block, detailTxs)
+	for _, name := range names {
+		count := ai.count(name)
+		for count > 0 {
+			entry := rawdb.ReadAccountTxEntry(ai.db, name, count-1)
+			if entry != nil && entry.BlockNumber < block.NumberU64() {
+				break
+			}
+			rawdb.DeleteAccountTxEntry(batch, name, count-1)
+			count--
+		}
+		rawdb.WriteAccountTxCount(batch, name, count)
+		ai.counts[name] = count
+	}
+	rawdb.WriteAccountIndexHead(batch, block.NumberU64()-1, block.ParentHash())
+}
+
+// BuildAccountIndex brings the account index of the database up to date
+// with its canonical chain. Index entries of blocks which are no longer
+// canonical are removed first, then every missing canonical block is indexed.
+func BuildAccountIndex(db fdb.Database) error {
+	headHash := rawdb.ReadHeadBlockHash(db)
+	if headHash == (common.Hash{}) {
+		return fmt.Errorf("empty database")
+	}
+	headNumber := rawdb.ReadHeaderNumber(db, headHash)
+	if headNumber == nil {
+		return fmt.Errorf("head block %x not found", headHash)
+	}
+
+	var (
+		start = uint64(0)
+		head  = rawdb.ReadAccountIndexHead(db)
+	)
+	if head != nil {
+		// unwind the index until it joins the canonical chain
+		batch := db.NewBatch()
+		indexer := newAccountIndexer(db)
+		for head.Number > 0 && rawdb.ReadCanonicalHash(db, head.Number) != head.Hash {
+			block := rawdb.ReadBlock(db, head.Hash, head.Number)
+			if block == nil {
+				return fmt.Errorf("account index block #%d [%x] not found", head.Number, head.Hash)
+			}
+			indexer.unindexBlock(batch, block, rawdb.ReadDetailTxs(db, block.Hash(), block.NumberU64()))
+			head = &rawdb.AccountIndexHead{Number: head.Number - 1, Hash: block.ParentHash()}
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		start = head.Number + 1
+	}
+
+	var (
+		batch   = db.NewBatch()
+		indexer = newAccountIndexer(db)
+		logged  = time.Now()
+		tail    = rawdb.ReadHistoryTail(db)
+	)
+	for number := start; number <= *headNumber; number++ {
+		if number > 0 && number < tail {
+			// the bodies of pruned blocks can't be indexed
+			continue
+		}
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			// the chain may be started from a specified block number
+			continue
+		}
+		block := rawdb.ReadBlock(db, hash, number)
+		if block == nil {
+			return fmt.Errorf("block #%d [%x] not found", number, hash)
+		}
+		indexer.indexBlock(batch, block, rawdb.ReadDetailTxs(db, hash, number))
+		if batch.ValueSize() > fdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			indexer = newAccountIndexer(db)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Building account index", "number", number, "head", *headNumber)
+			logged = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Account index is up to date", "number", *headNumber, "hash", headHash)
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/types"
+	mdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCanonicalTestBlock(db rawdb.DatabaseWriter, parent *types.Block, txs ...*types.Transaction) *types.Block {
+	header := &types.Header{Number: big.NewInt(0), Coinbase: "coinbase"}
+	if parent != nil {
+		header.ParentHash = parent.Hash()
+		header.Number = new(big.Int).Add(parent.Number(), big.NewInt(1))
+	}
+	header.TxsRoot = types.DeriveTxsMerkleRoot(txs)
+	block := &types.Block{Head: header, Txs: txs}
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	rawdb.WriteHeadBlockHash(db, block.Hash())
+	return block
+}
+
+func newTransferTx(from, to string, nonce uint64) *types.Transaction {
+	action := types.NewAction(types.Transfer, common.Name(from), common.Name(to), nonce, 0, 20000, big.NewInt(1), nil, nil)
+	return types.NewTransaction(0, big.NewInt(1), action)
+}
+
+func TestBuildAccountIndex(t *testing.T) {
+	db := mdb.NewMemDatabase()
+
+	genesis := writeCanonicalTestBlock(db, nil)
+	block1 := writeCanonicalTestBlock(db, genesis, newTransferTx("alice", "bob", 0), newTransferTx("bob", "carol", 0))
+	block2 := writeCanonicalTestBlock(db, block1, newTransferTx("alice", "carol", 1))
+	assert.NoError(t, BuildAccountIndex(db))
+
+	assert.Equal(t, uint64(2), rawdb.ReadAccountTxCount(db, "alice"))
+	assert.Equal(t, uint64(2), rawdb.ReadAccountTxCount(db, "bob"))
+	assert.Equal(t, uint64(2), rawdb.ReadAccountTxCount(db, "carol"))
+	entry := rawdb.ReadAccountTxEntry(db, "carol", 1)
+	assert.Equal(t, block2.NumberU64(), entry.BlockNumber)
+	assert.Equal(t, block2.Transactions()[0].Hash(), entry.TxHash)
+	entry = rawdb.ReadAccountTxEntry(db, "bob", 1)
+	assert.Equal(t, uint64(1), entry.TxIndex)
+
+	// replace block 2, the stale entries must be unwound
+	fork2 := writeCanonicalTestBlock(db, block1, newTransferTx("dave", "bob", 0))
+	assert.NoError(t, BuildAccountIndex(db))
+
+	head := rawdb.ReadAccountIndexHead(db)
+	assert.Equal(t, fork2.Hash(), head.Hash)
+	assert.Equal(t, uint64(1), rawdb.ReadAccountTxCount(db, "alice"))
+	assert.Equal(t, uint64(3), rawdb.ReadAccountTxCount(db, "bob"))
+	assert.Equal(t, uint64(1), rawdb.ReadAccountTxCount(db, "carol"))
+	assert.Equal(t, uint64(1), rawdb.ReadAccountTxCount(db, "dave"))
+	assert.Nil(t, rawdb.ReadAccountTxEntry(db, "alice", 1))
+	assert.Equal(t, fork2.Transactions()[0].Hash(), rawdb.ReadAccountTxEntry(db, "bob", 2).TxHash)
+}
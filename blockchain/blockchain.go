@@ -75,6 +75,9 @@ type BlockChain struct {
 	procmu             sync.RWMutex // block processor lock
 	currentBlock       atomic.Value // Current head of the block chain
 	irreversibleNumber atomic.Value // irreversible Number of the block chain
+	accountIndex       bool         // maintain the account name -> action index
+	historyRetention   uint64       // blocks before the irreversible block whose history is kept, 0 keeps everything
+	pruneTxLookup      bool         // remove the tx lookup entries of the pruned blocks
 
 	stateCache state.Database // State database to reuse between imports (contains state cache)
 	badHashes  map[common.Hash]bool
@@ -241,18 +244,28 @@ func (bc *BlockChain) Reset() error {
 }
 
 func (bc *BlockChain) repair(head **types.Block) error {
+	block, err := rewindToState(bc.stateCache, *head, bc.GetBlock)
+	if err != nil {
+		return err
+	}
+	*head = block
+	return nil
+}
+
+// rewindToState walks back from head to the newest block whose state is available.
+func rewindToState(cache state.Database, head *types.Block, getBlock func(common.Hash, uint64) *types.Block) (*types.Block, error) {
 	for {
 		// Abort if we've rewound to a head block that does have associated state
-		if _, err := state.New((*head).Root(), bc.stateCache); err == nil {
-			log.Info("Rewound blockchain to past state", "number", (*head).Number(), "hash", (*head).Hash())
-			return nil
+		if _, err := state.New(head.Root(), cache); err == nil {
+			log.Info("Rewound blockchain to past state", "number", head.Number(), "hash", head.Hash())
+			return head, nil
 		}
 		// Otherwise rewind one block and recheck state availability there
-		block := bc.GetBlock((*head).ParentHash(), (*head).NumberU64()-1)
+		block := getBlock(head.ParentHash(), head.NumberU64()-1)
 		if block == nil {
-			return fmt.Errorf("missing block %d [%x]", (*head).NumberU64()-1, (*head).ParentHash())
+			return nil, fmt.Errorf("missing block %d [%x]", head.NumberU64()-1, head.ParentHash())
 		}
-		*head = block
+		head = block
 	}
 }
 
@@ -541,6 +554,100 @@ func (bc *BlockChain) WriteBlockWithoutState(block *types.Block, td *big.Int) (e
 	return nil
 }
 
+// InsertFastBlocks writes a contiguous chain of blocks downloaded by fast sync
+// without executing them. The blocks are linked into the canonical chain, but
+// the head block is only moved by CommitFastSync once the state is available.
+// The seals are checked by the downloader once the states of the pivot are
+// downloaded, there is no state to check them against before.
+func (bc *BlockChain) InsertFastBlocks(chain types.Blocks) (int, error) {
+	if len(chain) == 0 {
+		return 0, nil
+	}
+	if err := bc.sanityCheck(chain); err != nil {
+		return 0, err
+	}
+
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	td := bc.GetTd(chain[0].ParentHash(), chain[0].NumberU64()-1)
+	if td == nil {
+		return 0, processor.ErrUnknownAncestor
+	}
+	batch := bc.db.NewBatch()
+	for i, block := range chain {
+		if bc.badHashes[block.Hash()] {
+			return i, ErrBlacklistedHash
+		}
+		if err := bc.Validator().ValidateBody(block); err != nil {
+			return i, err
+		}
+		td = new(big.Int).Add(td, block.Difficulty())
+		rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteBlock(batch, block)
+		rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+		rawdb.WriteTxLookupEntries(batch, block)
+	}
+	rawdb.WriteHeadFastBlockHash(batch, chain[len(chain)-1].Hash())
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// CurrentFastBlock retrieves the latest block downloaded by fast sync, or the
+// head block if fast sync didn't download anything past it.
+func (bc *BlockChain) CurrentFastBlock() *types.Block {
+	head := bc.CurrentBlock()
+	if hash := rawdb.ReadHeadFastBlockHash(bc.db); hash != (common.Hash{}) {
+		if block := bc.GetBlockByHash(hash); block != nil && block.NumberU64() > head.NumberU64() {
+			return block
+		}
+	}
+	return head
+}
+
+// CommitFastSync makes the block, whose state was downloaded by fast sync, the
+// head of the chain. The blocks downloaded past it are dropped from the
+// canonical chain, they are processed by the full sync following fast sync.
+func (bc *BlockChain) CommitFastSync(hash common.Hash) error {
+	block := bc.GetBlockByHash(hash)
+	if block == nil {
+		return fmt.Errorf("fast sync pivot %x not found", hash)
+	}
+	if !bc.HasState(block.Root()) {
+		return fmt.Errorf("fast sync pivot %d state %x missing", block.NumberU64(), block.Root())
+	}
+
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	batch := bc.db.NewBatch()
+	for number := bc.CurrentFastBlock().NumberU64(); number > block.NumberU64(); number-- {
+		rawdb.DeleteCanonicalHash(batch, number)
+	}
+	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	rawdb.WriteIrreversibleNumber(batch, block.NumberU64())
+	rawdb.DeleteHeadFastBlockHash(batch)
+	rawdb.DeleteFastSyncPivot(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	bc.currentBlock.Store(block)
+	bc.irreversibleNumber.Store(block.NumberU64())
+	log.Info("Fast sync committed head", "number", block.NumberU64(), "hash", block.Hash())
+	event.SendEvent(&event.Event{Typecode: event.ChainHeadEv, Data: block})
+	return nil
+}
+
 func (bc *BlockChain) writeSnapshotToDB(db rawdb.DatabaseWriter, root common.Hash, block *types.Block) {
 	snapshotInfo := types.SnapshotInfo{
 		Root: root,
@@ -683,9 +790,13 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	}
 
 	if reorg {
+		var indexer *accountIndexer
+		if bc.accountIndex {
+			indexer = newAccountIndexer(bc.db)
+		}
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != currentBlock.Hash() {
-			if err = bc.reorgChain(currentBlock, block, batch); err != nil {
+			if err = bc.reorgChain(currentBlock, block, batch, indexer); err != nil {
 				return false, err
 			}
 		}
@@ -693,6 +804,13 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		// Write the positional metadata for transaction/receipt lookups and preimages
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, block.NumberU64(), state.Preimages())
+		if indexer != nil {
+			detailtxs := make([]*types.DetailTx, len(receipts))
+			for i := 0; i < len(receipts); i++ {
+				detailtxs[i] = receipts[i].GetInternalTxsLog()
+			}
+			indexer.indexBlock(batch, block, detailtxs)
+		}
 		isCanon = true
 	}
 
@@ -712,6 +830,30 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	return isCanon, err
 }
 
+// EnableAccountIndex builds the missing part of the account index and keeps
+// it up to date as blocks are inserted.
+func (bc *BlockChain) EnableAccountIndex() error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+	if err := BuildAccountIndex(bc.db); err != nil {
+		return err
+	}
+	bc.accountIndex = true
+	return nil
+}
+
+// AccountIndexEnabled returns whether the account index is maintained.
+func (bc *BlockChain) AccountIndexEnabled() bool {
+	return bc.accountIndex
+}
+
+// SetFastSync enables or disables fast sync. Fast sync only runs while the
+// head of the chain is the genesis block, the downloaded chain must hold the
+// checkpoint block if it is set.
+func (bc *BlockChain) SetFastSync(enable bool, checkpoint common.Hash) {
+	bc.station.downloader.SetFastSync(enable, checkpoint)
+}
+
 // StatePruning enale/disable state pruning
 func (bc *BlockChain) StatePruning(enable bool) (bool, uint64) {
 	bc.chainmu.Lock()
@@ -902,7 +1044,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []*types.Log, error)
 	return 0, coalescedLogs, nil
 }
 
-func (bc *BlockChain) reorgChain(oldBlock, newBlock *types.Block, batch fdb.Batch) error {
+func (bc *BlockChain) reorgChain(oldBlock, newBlock *types.Block, batch fdb.Batch, indexer *accountIndexer) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
@@ -966,10 +1108,20 @@ func (bc *BlockChain) reorgChain(oldBlock, newBlock *types.Block, batch fdb.Batc
 		}
 	}
 
+	if indexer != nil {
+		for _, block := range oldChain {
+			indexer.unindexBlock(batch, block, rawdb.ReadDetailTxs(bc.db, block.Hash(), block.NumberU64()))
+		}
+	}
+
 	var addedTxs []*types.Transaction
 	for i := len(newChain) - 1; i >= 0; i-- {
 		bc.insert(batch, newChain[i])
 		rawdb.WriteTxLookupEntries(batch, newChain[i])
+		// newChain[0] is the block being written, its caller indexes it
+		if indexer != nil && i > 0 {
+			indexer.indexBlock(batch, newChain[i], rawdb.ReadDetailTxs(bc.db, newChain[i].Hash(), newChain[i].NumberU64()))
+		}
 		addedTxs = append(addedTxs, newChain[i].Txs...)
 	}
 
@@ -983,10 +1135,14 @@ func (bc *BlockChain) reorgChain(oldBlock, newBlock *types.Block, batch fdb.Batc
 func (bc *BlockChain) update() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+	pruneTimer := time.NewTicker(historyPruneInterval)
+	defer pruneTimer.Stop()
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-pruneTimer.C:
+			bc.pruneHistory()
 		case <-bc.quit:
 			return
 		}
@@ -40,6 +40,7 @@ type BlockGenerator struct {
 	gasPool  *common.GasPool
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+	prepared bool
 
 	config *params.ChainConfig
 	engine consensus.IEngine
@@ -90,6 +91,9 @@ func (bg *BlockGenerator) AddTxWithChain(tx *types.Transaction) {
 	if bg.gasPool == nil {
 		bg.SetCoinbase(bg.genesisBlock.Coinbase())
 	}
+	// the engine prepares the state before the transactions, as it does
+	// when the block is processed
+	bg.prepare()
 
 	bg.statedb.Prepare(tx.Hash(), common.Hash{}, len(bg.txs))
 
@@ -102,6 +106,16 @@ func (bg *BlockGenerator) AddTxWithChain(tx *types.Transaction) {
 	bg.receipts = append(bg.receipts, receipt)
 }
 
+func (bg *BlockGenerator) prepare() {
+	if bg.prepared {
+		return
+	}
+	if err := bg.engine.Prepare(bg, bg.header, bg.txs, nil, bg.statedb); err != nil {
+		panic(fmt.Sprintf("engine prepare error: %v", err))
+	}
+	bg.prepared = true
+}
+
 // CurrentHeader return current header
 func (bg *BlockGenerator) CurrentHeader() *types.Header {
 	return bg.parent.Head
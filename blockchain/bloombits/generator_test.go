@@ -0,0 +1,78 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func logsBloom(name string, topics ...common.Hash) types.Bloom {
+	return types.BytesToBloom(types.LogsBloom([]*types.Log{{Name: common.Name(name), Topics: topics}}).Bytes())
+}
+
+// Tests that the blooms of a section can be matched once rotated.
+func TestGeneratorMatcher(t *testing.T) {
+	const sectionSize = 16
+	var (
+		topic = common.BytesToHash([]byte("topic"))
+		other = common.BytesToHash([]byte("other"))
+	)
+
+	gen, err := NewGenerator(sectionSize)
+	assert.NoError(t, err)
+	for i := uint(0); i < sectionSize; i++ {
+		var bloom types.Bloom
+		switch i {
+		case 3:
+			bloom = logsBloom("contract", topic)
+		case 7:
+			bloom = logsBloom("contract", other)
+		case 12:
+			bloom = logsBloom("another", topic)
+		}
+		assert.NoError(t, gen.AddBloom(i, bloom))
+	}
+	assert.Equal(t, errSectionOutOfBounds, gen.AddBloom(sectionSize, types.Bloom{}))
+
+	retrieve := func(bit uint, section uint64) ([]byte, error) {
+		return gen.Bitset(bit)
+	}
+	tests := []struct {
+		filters [][][]byte
+		want    []uint64
+	}{
+		{[][][]byte{{[]byte("contract")}}, []uint64{3, 7}},
+		{[][][]byte{{[]byte("contract")}, {topic.Bytes()}}, []uint64{3}},
+		{[][][]byte{nil, {topic.Bytes()}}, []uint64{3, 12}},
+		{[][][]byte{{[]byte("contract"), []byte("another")}, {topic.Bytes(), other.Bytes()}}, []uint64{3, 7, 12}},
+		{[][][]byte{{[]byte("missing")}}, nil},
+	}
+	for i, tt := range tests {
+		numbers, err := NewMatcher(sectionSize, tt.filters).Match(0, retrieve)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, numbers, "test %d", i)
+	}
+
+	// blocks of later sections are offset by the section number
+	numbers, err := NewMatcher(sectionSize, [][][]byte{{[]byte("another")}}).Match(2, retrieve)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2*sectionSize + 12}, numbers)
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"bytes"
+
+	"github.com/fractalplatform/fractal/crypto"
+)
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Retriever returns the uncompressed bit vector of the given bloom bit of a
+// section.
+type Retriever func(bit uint, section uint64) ([]byte, error)
+
+// Matcher is a filter scheduler that runs the rotated bloom bits of a
+// section against a set of filters.
+//
+// The filters are a list of groups: a block matches when every group has
+// at least one matching key. An empty group matches everything.
+type Matcher struct {
+	sectionSize uint64
+	filters     [][]bloomIndexes
+}
+
+// NewMatcher creates a new matcher for the given section size and filters.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		// Gather the bit indexes of the filter rule, special casing the nil filter
+		if len(filter) == 0 {
+			continue
+		}
+		bloomBits := make([]bloomIndexes, len(filter))
+		for i, clause := range filter {
+			if clause == nil {
+				bloomBits = nil
+				break
+			}
+			bloomBits[i] = calcBloomIndexes(clause)
+		}
+		// Accumulate the filter rules if no nil rule was within
+		if bloomBits != nil {
+			m.filters = append(m.filters, bloomBits)
+		}
+	}
+	return m
+}
+
+// Match returns the numbers of the blocks of the section which potentially
+// match the filters, in ascending order.
+func (m *Matcher) Match(section uint64, retrieve Retriever) ([]uint64, error) {
+	var (
+		size    = int(m.sectionSize / 8)
+		result  = bytes.Repeat([]byte{0xff}, size)
+		fetched = make(map[uint][]byte)
+	)
+	fetch := func(bit uint) ([]byte, error) {
+		if bits, ok := fetched[bit]; ok {
+			return bits, nil
+		}
+		bits, err := retrieve(bit, section)
+		if err != nil {
+			return nil, err
+		}
+		fetched[bit] = bits
+		return bits, nil
+	}
+
+	for _, filter := range m.filters {
+		// OR the keys of the filter, a key matches if all its bits are set
+		group := make([]byte, size)
+		for _, idxs := range filter {
+			key := bytes.Repeat([]byte{0xff}, size)
+			for _, idx := range idxs {
+				bits, err := fetch(idx)
+				if err != nil {
+					return nil, err
+				}
+				for i := range key {
+					key[i] &= bits[i]
+				}
+			}
+			for i := range group {
+				group[i] |= key[i]
+			}
+		}
+		for i := range result {
+			result[i] &= group[i]
+		}
+	}
+
+	var numbers []uint64
+	for i, b := range result {
+		if b == 0 {
+			continue
+		}
+		for bit := uint(0); bit < 8; bit++ {
+			if b&(byte(1)<<(7-bit)) != 0 {
+				numbers = append(numbers, section*m.sectionSize+uint64(i)*8+uint64(bit))
+			}
+		}
+	}
+	return numbers, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
+)
+
+// readHeadBlock returns the head block of the database.
+func readHeadBlock(db fdb.Database) (*types.Block, error) {
+	hash := rawdb.ReadHeadBlockHash(db)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("empty database")
+	}
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		return nil, fmt.Errorf("head block %x not found", hash)
+	}
+	block := rawdb.ReadBlock(db, hash, *number)
+	if block == nil {
+		return nil, fmt.Errorf("head block #%d [%x] not found", *number, hash)
+	}
+	return block, nil
+}
+
+// SetHead rewinds the head of a stopped node's database to the canonical
+// block with the given number, or to its newest ancestor whose state is
+// available. The block state-outs of the dropped blocks are rolled back,
+// their canonical hashes and tx lookup entries are removed. It returns the
+// new head block.
+func SetHead(db fdb.Database, number uint64) (*types.Block, error) {
+	head, err := readHeadBlock(db)
+	if err != nil {
+		return nil, err
+	}
+	if number >= head.NumberU64() {
+		return nil, fmt.Errorf("block #%d isn't below the head block #%d", number, head.NumberU64())
+	}
+	hash := rawdb.ReadCanonicalHash(db, number)
+	block := rawdb.ReadBlock(db, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("canonical block #%d [%x] not found", number, hash)
+	}
+
+	cache := state.NewDatabase(db)
+	block, err = rewindToState(cache, block, func(hash common.Hash, number uint64) *types.Block {
+		return rawdb.ReadBlock(db, hash, number)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := state.TransToSpecBlock(db, cache, head.Hash(), block.Hash()); err != nil {
+		return nil, err
+	}
+
+	batch := db.NewBatch()
+	for n := head.NumberU64(); n > block.NumberU64(); n-- {
+		hash := rawdb.ReadCanonicalHash(db, n)
+		if body := rawdb.ReadBody(db, hash, n); body != nil {
+			for _, tx := range body.Transactions {
+				rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+			}
+		}
+		rawdb.DeleteCanonicalHash(batch, n)
+	}
+	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteOptBlockHash(batch, block.Hash())
+	if rawdb.ReadIrreversibleNumber(db) > block.NumberU64() {
+		rawdb.WriteIrreversibleNumber(batch, block.NumberU64())
+	}
+	rawdb.DeleteHeadFastBlockHash(batch)
+	rawdb.DeleteFastSyncPivot(batch)
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Rewound chain head", "from", head.NumberU64(), "to", block.NumberU64(), "hash", block.Hash())
+	return block, nil
+}
+
+// VerifyChain checks the canonical chain of the database from the genesis
+// to the head block: every block is stored with its number and total
+// difficulty and links to its parent, the bodies above the history tail
+// exist, and the root nodes of the head state and of the dpos snapshot
+// states exist. The tries aren't walked, a missing node below a root isn't
+// detected.
+func VerifyChain(db fdb.Database) error {
+	head, err := readHeadBlock(db)
+	if err != nil {
+		return err
+	}
+
+	var (
+		cache  = state.NewDatabase(db)
+		tail   = rawdb.ReadHistoryTail(db)
+		parent *types.Header
+		states int
+		logged = time.Now()
+	)
+	for number := uint64(0); number <= head.NumberU64(); number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			if number == 0 {
+				return fmt.Errorf("genesis block not found")
+			}
+			// the chain may be started from a specified block number
+			parent = nil
+			continue
+		}
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return fmt.Errorf("header #%d [%x] not found", number, hash)
+		}
+		if header.Hash() != hash {
+			return fmt.Errorf("header #%d hash mismatch, have %x want %x", number, header.Hash(), hash)
+		}
+		if n := rawdb.ReadHeaderNumber(db, hash); n == nil || *n != number {
+			return fmt.Errorf("header #%d [%x] number not found", number, hash)
+		}
+		if parent != nil && header.ParentHash != parent.Hash() {
+			return fmt.Errorf("header #%d parent mismatch, have %x want %x", number, header.ParentHash, parent.Hash())
+		}
+		if rawdb.ReadTd(db, hash, number) == nil {
+			return fmt.Errorf("td #%d [%x] not found", number, hash)
+		}
+		if (number == 0 || number >= tail) && !rawdb.HasBody(db, hash, number) {
+			return fmt.Errorf("body #%d [%x] not found", number, hash)
+		}
+		if info := rawdb.ReadSnapshot(db, types.SnapshotBlock{Number: number, BlockHash: header.ParentHash}); info != nil {
+			if _, err := state.New(info.Root, cache); err != nil {
+				return fmt.Errorf("snapshot #%d state %x not found", number, info.Root)
+			}
+		}
+		if _, err := state.New(header.Root, cache); err == nil {
+			states++
+		}
+		parent = header
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying chain", "number", number, "head", head.NumberU64())
+			logged = time.Now()
+		}
+	}
+	if _, err := state.New(head.Root(), cache); err != nil {
+		return fmt.Errorf("head state %x not found", head.Root())
+	}
+	if irreversible := rawdb.ReadIrreversibleNumber(db); irreversible > head.NumberU64() {
+		return fmt.Errorf("irreversible block #%d above the head block #%d", irreversible, head.NumberU64())
+	}
+	log.Info("Chain verified", "head", head.NumberU64(), "hash", head.Hash(), "states", states)
+	return nil
+}
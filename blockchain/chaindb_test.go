@@ -0,0 +1,106 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/processor"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/txpool"
+)
+
+func TestSetHead(t *testing.T) {
+	genesis := DefaultGenesis()
+	genesis.AllocAccounts = append(genesis.AllocAccounts, getDefaultGenesisAccounts()...)
+	source := newCanonical(t, genesis)
+	defer source.Stop()
+
+	var (
+		parentTime = genesis.Timestamp * uint64(time.Millisecond)
+		interval   = genesis.Config.DposCfg.BlockInterval * uint64(time.Millisecond)
+		engine     = dpos.New(dposConfig(genesis.Config), source)
+	)
+	tmpdb, err := deepCopyDB(source.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+		return crypto.Sign(content, systemPrikey)
+	})
+	blocks, _ := generateChain(genesis.Config, source.CurrentBlock(), engine, source, tmpdb, 20, func(i int, b *BlockGenerator) {
+		b.SetCoinbase(common.StrToName(genesis.Config.SysName))
+		b.OffsetTime(int64(engine.Slot(parentTime + interval*uint64(i+1))))
+	})
+	chain := newCanonical(t, genesis)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("insert chain err %v", err)
+	}
+	chain.Stop()
+
+	db := chain.db
+	if err := VerifyChain(db); err != nil {
+		t.Fatalf("verify chain err %v", err)
+	}
+	if _, err := SetHead(db, 20); err == nil {
+		t.Fatal("set head to the head block succeeded")
+	}
+	head, err := SetHead(db, 10)
+	if err != nil {
+		t.Fatalf("set head err %v", err)
+	}
+	if head.Hash() != blocks[9].Hash() {
+		t.Fatalf("head mismatch, have %d want 10", head.NumberU64())
+	}
+	if hash := rawdb.ReadCanonicalHash(db, 11); hash != (common.Hash{}) {
+		t.Fatalf("block #11 still canonical")
+	}
+	if err := VerifyChain(db); err != nil {
+		t.Fatalf("verify chain after set head err %v", err)
+	}
+
+	// the node restarts from the new head and imports the dropped blocks again
+	chainCfg, dposCfg, _, err := SetupGenesisBlock(db, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainCfg.SysTokenID, chainCfg.SysTokenDecimals = chain.Config().SysTokenID, chain.Config().SysTokenDecimals
+	chain, err = NewBlockChain(db, false, vm.Config{}, chainCfg, nil, 0, txpool.SenderCacher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	bc := struct {
+		*BlockChain
+		consensus.IEngine
+	}{chain, dpos.New(dposCfg, chain)}
+	chain.SetValidator(processor.NewBlockValidator(&bc, bc.IEngine))
+	chain.SetProcessor(processor.NewStateProcessor(&bc, bc.IEngine))
+	if chain.CurrentBlock().Hash() != head.Hash() {
+		t.Fatalf("restarted head mismatch, have %d want 10", chain.CurrentBlock().NumberU64())
+	}
+	if _, err := chain.InsertChain(blocks[10:]); err != nil {
+		t.Fatalf("insert dropped blocks err %v", err)
+	}
+}
@@ -84,9 +84,11 @@ type Downloader struct {
 	loopWG          sync.WaitGroup
 	downloadTrigger chan struct{}
 	// bloom           HashBloom
-	maxNumber   uint64
-	knownBlocks mapset.Set
-	subs        []router.Subscription
+	maxNumber    uint64
+	knownBlocks  mapset.Set
+	subs         []router.Subscription
+	fastSyncMode int32        // whether fast sync is enabled, accessed atomically
+	checkpoint   atomic.Value // trusted block hash the fast sync pivot must be linked to
 }
 
 // NewDownloader create a new downloader
@@ -411,6 +413,10 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 	router.StationRegister(stationSearch)
 	defer router.StationUnregister(stationSearch)
 
+	if dl.fastSyncing() {
+		return dl.fastSync(stationSearch, status)
+	}
+
 	headNumber := head.NumberU64()
 	if headNumber > statusNumber {
 		headNumber = statusNumber
@@ -425,7 +431,6 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 		return false
 	}
 	log.Debug("downloader ancestro:", "ancestor", ancestor)
-	downloadStart := ancestor + 1
 	downloadAmount := statusNumber - ancestor
 	if downloadAmount == 0 { // maybe the status of remote has changed
 		log.Debug(fmt.Sprintf("Why-1?:number: head:%d headNumber:%d statusNumber: %d", head.NumberU64(), headNumber, statusNumber))
@@ -433,25 +438,46 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 		log.Debug(fmt.Sprintf("Why-3?:td: head:%d status: %d", dl.blockchain.GetTd(head.Hash(), head.NumberU64()).Uint64(), statusTD.Uint64()))
 		return false
 	}
+	if _, ok := dl.downloadRange(stationSearch, status, ancestor, downloadAmount, dl.blockchain.InsertChain); !ok {
+		return false
+	}
+
+	head = dl.blockchain.CurrentBlock()
+	if statusTD.Cmp(dl.blockchain.GetTd(head.Hash(), head.NumberU64())) <= 0 {
+		dl.broadcastStatus(&NewBlockHashesData{
+			Hash:      head.Hash(),
+			Number:    head.NumberU64(),
+			TD:        dl.blockchain.GetTd(head.Hash(), head.NumberU64()),
+			Completed: true,
+		})
+		return false
+	}
+	return true
+}
+
+// downloadRange downloads at most 1024 blocks after ancestor from the station
+// and hands them to insert. It returns the number of the last inserted block,
+// ok is false if the download plan couldn't be fetched from the station.
+func (dl *Downloader) downloadRange(stationSearch router.Station, status *stationStatus, ancestor uint64, downloadAmount uint64, insert func(types.Blocks) (int, error)) (uint64, bool) {
+	downloadStart := ancestor + 1
 	if downloadAmount > 1024 {
 		downloadAmount = 1024
 	}
 	downloadEnd := ancestor + downloadAmount
 	downloadBulk := uint64(64)
 	var numbers []uint64
-	var hashes []common.Hash
 	downloadSkip := downloadBulk
 	for i := downloadStart; i <= downloadEnd; i += downloadSkip + 1 {
 		numbers = append(numbers, i)
 	}
-	hashes, err = getBlockHashes(stationSearch, status.station, &getBlcokHashByNumber{
+	hashes, err := getBlockHashes(stationSearch, status.station, &getBlcokHashByNumber{
 		Number:  downloadStart,
 		Amount:  uint64(len(numbers)),
 		Skip:    downloadSkip,
 		Reverse: false}, status.errCh)
 	if err != nil || len(hashes) != len(numbers) {
 		log.Debug("getBlockHashes 1 err", "err", err, "len(hashes)", len(hashes), "len(numbers)", len(numbers))
-		return false
+		return 0, false
 	}
 	if numbers[len(numbers)-1] != downloadEnd {
 		numbers = append(numbers, downloadEnd)
@@ -462,7 +488,7 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 			Reverse: false}, status.errCh)
 		if err != nil || len(hash) != 1 {
 			log.Debug("getBlockHashes 2 err", "len(hash)", len(hash), "err", err)
-			return false
+			return 0, false
 		}
 		hashes = append(hashes, hash...)
 	}
@@ -478,7 +504,7 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 	// log.Debug(info3)
 	// info4 := fmt.Sprintf("4 numbers:%d hashes:%d\n", len(numbers), len(hashes))
 	// log.Debug(info4)
-	n, err := dl.assignDownloadTask(hashes, numbers)
+	n, err := dl.assignDownloadTask(hashes, numbers, insert)
 	status.ancestor = n
 	if err != nil {
 		log.Warn("Insert error:", "number:", n, "error", err)
@@ -489,18 +515,7 @@ func (dl *Downloader) multiplexDownload(status *stationStatus) bool {
 			router.SendTo(nil, nil, router.OneMinuteLimited, status.station) // disconnect and put into blacklist
 		}
 	}
-
-	head = dl.blockchain.CurrentBlock()
-	if statusTD.Cmp(dl.blockchain.GetTd(head.Hash(), head.NumberU64())) <= 0 {
-		dl.broadcastStatus(&NewBlockHashesData{
-			Hash:      head.Hash(),
-			Number:    head.NumberU64(),
-			TD:        dl.blockchain.GetTd(head.Hash(), head.NumberU64()),
-			Completed: true,
-		})
-		return false
-	}
-	return true
+	return n, true
 }
 
 func (dl *Downloader) loopStart() {
@@ -533,7 +548,7 @@ func (dl *Downloader) loop() {
 	}
 }
 
-func (dl *Downloader) assignDownloadTask(hashes []common.Hash, numbers []uint64) (uint64, *Error) {
+func (dl *Downloader) assignDownloadTask(hashes []common.Hash, numbers []uint64, insert func(types.Blocks) (int, error)) (uint64, *Error) {
 	log.Debug("assingDownloadTask:", "hashesLen", len(hashes), "numbersLen", len(numbers), "numbers", numbers)
 	workers := &simpleHeap{cmp: dl.remotes.cmp}
 	dl.remotesMutex.RLock()
@@ -603,7 +618,7 @@ func (dl *Downloader) assignDownloadTask(hashes []common.Hash, numbers []uint64)
 		if blocks == nil {
 			return start - 1, nil
 		}
-		if index, err := dl.blockchain.InsertChain(blocks); err != nil {
+		if index, err := insert(blocks); err != nil {
 			return blocks[index].NumberU64() - 1, &Error{err, other}
 		}
 	}
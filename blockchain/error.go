@@ -34,6 +34,10 @@ var (
 
 	// ErrBlacklistedHash is returned if a block to import is on the blacklist.
 	ErrBlacklistedHash = errors.New("blacklisted hash")
+
+	// ErrHistoryPruned is returned for the body, receipts or logs of a block
+	// removed by history pruning.
+	ErrHistoryPruned = errors.New("block history pruned")
 )
 
 // GenesisMismatchError is raised when trying to overwrite an existing
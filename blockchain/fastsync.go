@@ -0,0 +1,428 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+	router "github.com/fractalplatform/fractal/event"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/state"
+	trie "github.com/fractalplatform/fractal/state/mtp"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
+)
+
+const (
+	fsMinFullBlocks  = 64  // Number of blocks behind the head that are always fully processed
+	fsNodeBatch      = 384 // Number of state trie nodes requested from a station at once
+	fsMaxStalls      = 8   // Number of rounds without any node delivered before fast sync gives up
+	fsSnapshotEpochs = 2   // Number of epochs of snapshot states downloaded along the pivot state
+	fsPivotStations  = 2   // Number of stations confirming the pivot without a trusted checkpoint
+)
+
+var (
+	errFastSyncAborted = errors.New("fast sync aborted")
+	errNoStation       = errors.New("no station to download from")
+	errStateStalled    = errors.New("state download stalled")
+	errCheckpoint      = errors.New("fast sync checkpoint not in the downloaded chain")
+	errPivotConfirm    = errors.New("fast sync pivot not confirmed by enough stations")
+)
+
+// SetFastSync enables or disables fast sync. Without a trusted checkpoint the
+// pivot must be confirmed by several stations.
+func (dl *Downloader) SetFastSync(enable bool, checkpoint common.Hash) {
+	dl.checkpoint.Store(checkpoint)
+	if enable {
+		atomic.StoreInt32(&dl.fastSyncMode, 1)
+	} else {
+		atomic.StoreInt32(&dl.fastSyncMode, 0)
+	}
+}
+
+// fastSyncing returns whether the next download round runs fast sync: it's
+// enabled and the head of the chain hasn't moved past the genesis block yet.
+func (dl *Downloader) fastSyncing() bool {
+	return atomic.LoadInt32(&dl.fastSyncMode) == 1 && dl.blockchain.CurrentBlock().NumberU64() == 0
+}
+
+// fastSync runs one round of fast sync with the station. The blocks are
+// downloaded without being executed until the announced head, then the state
+// of the pivot block is downloaded and the pivot becomes the head of the chain,
+// from where full sync takes over. The pivot and the latest downloaded block
+// are recorded in the database, so an interrupted fast sync resumes from there.
+func (dl *Downloader) fastSync(stationSearch router.Station, status *stationStatus) bool {
+	bc := dl.blockchain
+	if pivot := rawdb.ReadFastSyncPivot(bc.db); pivot != (common.Hash{}) {
+		return dl.fastSyncState(pivot)
+	}
+
+	statusNumber := status.getStatus().Number
+	headNumber := bc.CurrentFastBlock().NumberU64()
+	if headNumber > statusNumber {
+		headNumber = statusNumber
+	}
+	ancestor, err := dl.findAncestor(stationSearch, status.station, headNumber, 0, status.errCh)
+	if err != nil {
+		log.Warn("Fast sync ancestor err", "err", err, "errid:", err.eid)
+		return false
+	}
+	if ancestor < statusNumber {
+		n, ok := dl.downloadRange(stationSearch, status, ancestor, statusNumber-ancestor, bc.InsertFastBlocks)
+		if !ok || n <= ancestor {
+			return false
+		}
+		if n < statusNumber {
+			return true
+		}
+	}
+
+	pivot := dl.fastSyncPivot()
+	if pivot == nil {
+		log.Info("Fast sync pivot not found, switching to full sync", "number", statusNumber)
+		if err := bc.CommitFastSync(bc.CurrentBlock().Hash()); err != nil {
+			log.Error("Fast sync rollback failed", "err", err)
+			return false
+		}
+		atomic.StoreInt32(&dl.fastSyncMode, 0)
+		return true
+	}
+	rawdb.WriteFastSyncPivot(bc.db, pivot.Hash())
+	return dl.fastSyncState(pivot.Hash())
+}
+
+// fastSyncPivot picks the pivot of fast sync: the latest snapshot block that is
+// irreversible according to the latest downloaded block. The states of the
+// snapshot blocks are never pruned, every station keeps serving them.
+func (dl *Downloader) fastSyncPivot() *types.Header {
+	bc := dl.blockchain
+	head := bc.CurrentFastBlock().Header()
+	number := head.ProposedIrreversible
+	if number == 0 || number > head.Number.Uint64() {
+		if head.Number.Uint64() <= fsMinFullBlocks {
+			return nil
+		}
+		number = head.Number.Uint64() - fsMinFullBlocks
+	}
+
+	interval := bc.chainConfig.SnapshotInterval * uint64(time.Millisecond)
+	for header := bc.GetHeaderByNumber(number); header != nil && header.Number.Uint64() > 0; {
+		parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil
+		}
+		if parent.Time.Uint64()/interval != header.Time.Uint64()/interval {
+			return header
+		}
+		header = parent
+	}
+	return nil
+}
+
+// fastSyncState downloads the states needed to process the blocks after the
+// pivot and makes the pivot the head of the chain.
+func (dl *Downloader) fastSyncState(hash common.Hash) bool {
+	bc := dl.blockchain
+	pivot := bc.GetHeaderByHash(hash)
+	if pivot == nil {
+		log.Warn("Fast sync pivot missing", "hash", hash)
+		rawdb.DeleteFastSyncPivot(bc.db)
+		return true
+	}
+
+	if err := dl.verifyPivot(pivot); err != nil {
+		log.Warn("Fast sync pivot not trusted", "pivot", pivot.Number, "hash", hash, "err", err)
+		return false
+	}
+
+	log.Info("Fast sync downloading state", "pivot", pivot.Number, "hash", hash, "root", pivot.Root)
+	snapshots := dl.snapshotHeaders(pivot)
+	for _, header := range snapshots {
+		if err := dl.syncState(header.Root); err != nil {
+			log.Warn("Fast sync state download failed", "root", header.Root, "err", err)
+			return false
+		}
+	}
+	if err := dl.verifySeals(snapshots); err != nil {
+		log.Warn("Fast sync seal verification failed", "pivot", pivot.Number, "hash", hash, "err", err)
+		return false
+	}
+	if err := bc.CommitFastSync(hash); err != nil {
+		log.Error("Fast sync commit failed", "err", err)
+		return false
+	}
+	atomic.StoreInt32(&dl.fastSyncMode, 0)
+	return true
+}
+
+// verifyPivot checks that the pivot belongs to the trusted chain: the chain
+// downloaded holds the trusted checkpoint, or without a checkpoint, more
+// stations report the pivot at its number than any other block and at least
+// fsPivotStations do.
+func (dl *Downloader) verifyPivot(pivot *types.Header) error {
+	bc := dl.blockchain
+	if checkpoint, _ := dl.checkpoint.Load().(common.Hash); checkpoint != (common.Hash{}) {
+		header := bc.GetHeaderByHash(checkpoint)
+		if header == nil {
+			return errCheckpoint
+		}
+		if canonical := bc.GetHeaderByNumber(header.Number.Uint64()); canonical == nil || canonical.Hash() != checkpoint {
+			return errCheckpoint
+		}
+		return nil
+	}
+
+	agreed, disagreed := 0, 0
+	for _, worker := range dl.stations() {
+		remote := worker.station
+		station := router.NewLocalStation(fmt.Sprintf("pv%d%s", rand.Int(), remote.Name()), nil)
+		router.StationRegister(station)
+		hashes, err := getBlockHashes(station, remote, &getBlcokHashByNumber{
+			Number: pivot.Number.Uint64(),
+			Amount: 1,
+		}, worker.errCh)
+		router.StationUnregister(station)
+		if err != nil {
+			log.Debug("Fast sync pivot hash err", "err", err, "errid:", err.eid)
+			continue
+		}
+		if hashes[0] == pivot.Hash() {
+			agreed++
+		} else {
+			disagreed++
+		}
+	}
+	if agreed < fsPivotStations || agreed <= disagreed {
+		return errPivotConfirm
+	}
+	return nil
+}
+
+// snapshotHeaders returns the headers whose states are downloaded for the
+// pivot, from the pivot back: the pivot itself and the snapshot blocks the
+// consensus engine reads while processing the blocks after it. The snapshot
+// records of the blocks are written as full sync would have done.
+func (dl *Downloader) snapshotHeaders(pivot *types.Header) []*types.Header {
+	bc := dl.blockchain
+	var (
+		interval = bc.chainConfig.SnapshotInterval * uint64(time.Millisecond)
+		window   = fsSnapshotEpochs * bc.chainConfig.DposCfg.EpochInterval * uint64(time.Millisecond)
+		headers  = []*types.Header{pivot}
+	)
+	for header := pivot; header.Number.Uint64() > 0; {
+		parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			break
+		}
+		if parent.Time.Uint64()/interval != header.Time.Uint64()/interval {
+			rawdb.WriteSnapshot(bc.db, types.SnapshotBlock{
+				Number:    header.Number.Uint64(),
+				BlockHash: header.ParentHash,
+			}, types.SnapshotInfo{Root: header.Root})
+			if header != pivot {
+				headers = append(headers, header)
+			}
+			if header.Time.Uint64()+window < pivot.Time.Uint64() {
+				break
+			}
+		}
+		header = parent
+	}
+	return headers
+}
+
+// verifySeals checks the seals of the blocks between the oldest downloaded
+// snapshot and the pivot. The producer of a block must be valid in the state
+// of the snapshot before it or after it, as it may have been elected or have
+// changed its key in between. The older blocks are linked to the trusted
+// pivot by their hashes.
+func (dl *Downloader) verifySeals(snapshots []*types.Header) error {
+	bc := dl.blockchain
+	states := make([]*state.StateDB, len(snapshots))
+	for i, header := range snapshots {
+		statedb, err := bc.StateAt(header.Root)
+		if err != nil {
+			return err
+		}
+		states[i] = statedb
+	}
+	if len(snapshots) == 1 {
+		return bc.Validator().ValidateProducer(snapshots[0], states[0])
+	}
+	for i := len(snapshots) - 1; i > 0; i-- {
+		for number := snapshots[i].Number.Uint64() + 1; number <= snapshots[i-1].Number.Uint64(); number++ {
+			header := bc.GetHeaderByNumber(number)
+			if header == nil {
+				return fmt.Errorf("fast sync block %d missing", number)
+			}
+			if bc.Validator().ValidateProducer(header, states[i]) == nil {
+				continue
+			}
+			if err := bc.Validator().ValidateProducer(header, states[i-1]); err != nil {
+				return fmt.Errorf("block %d: %v", number, err)
+			}
+		}
+	}
+	return nil
+}
+
+// stations returns the stations currently known by the downloader.
+func (dl *Downloader) stations() []*stationStatus {
+	dl.remotesMutex.RLock()
+	defer dl.remotesMutex.RUnlock()
+	stations := make([]*stationStatus, 0, len(dl.remotes.data))
+	for _, v := range dl.remotes.data {
+		stations = append(stations, v.(*stationStatus))
+	}
+	return stations
+}
+
+// syncState downloads the state trie rooted at root from all the stations.
+// The trie nodes are committed bottom up, so an interrupted download resumes
+// from the nodes already in the database.
+func (dl *Downloader) syncState(root common.Hash) error {
+	type nodeData struct {
+		hashes []common.Hash
+		data   [][]byte
+	}
+	var (
+		db     = dl.blockchain.db
+		sched  = trie.NewSync(root, db, nil)
+		batch  = db.NewBatch()
+		retry  []common.Hash
+		stalls int
+		nodes  int
+		report = time.Now()
+	)
+	for sched.Pending() > 0 {
+		select {
+		case <-dl.quit:
+			return errFastSyncAborted
+		default:
+		}
+		workers := dl.stations()
+		if len(workers) == 0 {
+			return errNoStation
+		}
+
+		// Hand a batch of missing nodes to every station
+		results := make(chan *nodeData, len(workers))
+		requests := 0
+		for _, worker := range workers {
+			hashes := make([]common.Hash, 0, fsNodeBatch)
+			if n := len(retry); n > 0 {
+				if n > fsNodeBatch {
+					n = fsNodeBatch
+				}
+				hashes = append(hashes, retry[:n]...)
+				retry = retry[n:]
+			}
+			if len(hashes) < fsNodeBatch {
+				hashes = append(hashes, sched.Missing(fsNodeBatch-len(hashes))...)
+			}
+			if len(hashes) == 0 {
+				break
+			}
+			requests++
+			go func(worker *stationStatus, hashes []common.Hash) {
+				remote := worker.station
+				station := router.NewLocalStation(fmt.Sprintf("ns%d%s", rand.Int(), remote.Name()), nil)
+				router.StationRegister(station)
+				defer router.StationUnregister(station)
+				data, err := getNodeData(station, remote, hashes, worker.errCh)
+				if err != nil {
+					log.Debug("Fast sync node data err", "err", err, "errid:", err.eid)
+				}
+				results <- &nodeData{hashes: hashes, data: data}
+			}(worker, hashes)
+		}
+
+		// Feed the delivered nodes to the scheduler, the others are requested again
+		delivered := 0
+		for i := 0; i < requests; i++ {
+			res := <-results
+			received := make(map[common.Hash][]byte, len(res.data))
+			for _, blob := range res.data {
+				received[crypto.Keccak256Hash(blob)] = blob
+			}
+			items := make([]trie.SyncResult, 0, len(received))
+			for _, hash := range res.hashes {
+				if blob, ok := received[hash]; ok {
+					items = append(items, trie.SyncResult{Hash: hash, Data: blob})
+				} else {
+					retry = append(retry, hash)
+				}
+			}
+			if _, index, err := sched.Process(items); err != nil {
+				return fmt.Errorf("invalid state node %x: %v", items[index].Hash, err)
+			}
+			delivered += len(items)
+		}
+		if delivered == 0 {
+			if stalls++; stalls > fsMaxStalls {
+				return errStateStalled
+			}
+			continue
+		}
+		stalls = 0
+		nodes += delivered
+
+		if _, err := sched.Commit(batch); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= fdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(report) > 8*time.Second {
+			log.Info("Fast sync state progress", "root", root, "nodes", nodes, "pending", sched.Pending())
+			report = time.Now()
+		}
+	}
+	if _, err := sched.Commit(batch); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Fast sync state downloaded", "root", root, "nodes", nodes)
+	return nil
+}
+
+func getNodeData(from router.Station, to router.Station, req []common.Hash, errch chan struct{}) ([][]byte, *Error) {
+	se := &router.Event{
+		From:     from,
+		To:       to,
+		Typecode: router.P2PGetNodeDataMsg,
+		Data:     req,
+	}
+	timeout := time.Second + time.Duration(len(req))*(10*time.Millisecond)
+	e, err := syncReq(se, router.P2PNodeDataMsg, [][]byte{}, timeout, errch)
+	if err != nil {
+		return nil, err
+	}
+	return e.Data.([][]byte), nil
+}
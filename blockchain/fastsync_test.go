@@ -0,0 +1,242 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/state"
+	trie "github.com/fractalplatform/fractal/state/mtp"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// fastSyncStates downloads the states of the pivot from the node source,
+// as the stations would serve them.
+func fastSyncStates(t *testing.T, chain *BlockChain, pivot *types.Header, node func(common.Hash) ([]byte, error)) []*types.Header {
+	snapshots := chain.station.downloader.snapshotHeaders(pivot)
+	for _, header := range snapshots {
+		sched := trie.NewSync(header.Root, chain.db, nil)
+		for sched.Pending() > 0 {
+			var results []trie.SyncResult
+			for _, hash := range sched.Missing(fsNodeBatch) {
+				data, err := node(hash)
+				if err != nil {
+					t.Fatalf("source node %x err %v", hash, err)
+				}
+				results = append(results, trie.SyncResult{Hash: hash, Data: data})
+			}
+			if _, index, err := sched.Process(results); err != nil {
+				t.Fatalf("process node %x err %v", results[index].Hash, err)
+			}
+			if _, err := sched.Commit(chain.db); err != nil {
+				t.Fatalf("commit err %v", err)
+			}
+		}
+	}
+	return snapshots
+}
+
+// rekeyTx replaces the key of the account.
+func rekeyTx(t *testing.T, name string, oldKey, newKey *ecdsa.PrivateKey, statedb *state.StateDB) *types.Transaction {
+	am, err := accountmanager.NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := am.GetNonce(common.StrToName(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := rlp.EncodeToBytes(&accountmanager.AccountAuthorAction{
+		AuthorActions: []*accountmanager.AuthorAction{
+			{ActionType: accountmanager.AddAuthor, Author: common.NewAuthor(common.BytesToPubKey(crypto.FromECDSAPub(&newKey.PublicKey)), 1)},
+			{ActionType: accountmanager.DeleteAuthor, Author: common.NewAuthor(common.BytesToPubKey(crypto.FromECDSAPub(&oldKey.PublicKey)), 1)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := types.NewAction(types.UpdateAccountAuthor, common.StrToName(name), common.StrToName(params.DefaultChainconfig.AccountName), nonce, uint64(0), uint64(210000), big.NewInt(0), payload, nil)
+	tx := types.NewTransaction(uint64(0), big.NewInt(2), action)
+	keyPair := types.MakeKeyPair(oldKey, []uint64{0})
+	if err := types.SignActionWithMultiKey(action, tx, types.NewSigner(params.DefaultChainconfig.ChainID), 0, []*types.KeyPair{keyPair}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestFastSyncState(t *testing.T) {
+	genesis := DefaultGenesis()
+	genesis.AllocAccounts = append(genesis.AllocAccounts, getDefaultGenesisAccounts()...)
+	source := newCanonical(t, genesis)
+	defer source.Stop()
+
+	// a few snapshot intervals of blocks produced by the system candidate,
+	// which changes its key in the first block
+	var (
+		n          = 5 * genesis.Config.SnapshotInterval / genesis.Config.DposCfg.BlockInterval
+		parentTime = genesis.Timestamp * uint64(time.Millisecond)
+		interval   = genesis.Config.DposCfg.BlockInterval * uint64(time.Millisecond)
+		engine     = dpos.New(dposConfig(genesis.Config), source)
+	)
+	newKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpdb, err := deepCopyDB(source.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, _ := generateChain(genesis.Config, source.CurrentBlock(), engine, source, tmpdb, int(n), func(i int, b *BlockGenerator) {
+		key := systemPrikey
+		if i > 0 {
+			key = newKey
+		}
+		engine.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+			return crypto.Sign(content, key)
+		})
+		b.SetCoinbase(common.StrToName(genesis.Config.SysName))
+		b.OffsetTime(int64(engine.Slot(parentTime + interval*uint64(i+1))))
+		if i == 0 {
+			b.AddTx(rekeyTx(t, genesis.Config.SysName, systemPrikey, newKey, b.statedb))
+		}
+	})
+	if _, err := source.InsertChain(blocks); err != nil {
+		t.Fatalf("insert chain err %v", err)
+	}
+
+	chain := newCanonical(t, genesis)
+	defer chain.Stop()
+
+	// blocks are written without being executed
+	if _, err := chain.InsertFastBlocks(blocks); err != nil {
+		t.Fatalf("insert fast blocks err %v", err)
+	}
+	if chain.CurrentBlock().NumberU64() != 0 {
+		t.Fatalf("fast blocks moved the head to %d", chain.CurrentBlock().NumberU64())
+	}
+	if fast := chain.CurrentFastBlock(); fast.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("fast head mismatch, have %d want %d", fast.NumberU64(), blocks[len(blocks)-1].NumberU64())
+	}
+
+	dl := chain.station.downloader
+	pivot := dl.fastSyncPivot()
+	if pivot == nil {
+		t.Fatal("fast sync pivot not found")
+	}
+	// the pivot is trusted through the checkpoint, or the stations
+	if err := dl.verifyPivot(pivot); err != errPivotConfirm {
+		t.Fatalf("want %v, got %v", errPivotConfirm, err)
+	}
+	dl.SetFastSync(true, common.BytesToHash([]byte("checkpoint")))
+	if err := dl.verifyPivot(pivot); err != errCheckpoint {
+		t.Fatalf("want %v, got %v", errCheckpoint, err)
+	}
+	dl.SetFastSync(true, blocks[1].Hash())
+	if err := dl.verifyPivot(pivot); err != nil {
+		t.Fatalf("verify pivot err %v", err)
+	}
+
+	// the genesis state doesn't know the new key
+	genesisState, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Validator().ValidateProducer(blocks[1].Header(), genesisState); err != dpos.ErrIllegalCandidatePubKey {
+		t.Fatalf("want %v, got %v", dpos.ErrIllegalCandidatePubKey, err)
+	}
+
+	// serve the trie nodes straight from the source chain, the seals made
+	// with either key are checked against the downloaded states
+	snapshots := fastSyncStates(t, chain, pivot, source.stateCache.TrieDB().Node)
+	if len(snapshots) < 2 {
+		t.Fatalf("%d snapshot states downloaded", len(snapshots))
+	}
+	if err := dl.verifySeals(snapshots); err != nil {
+		t.Fatalf("verify seals err %v", err)
+	}
+	if err := chain.CommitFastSync(pivot.Hash()); err != nil {
+		t.Fatalf("commit fast sync err %v", err)
+	}
+	if chain.CurrentBlock().Hash() != pivot.Hash() {
+		t.Fatalf("head mismatch, have %d want %d", chain.CurrentBlock().NumberU64(), pivot.Number.Uint64())
+	}
+	if chain.GetHeaderByNumber(pivot.Number.Uint64()+1) != nil {
+		t.Fatal("blocks after the pivot still canonical")
+	}
+
+	// full sync takes over after the pivot
+	if _, err := chain.InsertChain(blocks[pivot.Number.Uint64():]); err != nil {
+		t.Fatalf("insert chain after pivot err %v", err)
+	}
+	checkBlocksInsert(t, chain, blocks)
+}
+
+func TestFastSyncForgedHeader(t *testing.T) {
+	genesis := DefaultGenesis()
+	genesis.AllocAccounts = append(genesis.AllocAccounts, getDefaultGenesisAccounts()...)
+	source := newCanonical(t, genesis)
+	defer source.Stop()
+
+	// a chain claiming the system candidate as producer, sealed by another key
+	var (
+		n          = 3 * genesis.Config.SnapshotInterval / genesis.Config.DposCfg.BlockInterval
+		parentTime = genesis.Timestamp * uint64(time.Millisecond)
+		interval   = genesis.Config.DposCfg.BlockInterval * uint64(time.Millisecond)
+		engine     = dpos.New(dposConfig(genesis.Config), source)
+	)
+	forger, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpdb, err := deepCopyDB(source.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+		return crypto.Sign(content, forger)
+	})
+	blocks, _ := generateChain(genesis.Config, source.CurrentBlock(), engine, source, tmpdb, int(n), func(i int, b *BlockGenerator) {
+		b.SetCoinbase(common.StrToName(genesis.Config.SysName))
+		b.OffsetTime(int64(engine.Slot(parentTime + interval*uint64(i+1))))
+	})
+
+	// the blocks are written, their seals are checked once the states are
+	// downloaded
+	chain := newCanonical(t, genesis)
+	defer chain.Stop()
+	if _, err := chain.InsertFastBlocks(blocks); err != nil {
+		t.Fatalf("insert fast blocks err %v", err)
+	}
+	pivot := chain.station.downloader.fastSyncPivot()
+	if pivot == nil {
+		t.Fatal("fast sync pivot not found")
+	}
+	snapshots := fastSyncStates(t, chain, pivot, source.stateCache.TrieDB().Node)
+	if err := chain.station.downloader.verifySeals(snapshots); err == nil || !strings.Contains(err.Error(), dpos.ErrIllegalCandidatePubKey.Error()) {
+		t.Fatalf("want %v, got %v", dpos.ErrIllegalCandidatePubKey, err)
+	}
+}
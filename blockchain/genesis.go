@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	am "github.com/fractalplatform/fractal/accountmanager"
 	at "github.com/fractalplatform/fractal/asset"
@@ -43,9 +44,19 @@ import (
 
 // GenesisAccount is an account in the state of the genesis block.
 type GenesisAccount struct {
-	Name    string        `json:"name,omitempty"`
-	Founder string        `json:"founder,omitempty"`
-	PubKey  common.PubKey `json:"pubKey,omitempty"`
+	Name     string                      `json:"name,omitempty"`
+	Founder  string                      `json:"founder,omitempty"`
+	PubKey   common.PubKey               `json:"pubKey,omitempty"`
+	Balances []*GenesisBalance           `json:"balances,omitempty"`
+	Code     hexutil.Bytes               `json:"code,omitempty"`
+	Storage  map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// GenesisBalance is an amount of asset transferred from the asset owner to
+// the account in the genesis block.
+type GenesisBalance struct {
+	Asset  string   `json:"asset,omitempty"`
+	Amount *big.Int `json:"amount,omitempty"`
 }
 
 // GenesisCandidate is an cadicate in the state of the genesis block.
@@ -94,6 +105,9 @@ func dposConfig(cfg *params.ChainConfig) *dpos.Config {
 		BackupScheduleSize:            cfg.DposCfg.BackupScheduleSize,
 		EpochInterval:                 cfg.DposCfg.EpochInterval,
 		FreezeEpochSize:               cfg.DposCfg.FreezeEpochSize,
+		JailMissPercent:               cfg.DposCfg.JailMissPercent,
+		JailEpochSize:                 cfg.DposCfg.JailEpochSize,
+		SlashPercent:                  cfg.DposCfg.SlashPercent,
 		AccountName:                   cfg.DposName,
 		SystemName:                    cfg.SysName,
 		SystemURL:                     cfg.ChainURL,
@@ -283,6 +297,7 @@ func (g *Genesis) ToBlock(db fdb.Database) (*types.Block, []*types.Receipt, erro
 		internalLogs, err := accountManager.Process(&types.AccountManagerContext{
 			Action:      action,
 			Number:      0,
+			Time:        timestamp,
 			CurForkID:   g.ForkID,
 			ChainConfig: g.Config,
 		})
@@ -349,6 +364,7 @@ func (g *Genesis) ToBlock(db fdb.Database) (*types.Block, []*types.Receipt, erro
 		internalLogs, err := accountManager.Process(&types.AccountManagerContext{
 			Action:      action,
 			Number:      0,
+			Time:        timestamp,
 			CurForkID:   g.ForkID,
 			ChainConfig: g.Config,
 		})
@@ -357,6 +373,60 @@ func (g *Genesis) ToBlock(db fdb.Database) (*types.Block, []*types.Receipt, erro
 		}
 		internals = append(internals, &types.DetailAction{InternalActions: internalLogs})
 	}
+
+	balActions := []*types.Action{}
+	for _, account := range g.AllocAccounts {
+		for _, balance := range account.Balances {
+			if balance.Amount == nil || balance.Amount.Sign() <= 0 {
+				return nil, nil, fmt.Errorf("genesis account %v balance of %v invalid amount", account.Name, balance.Asset)
+			}
+			assetInfo, err := accountManager.GetAssetInfoByName(balance.Asset)
+			if err != nil || assetInfo == nil {
+				return nil, nil, fmt.Errorf("genesis account %v balance asset %v not exist", account.Name, balance.Asset)
+			}
+			balActions = append(balActions, types.NewAction(
+				types.Transfer,
+				assetInfo.Owner,
+				common.StrToName(account.Name),
+				0,
+				assetInfo.AssetId,
+				0,
+				balance.Amount,
+				nil,
+				nil,
+			))
+		}
+	}
+
+	for index, action := range balActions {
+		internalLogs, err := accountManager.Process(&types.AccountManagerContext{
+			Action:      action,
+			Number:      0,
+			Time:        timestamp,
+			CurForkID:   g.ForkID,
+			ChainConfig: g.Config,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("genesis transfer balance %v,err %v", index, err)
+		}
+		internals = append(internals, &types.DetailAction{InternalActions: internalLogs})
+	}
+
+	for _, account := range g.AllocAccounts {
+		if len(account.Code) == 0 {
+			if len(account.Storage) != 0 {
+				return nil, nil, fmt.Errorf("genesis account %v has storage without code", account.Name)
+			}
+			continue
+		}
+		if _, err := accountManager.SetCode(common.StrToName(account.Name), account.Code); err != nil {
+			return nil, nil, fmt.Errorf("genesis account %v set code err %v", account.Name, err)
+		}
+		for key, value := range account.Storage {
+			statedb.SetState(account.Name, key, value)
+		}
+	}
+
 	am.SetAccountNameConfig(&am.Config{
 		AccountNameLevel:         g.Config.AccountNameCfg.Level,
 		AccountNameMaxLength:     g.Config.AccountNameCfg.AllLength,
@@ -414,7 +484,7 @@ func (g *Genesis) ToBlock(db fdb.Database) (*types.Block, []*types.Receipt, erro
 		}
 	}
 	if fid := g.ForkID; fid >= params.ForkID2 {
-		if err := sys.UpdateElectedCandidates1(epoch, epoch, number.Uint64(), ""); err != nil {
+		if err := sys.UpdateElectedCandidates1(epoch, epoch, number.Uint64(), "", fid); err != nil {
 			return nil, nil, fmt.Errorf("genesis create candidate err %v", err)
 		}
 	} else {
@@ -488,6 +558,7 @@ func (g *Genesis) ToBlock(db fdb.Database) (*types.Block, []*types.Receipt, erro
 		}
 		actions = append(actions, action)
 	}
+	actions = append(actions, balActions...)
 	tx := types.NewTransaction(g.Config.SysTokenID, big.NewInt(0), actions...)
 	receipt := types.NewReceipt(root[:], 0, 0)
 	receipt.TxHash = tx.Hash()
@@ -565,6 +636,22 @@ func DefaultGenesis() *Genesis {
 	}
 }
 
+// DefaultDevGenesis returns the genesis block of the dev chain. It allocates
+// the same accounts and assets as the main net, but uses its own chain id and
+// a short fork window so that the latest fork activates after a few blocks.
+// The assets have no issuance limit, so the epoch rewards can be minted.
+func DefaultDevGenesis() *Genesis {
+	cfg := params.DefaultChainconfig.Copy()
+	cfg.ChainID = big.NewInt(1337)
+	cfg.ForkedCfg.ForkBlockNum = 5
+	genesis := DefaultGenesis()
+	genesis.Config = cfg
+	for _, asset := range genesis.AllocAssets {
+		asset.UpperLimit = big.NewInt(0)
+	}
+	return genesis
+}
+
 // DefaultGenesisAccounts returns the ft net genesis accounts.
 func DefaultGenesisAccounts() []*GenesisAccount {
 	return []*GenesisAccount{
@@ -24,10 +24,12 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	am "github.com/fractalplatform/fractal/accountmanager"
 	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus/dpos"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/state"
 	"github.com/fractalplatform/fractal/utils/fdb"
 	memdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
 )
@@ -142,3 +144,63 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestGenesisAlloc(t *testing.T) {
+	var (
+		code    = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+		key     = common.BytesToHash([]byte{0x01})
+		value   = common.BytesToHash([]byte{0x02})
+		amount  = big.NewInt(1000)
+		genesis = func() *Genesis {
+			g := DefaultGenesis()
+			g.Config = params.DefaultChainconfig.Copy()
+			g.AllocAccounts = append(g.AllocAccounts, &GenesisAccount{
+				Name:     "testaccount",
+				Founder:  params.DefaultChainconfig.SysName,
+				Balances: []*GenesisBalance{{Asset: params.DefaultChainconfig.SysToken, Amount: amount}},
+				Code:     code,
+				Storage:  map[common.Hash]common.Hash{key: value},
+			})
+			return g
+		}
+	)
+
+	db := memdb.NewMemDatabase()
+	block, err := genesis().Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountManager, err := am.NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := accountManager.GetAccountBalanceByID(common.Name("testaccount"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(amount) != 0 {
+		t.Errorf("balance mismatch, got %v, want %v", balance, amount)
+	}
+	if got, _ := accountManager.GetCode(common.Name("testaccount")); !bytes.Equal(got, code) {
+		t.Errorf("code mismatch, got %x, want %x", got, code)
+	}
+	if got := statedb.GetState("testaccount", key); got != value {
+		t.Errorf("storage mismatch, got %v, want %v", got.Hex(), value.Hex())
+	}
+
+	// the same allocation must match the stored genesis
+	if _, _, hash, err := SetupGenesisBlock(db, genesis()); err != nil || hash != block.Hash() {
+		t.Errorf("setup stored genesis, got %v %v, want %v", hash.Hex(), err, block.Hash().Hex())
+	}
+
+	invalid := genesis()
+	invalid.AllocAccounts[len(invalid.AllocAccounts)-1].Balances[0].Asset = "notexist"
+	if _, _, err := invalid.ToBlock(nil); err == nil {
+		t.Errorf("genesis with unknown balance asset should fail")
+	}
+}
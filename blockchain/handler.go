@@ -27,6 +27,11 @@ import (
 	"github.com/fractalplatform/fractal/types"
 )
 
+const (
+	maxNodeDataServe  = 384             // Maximum number of state trie nodes to serve in one reply
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of a node data reply
+)
+
 type BlockchainStation struct {
 	peerCh     chan *router.Event
 	blockchain *BlockChain
@@ -48,7 +53,7 @@ func newBlockchainStation(bc *BlockChain, networkId uint64) *BlockchainStation {
 		networkId:  networkId,
 		quit:       make(chan struct{}),
 		downloader: NewDownloader(bc),
-		subs:       make([]router.Subscription, 6),
+		subs:       make([]router.Subscription, 7),
 	}
 	bs.subs[0] = router.Subscribe(nil, bs.peerCh, router.NewPeerNotify, nil)
 	bs.subs[1] = router.Subscribe(nil, bs.peerCh, router.DelPeerNotify, nil)
@@ -56,6 +61,7 @@ func newBlockchainStation(bc *BlockChain, networkId uint64) *BlockchainStation {
 	bs.subs[3] = router.Subscribe(nil, bs.peerCh, router.P2PGetBlockHashMsg, &getBlcokHashByNumber{})
 	bs.subs[4] = router.Subscribe(nil, bs.peerCh, router.P2PGetBlockHeadersMsg, &getBlockHeadersData{})
 	bs.subs[5] = router.Subscribe(nil, bs.peerCh, router.P2PGetBlockBodiesMsg, []common.Hash{})
+	bs.subs[6] = router.Subscribe(nil, bs.peerCh, router.P2PGetNodeDataMsg, []common.Hash{})
 
 	go bs.loop()
 	return bs
@@ -243,6 +249,26 @@ func (bs *BlockchainStation) handleMsg(e *router.Event) error {
 		}
 		router.ReplyEvent(e, router.P2PBlockBodiesMsg, bodies)
 		return nil
+	case router.P2PGetNodeDataMsg:
+		hashes := e.Data.([]common.Hash)
+		// Gather state trie nodes until the fetch or network limits is reached
+		var (
+			bytes int
+			data  [][]byte
+		)
+		triedb := bs.blockchain.stateCache.TrieDB()
+		for _, hash := range hashes {
+			if len(data) >= maxNodeDataServe || bytes >= softResponseLimit {
+				break
+			}
+			// Retrieve the requested state entry, unknown nodes are skipped
+			if entry, err := triedb.Node(hash); err == nil && len(entry) > 0 {
+				data = append(data, entry)
+				bytes += len(entry)
+			}
+		}
+		router.ReplyEvent(e, router.P2PNodeDataMsg, data)
+		return nil
 	}
 	return nil
 }
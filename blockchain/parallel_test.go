@@ -0,0 +1,139 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/processor"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/types"
+)
+
+func TestParallelProcess(t *testing.T) {
+	const senders = 16
+	var (
+		genesis = DefaultGenesis()
+		amount  = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18))
+		pubKey  = common.BytesToPubKey(crypto.FromECDSAPub(&systemPrikey.PublicKey))
+		sender  = func(i int) string { return fmt.Sprintf("testsender%d", i) }
+		to      = func(i int) string { return fmt.Sprintf("testreceiver%d", i) }
+	)
+	// the accounts already hold the system asset so that the transfers don't
+	// change its holder count.
+	balances := func(amount *big.Int) []*GenesisBalance {
+		return []*GenesisBalance{{Asset: genesis.Config.SysToken, Amount: amount}}
+	}
+	for _, account := range genesis.AllocAccounts {
+		if account.Name == genesis.Config.FeeName {
+			account.Balances = balances(big.NewInt(1))
+		}
+	}
+	for i := 0; i < senders; i++ {
+		genesis.AllocAccounts = append(genesis.AllocAccounts, &GenesisAccount{
+			Name:     sender(i),
+			Founder:  genesis.Config.SysName,
+			PubKey:   pubKey,
+			Balances: balances(amount),
+		}, &GenesisAccount{
+			Name:     to(i),
+			Founder:  genesis.Config.SysName,
+			PubKey:   pubKey,
+			Balances: balances(big.NewInt(1)),
+		})
+	}
+	source := newCanonical(t, genesis)
+	defer source.Stop()
+
+	var (
+		parentTime = genesis.Timestamp * uint64(time.Millisecond)
+		interval   = genesis.Config.DposCfg.BlockInterval * uint64(time.Millisecond)
+		engine     = dpos.New(dposConfig(genesis.Config), source)
+		signer     = types.NewSigner(params.DefaultChainconfig.ChainID)
+		keyPair    = types.MakeKeyPair(systemPrikey, []uint64{0})
+	)
+	transfer := func(b *BlockGenerator, from, to string) {
+		action := types.NewAction(types.Transfer, common.Name(from), common.Name(to), b.TxNonce(common.Name(from)), 0, 210000, big.NewInt(100), nil, nil)
+		tx := types.NewTransaction(0, big.NewInt(2), action)
+		if err := types.SignActionWithMultiKey(action, tx, signer, 0, []*types.KeyPair{keyPair}); err != nil {
+			t.Fatalf("sign action err %v", err)
+		}
+		b.AddTx(tx)
+	}
+	tmpdb, err := deepCopyDB(source.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+		return crypto.Sign(content, systemPrikey)
+	})
+	blocks, _ := generateChain(genesis.Config, source.CurrentBlock(), engine, source, tmpdb, 1, func(i int, b *BlockGenerator) {
+		b.SetCoinbase(common.StrToName(genesis.Config.SysName))
+		b.OffsetTime(int64(engine.Slot(parentTime + interval*uint64(i+1))))
+
+		// independent transfers
+		for j := 0; j < senders/2; j++ {
+			transfer(b, sender(j), to(j))
+		}
+		// transfers conflicting on the sender, the recipient and the fee account
+		transfer(b, sender(0), to(1))
+		transfer(b, sender(senders/2), sender(senders/2+1))
+		transfer(b, sender(senders/2+1), to(senders/2+1))
+		transfer(b, sender(senders/2+2), genesis.Config.FeeName)
+		transfer(b, sender(senders/2+3), to(senders/2+3))
+	})
+	var (
+		block = blocks[0]
+		proc  = source.processor.(*processor.StateProcessor)
+	)
+	process := func(parallel int) ([]*types.Receipt, uint64, common.Hash) {
+		proc.SetParallel(parallel)
+		statedb, err := source.StateAt(source.CurrentBlock().Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts, _, usedGas, err := proc.Process(block, statedb, vm.Config{})
+		if err != nil {
+			t.Fatalf("process with %d workers err %v", parallel, err)
+		}
+		return receipts, usedGas, statedb.IntermediateRoot()
+	}
+
+	want, wantGas, wantRoot := process(0)
+	for _, parallel := range []int{2, 4, senders} {
+		have, haveGas, haveRoot := process(parallel)
+		if haveRoot != wantRoot || haveGas != wantGas {
+			t.Fatalf("%d workers state mismatch, have %x/%d want %x/%d", parallel, haveRoot, haveGas, wantRoot, wantGas)
+		}
+		if len(have) != len(want) {
+			t.Fatalf("%d workers receipts mismatch, have %d want %d", parallel, len(have), len(want))
+		}
+		for i := range want {
+			if have[i].ConsensusReceipt().Hash() != want[i].ConsensusReceipt().Hash() {
+				t.Fatalf("%d workers receipt %d mismatch", parallel, i)
+			}
+		}
+	}
+}
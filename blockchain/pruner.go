@@ -0,0 +1,133 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/utils/fdb"
+)
+
+const (
+	historyPruneInterval = time.Minute // Interval between two runs of the history pruner
+	historyPruneBatch    = 1024        // Number of blocks pruned in a single database batch
+	historyPruneMax      = 32 * 1024   // Number of blocks pruned at most by a run of the background pruner
+)
+
+// PruneHistory removes the bodies, receipts, internal tx logs and state-outs
+// of the canonical blocks before end, their headers are kept. The tx lookup
+// entries are removed too if txLookup is set, otherwise the transactions can
+// still be reported as pruned. The genesis block is never pruned. It returns
+// the new history tail, the oldest block with its history.
+func PruneHistory(db fdb.Database, end uint64, txLookup bool) (uint64, error) {
+	tail := rawdb.ReadHistoryTail(db)
+	if tail == 0 {
+		tail = 1
+	}
+	if end <= tail {
+		return tail, nil
+	}
+
+	var (
+		batch  = db.NewBatch()
+		logged = time.Now()
+	)
+	for number := tail; number < end; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			// the chain may be started from a specified block number
+			continue
+		}
+		if txLookup {
+			if body := rawdb.ReadBody(db, hash, number); body != nil {
+				for _, tx := range body.Transactions {
+					rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+				}
+			}
+		}
+		rawdb.DeleteBody(batch, hash, number)
+		rawdb.DeleteReceipts(batch, hash, number)
+		rawdb.DeleteDetailTxs(batch, hash, number)
+		rawdb.DeleteBlockStateOut(batch, hash)
+
+		if (number-tail+1)%historyPruneBatch == 0 {
+			rawdb.WriteHistoryTail(batch, number+1)
+			if err := batch.Write(); err != nil {
+				return tail, err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning block history", "number", number, "end", end)
+			logged = time.Now()
+		}
+	}
+	rawdb.WriteHistoryTail(batch, end)
+	if err := batch.Write(); err != nil {
+		return tail, err
+	}
+	log.Info("Pruned block history", "from", tail, "to", end, "txlookup", txLookup)
+	return end, nil
+}
+
+// SetHistoryRetention enables history pruning, the history of the blocks
+// more than retention blocks before the irreversible block is removed in
+// the background. A zero retention keeps the whole history.
+func (bc *BlockChain) SetHistoryRetention(retention uint64, txLookup bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.historyRetention = retention
+	bc.pruneTxLookup = txLookup
+}
+
+// HistoryTail returns the number of the oldest block whose body, receipts and
+// logs weren't pruned.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return rawdb.ReadHistoryTail(bc.db)
+}
+
+// HistoryPruned returns whether the history of the block was pruned.
+func (bc *BlockChain) HistoryPruned(number uint64) bool {
+	return number > 0 && number < bc.HistoryTail()
+}
+
+// pruneHistory runs the history pruner towards the retention before the
+// irreversible block.
+func (bc *BlockChain) pruneHistory() {
+	bc.mu.RLock()
+	retention, txLookup := bc.historyRetention, bc.pruneTxLookup
+	bc.mu.RUnlock()
+
+	irreversible := bc.IrreversibleNumber()
+	if retention == 0 || irreversible <= retention {
+		return
+	}
+	// bound the run, so that stopping the chain doesn't wait for a whole catch up
+	end := irreversible - retention
+	if tail := bc.HistoryTail(); end > tail+historyPruneMax {
+		end = tail + historyPruneMax
+	}
+
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+	if _, err := PruneHistory(bc.db, end, txLookup); err != nil {
+		log.Error("Block history pruning failed", "err", err)
+	}
+}
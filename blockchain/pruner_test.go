@@ -0,0 +1,90 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/state"
+)
+
+func TestPruneHistory(t *testing.T) {
+	genesis := DefaultGenesis()
+	genesis.AllocAccounts = append(genesis.AllocAccounts, getDefaultGenesisAccounts()...)
+	chain := newCanonical(t, genesis)
+	defer chain.Stop()
+
+	var (
+		parentTime = genesis.Timestamp * uint64(time.Millisecond)
+		interval   = genesis.Config.DposCfg.BlockInterval * uint64(time.Millisecond)
+		engine     = dpos.New(dposConfig(genesis.Config), chain)
+	)
+	tmpdb, err := deepCopyDB(chain.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+		return crypto.Sign(content, systemPrikey)
+	})
+	blocks, _ := generateChain(genesis.Config, chain.CurrentBlock(), engine, chain, tmpdb, 20, func(i int, b *BlockGenerator) {
+		b.SetCoinbase(common.StrToName(genesis.Config.SysName))
+		b.OffsetTime(int64(engine.Slot(parentTime + interval*uint64(i+1))))
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("insert chain err %v", err)
+	}
+
+	tail, err := PruneHistory(chain.db, 10, true)
+	if err != nil {
+		t.Fatalf("prune history err %v", err)
+	}
+	if tail != 10 || chain.HistoryTail() != 10 {
+		t.Fatalf("history tail mismatch, have %d/%d want 10", tail, chain.HistoryTail())
+	}
+	for _, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if rawdb.ReadHeader(chain.db, hash, number) == nil {
+			t.Fatalf("block #%d header pruned", number)
+		}
+		pruned := number < 10
+		if chain.HistoryPruned(number) != pruned {
+			t.Fatalf("block #%d pruned %v, want %v", number, !pruned, pruned)
+		}
+		if rawdb.HasBody(chain.db, hash, number) == pruned {
+			t.Fatalf("block #%d body kept %v, want %v", number, pruned, !pruned)
+		}
+		if (rawdb.ReadReceipts(chain.db, hash, number) == nil) != pruned {
+			t.Fatalf("block #%d receipts kept %v, want %v", number, pruned, !pruned)
+		}
+	}
+	if !rawdb.HasBody(chain.db, chain.Genesis().Hash(), 0) {
+		t.Fatal("genesis body pruned")
+	}
+
+	// pruning is resumed from the tail
+	if tail, err := PruneHistory(chain.db, 15, true); err != nil || tail != 15 {
+		t.Fatalf("prune history again, tail %d err %v", tail, err)
+	}
+	if rawdb.HasBody(chain.db, blocks[13].Hash(), 14) {
+		t.Fatal("block #14 body kept")
+	}
+}
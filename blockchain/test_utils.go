@@ -333,9 +333,7 @@ func generateChain(config *params.ChainConfig, parent *types.Block, engine conse
 
 		if b.engine != nil {
 			// Finalize and seal the block
-			if err := b.engine.Prepare(b, b.header, b.txs, nil, b.statedb); err != nil {
-				panic(fmt.Sprintf("engine prepare error: %v", err))
-			}
+			b.prepare()
 
 			block, err := b.engine.Finalize(b, b.header, b.txs, b.receipts, b.statedb)
 			if err != nil {
@@ -0,0 +1,168 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var (
+	passwordFile string
+	accountName  string
+)
+
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage the keys of the keystore",
+	Long:  `Manage the encrypted keys of the keystore of the datadir, the node doesn't need to run.`,
+	Args:  cobra.NoArgs,
+}
+
+var accountNewCmd = &cobra.Command{
+	Use:   "new -d <datadir> [--name <account name>]",
+	Short: "Create a new key",
+	Long:  `Create a new key, encrypted with a passphrase.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ks := openKeyStore()
+		account, err := ks.NewAccount(common.Name(accountName), getPassphrase(true))
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		printJSON(account)
+	},
+}
+
+var accountListCmd = &cobra.Command{
+	Use:   "list -d <datadir>",
+	Short: "List the keys of the keystore",
+	Long:  `List the keys of the keystore.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printJSON(openKeyStore().Accounts())
+	},
+}
+
+var accountImportCmd = &cobra.Command{
+	Use:   "import -d <datadir> [--name <account name>] <private key file>",
+	Short: "Import a hex encoded private key",
+	Long:  `Import a hex encoded private key from a file, encrypted with a passphrase.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		priv, err := crypto.LoadECDSA(args[0])
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		ks := openKeyStore()
+		account, err := ks.ImportECDSA(priv, common.Name(accountName), getPassphrase(true))
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		printJSON(account)
+	},
+}
+
+var accountExportCmd = &cobra.Command{
+	Use:   "export -d <datadir> <public key>",
+	Short: "Export the hex encoded private key",
+	Long:  `Export the hex encoded private key of a public key.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !common.IsHexPubKey(args[0]) {
+			jww.ERROR.Println("invalid public key", args[0])
+			os.Exit(1)
+		}
+		ks := openKeyStore()
+		priv, err := ks.Export(common.HexToPubKey(args[0]), getPassphrase(false))
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		jww.FEEDBACK.Println(common.Bytes2Hex(crypto.FromECDSA(priv)))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountNewCmd, accountListCmd, accountImportCmd, accountExportCmd)
+	accountCmd.PersistentFlags().StringVarP(&ftCfgInstance.NodeCfg.DataDir, "datadir", "d", ftCfgInstance.NodeCfg.DataDir, "Data directory for the databases and keystore")
+	accountCmd.PersistentFlags().StringVarP(&passwordFile, "password", "p", "", "Passphrase file, the passphrase is read from the terminal if not set")
+	accountNewCmd.Flags().StringVarP(&accountName, "name", "n", "", "Account name the key is bound to")
+	accountImportCmd.Flags().StringVarP(&accountName, "name", "n", "", "Account name the key is bound to")
+}
+
+func openKeyStore() *keystore.KeyStore {
+	stack, err := makeNode()
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	ks, err := keystore.NewKeyStore(stack.GetNodeConfig().ResolvePath("keystore"), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	return ks
+}
+
+// getPassphrase reads the passphrase from the password file, or from the
+// terminal without echoing it, asking for a confirmation if confirm is set.
+func getPassphrase(confirm bool) string {
+	if passwordFile != "" {
+		text, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			jww.ERROR.Println("Failed to read password file", err)
+			os.Exit(1)
+		}
+		return strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+	}
+	reader := bufio.NewReader(os.Stdin)
+	readLine := func(prompt string) string {
+		fmt.Print(prompt)
+		if restore, err := echoOff(int(os.Stdin.Fd())); err == nil {
+			defer func() {
+				restore()
+				fmt.Println()
+			}()
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			jww.ERROR.Println("Failed to read passphrase", err)
+			os.Exit(1)
+		}
+		return strings.TrimRight(line, "\r\n")
+	}
+	passphrase := readLine("Passphrase: ")
+	if confirm && readLine("Repeat passphrase: ") != passphrase {
+		jww.ERROR.Println("Passphrases do not match")
+		os.Exit(1)
+	}
+	return passphrase
+}
@@ -19,7 +19,11 @@ package main
 import (
 	"fmt"
 
+	"github.com/fractalplatform/fractal/blockchain"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/ftservice"
 	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/rawdb"
 	"github.com/fractalplatform/fractal/types"
 	"github.com/spf13/cobra"
 )
@@ -43,12 +47,44 @@ var (
 			}
 		},
 	}
+
+	accountIndexCommand = &cobra.Command{
+		Use:   "accountindex -d <datadir>",
+		Short: "Build the account index of an existing datadir",
+		Long:  "Build the account index of an existing datadir, the node must be stopped",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ftCfgInstance.LogCfg.Setup()
+			if err := buildAccountIndex(); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	pruneCommand = &cobra.Command{
+		Use:   "prune -d <datadir> --retention <blocks>",
+		Short: "Prune the block history of an existing datadir",
+		Long:  "Remove the bodies, receipts and logs of the blocks more than retention blocks before the irreversible block, the node must be stopped",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ftCfgInstance.LogCfg.Setup()
+			if err := pruneHistory(); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
 )
 
 func init() {
 	RootCmd.AddCommand(chainCommand)
 	chainCommand.AddCommand(statePureCommand)
 	statePureCommand.Flags().StringVarP(&ipcEndpoint, "ipcpath", "i", defaultIPCEndpoint(params.ClientIdentifier), "IPC Endpoint path")
+	chainCommand.AddCommand(accountIndexCommand)
+	accountIndexCommand.Flags().StringVarP(&ftCfgInstance.NodeCfg.DataDir, "datadir", "d", ftCfgInstance.NodeCfg.DataDir, "Data directory for the databases ")
+	chainCommand.AddCommand(pruneCommand)
+	pruneCommand.Flags().StringVarP(&ftCfgInstance.NodeCfg.DataDir, "datadir", "d", ftCfgInstance.NodeCfg.DataDir, "Data directory for the databases ")
+	pruneCommand.Flags().Uint64Var(&ftCfgInstance.FtServiceCfg.HistoryRetention, "retention", ftCfgInstance.FtServiceCfg.HistoryRetention, "Number of blocks before the irreversible block whose history is kept")
+	pruneCommand.Flags().BoolVar(&ftCfgInstance.FtServiceCfg.PruneTxLookup, "txlookup", ftCfgInstance.FtServiceCfg.PruneTxLookup, "Remove the transaction lookup entries too")
 }
 
 func prueState(arg string) error {
@@ -67,3 +103,46 @@ func prueState(arg string) error {
 	printJSON(result)
 	return nil
 }
+
+func buildAccountIndex() error {
+	stack, err := makeNode()
+	if err != nil {
+		return err
+	}
+	db, err := ftservice.CreateDB(stack.GetNodeConfig(), ftCfgInstance.FtServiceCfg, "chaindata")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return blockchain.BuildAccountIndex(db)
+}
+
+func pruneHistory() error {
+	retention := ftCfgInstance.FtServiceCfg.HistoryRetention
+	if retention == 0 {
+		return fmt.Errorf("no retention specified")
+	}
+	stack, err := makeNode()
+	if err != nil {
+		return err
+	}
+	db, err := ftservice.CreateDB(stack.GetNodeConfig(), ftCfgInstance.FtServiceCfg, "chaindata")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if rawdb.ReadHeadBlockHash(db) == (common.Hash{}) {
+		return fmt.Errorf("empty database")
+	}
+
+	irreversible := rawdb.ReadIrreversibleNumber(db)
+	if irreversible <= retention {
+		return fmt.Errorf("nothing to prune, irreversible block #%d", irreversible)
+	}
+	tail, err := blockchain.PruneHistory(db, irreversible-retention, ftCfgInstance.FtServiceCfg.PruneTxLookup)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("block history pruned, oldest block with history #%d\n", tail)
+	return nil
+}
@@ -0,0 +1,309 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/blockchain"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/ftservice"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
+	"github.com/fractalplatform/fractal/utils/fdb/leveldb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dumpLimit int
+	dumpStart string
+)
+
+var (
+	dbCommand = &cobra.Command{
+		Use:   "db",
+		Short: "Inspect and repair the chain database of a stopped node",
+		Long:  "Inspect and repair the chain database of a stopped node",
+		Args:  cobra.NoArgs,
+	}
+
+	dbInspectCommand = &cobra.Command{
+		Use:   "inspect -d <datadir>",
+		Short: "Show the number and size of the records of every type",
+		Long:  "Show the number and size of the records of every type",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDB(inspectDB); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	dbGetCommand = &cobra.Command{
+		Use:   "get <header|body|receipts|detailtxs|td|stateout|snapshot|txlookup|key> <number|hash|key> -d <datadir>",
+		Short: "Decode a record of the database",
+		Long:  "Decode the record of a block given by number or hash, the lookup entry of a transaction hash or the record of a raw hex key",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDB(func(db fdb.Database) error { return getRecord(db, args[0], args[1]) }); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	dbDumpCommand = &cobra.Command{
+		Use:   "dump <record type> -d <datadir>",
+		Short: "Decode the records of a type",
+		Long:  "Decode the records of a type in key order, the types are: " + strings.Join(rawdb.RecordTypes(), ", "),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDB(func(db fdb.Database) error { return dumpRecords(db, args[0]) }); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	dbVerifyCommand = &cobra.Command{
+		Use:   "verify -d <datadir>",
+		Short: "Check the canonical chain and its state roots",
+		Long:  "Check the links, numbers, bodies and total difficulties of the canonical chain and that the head and snapshot state roots exist",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDB(blockchain.VerifyChain); err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Println("chain verified")
+		},
+	}
+
+	dbSetHeadCommand = &cobra.Command{
+		Use:   "sethead <number> -d <datadir>",
+		Short: "Rewind the head block",
+		Long:  "Rewind the head to the canonical block, or to its newest ancestor whose state is available",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			number, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			err = runDB(func(db fdb.Database) error {
+				block, err := blockchain.SetHead(db, number)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("head block #%d [%x]\n", block.NumberU64(), block.Hash())
+				return nil
+			})
+			if err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(dbCommand)
+	for _, cmd := range []*cobra.Command{dbInspectCommand, dbGetCommand, dbDumpCommand, dbVerifyCommand, dbSetHeadCommand} {
+		dbCommand.AddCommand(cmd)
+		cmd.Flags().StringVarP(&ftCfgInstance.NodeCfg.DataDir, "datadir", "d", ftCfgInstance.NodeCfg.DataDir, "Data directory for the databases ")
+	}
+	dbDumpCommand.Flags().IntVar(&dumpLimit, "limit", 16, "Maximum number of records")
+	dbDumpCommand.Flags().StringVar(&dumpStart, "start", "", "Hex key to start from")
+}
+
+// runDB opens the chain database of the node and runs fn on it.
+func runDB(fn func(db fdb.Database) error) error {
+	ftCfgInstance.LogCfg.Setup()
+	stack, err := makeNode()
+	if err != nil {
+		return err
+	}
+	db, err := ftservice.CreateDB(stack.GetNodeConfig(), ftCfgInstance.FtServiceCfg, "chaindata")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+func iterableDB(db fdb.Database) (*leveldb.LDBDatabase, error) {
+	if ldb, ok := db.(*leveldb.LDBDatabase); ok {
+		return ldb, nil
+	}
+	return nil, fmt.Errorf("database can't be iterated, no datadir")
+}
+
+func inspectDB(db fdb.Database) error {
+	ldb, err := iterableDB(db)
+	if err != nil {
+		return err
+	}
+	stats, err := rawdb.InspectDatabase(ldb.NewIterator())
+	if err != nil {
+		return err
+	}
+	var (
+		count uint64
+		size  common.StorageSize
+	)
+	fmt.Printf("%-20s %12s %12s\n", "type", "count", "size")
+	for _, stat := range stats {
+		fmt.Printf("%-20s %12d %12s\n", stat.Type, stat.Count, stat.Size)
+		count += stat.Count
+		size += stat.Size
+	}
+	fmt.Printf("%-20s %12d %12s\n", "total", count, size)
+	return nil
+}
+
+// blockID resolves a block number or hash to the hash and number of the block.
+func blockID(db fdb.Database, id string) (common.Hash, uint64, error) {
+	if strings.HasPrefix(id, "0x") {
+		hash := common.HexToHash(id)
+		number := rawdb.ReadHeaderNumber(db, hash)
+		if number == nil {
+			return common.Hash{}, 0, fmt.Errorf("block %x not found", hash)
+		}
+		return hash, *number, nil
+	}
+	number, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return common.Hash{}, 0, fmt.Errorf("canonical block #%d not found", number)
+	}
+	return hash, number, nil
+}
+
+func getRecord(db fdb.Database, kind, id string) error {
+	var record interface{}
+	switch kind {
+	case "key":
+		key, err := hexutil.Decode(id)
+		if err != nil {
+			return err
+		}
+		value, err := db.Get(key)
+		if err != nil {
+			return fmt.Errorf("key %s not found", id)
+		}
+		if record, err = rawdb.DecodeRecord(key, value); err != nil {
+			return err
+		}
+	case "txlookup":
+		blockHash, number, index := rawdb.ReadTxLookupEntry(db, common.HexToHash(id))
+		if blockHash == (common.Hash{}) {
+			return fmt.Errorf("transaction %s not found", id)
+		}
+		record = &rawdb.TxLookupEntry{BlockHash: blockHash, BlockIndex: number, Index: index}
+	default:
+		hash, number, err := blockID(db, id)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case "header":
+			if header := rawdb.ReadHeader(db, hash, number); header != nil {
+				record = header
+			}
+		case "body":
+			if body := rawdb.ReadBody(db, hash, number); body != nil {
+				record = body
+			}
+		case "receipts":
+			if receipts := rawdb.ReadReceipts(db, hash, number); receipts != nil {
+				record = receipts
+			}
+		case "detailtxs":
+			if detailTxs := rawdb.ReadDetailTxs(db, hash, number); detailTxs != nil {
+				record = detailTxs
+			}
+		case "td":
+			if td := rawdb.ReadTd(db, hash, number); td != nil {
+				record = td
+			}
+		case "stateout":
+			if stateOut := rawdb.ReadBlockStateOut(db, hash); stateOut != nil {
+				record = stateOut
+			}
+		case "snapshot":
+			header := rawdb.ReadHeader(db, hash, number)
+			if header == nil {
+				return fmt.Errorf("header #%d [%x] not found", number, hash)
+			}
+			key := types.SnapshotBlock{Number: number, BlockHash: header.ParentHash}
+			if info := rawdb.ReadSnapshot(db, key); info != nil {
+				record = &rawdb.SnapshotRecord{Block: key, Info: *info}
+			}
+		default:
+			return fmt.Errorf("unknown record type %s", kind)
+		}
+		if record == nil && number > 0 && number < rawdb.ReadHistoryTail(db) {
+			return fmt.Errorf("%s of block #%d: %v", kind, number, blockchain.ErrHistoryPruned)
+		}
+	}
+	if record == nil {
+		return fmt.Errorf("%s %s not found", kind, id)
+	}
+	printJSON(record)
+	return nil
+}
+
+func dumpRecords(db fdb.Database, kind string) error {
+	prefix, err := rawdb.RecordPrefix(kind)
+	if err != nil {
+		return err
+	}
+	ldb, err := iterableDB(db)
+	if err != nil {
+		return err
+	}
+	var start []byte
+	if dumpStart != "" {
+		if start, err = hexutil.Decode(dumpStart); err != nil {
+			return err
+		}
+	}
+
+	it := ldb.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	valid := it.First()
+	if dumpStart != "" {
+		valid = it.Seek(start)
+	}
+	for count := 0; valid && count < dumpLimit; valid = it.Next() {
+		if rawdb.RecordType(it.Key()) != kind {
+			continue
+		}
+		record, err := rawdb.DecodeRecord(it.Key(), it.Value())
+		if err != nil {
+			return fmt.Errorf("key %x: %v", it.Key(), err)
+		}
+		fmt.Printf("%x:\n", it.Key())
+		printJSON(record)
+		count++
+	}
+	return it.Error()
+}
@@ -257,6 +257,30 @@ func addFlags(flags *flag.FlagSet) {
 	)
 	viper.BindPFlag("ftservice.contractlog", flags.Lookup("contractlog"))
 
+	flags.BoolVar(
+		&ftCfgInstance.FtServiceCfg.AccountIndex,
+		"accountindex",
+		ftCfgInstance.FtServiceCfg.AccountIndex,
+		"flag for db to index the actions of every account.",
+	)
+	viper.BindPFlag("ftservice.accountindex", flags.Lookup("accountindex"))
+
+	flags.BoolVar(
+		&ftCfgInstance.FtServiceCfg.FastSync,
+		"fastsync",
+		ftCfgInstance.FtServiceCfg.FastSync,
+		"flag for an empty node to download the state of a recent block instead of processing the whole chain.",
+	)
+	viper.BindPFlag("ftservice.fastsync", flags.Lookup("fastsync"))
+
+	flags.StringVar(
+		&ftCfgInstance.FtServiceCfg.FastSyncCheckpoint,
+		"fastsync_checkpoint",
+		ftCfgInstance.FtServiceCfg.FastSyncCheckpoint,
+		"hash of a trusted block the fast sync chain must hold, without it the pivot must be confirmed by several stations.",
+	)
+	viper.BindPFlag("ftservice.fastsynccheckpoint", flags.Lookup("fastsync_checkpoint"))
+
 	// state pruning
 	flags.BoolVar(
 		&ftCfgInstance.FtServiceCfg.StatePruning,
@@ -266,6 +290,32 @@ func addFlags(flags *flag.FlagSet) {
 	)
 	viper.BindPFlag("ftservice.statepruning", flags.Lookup("statepruning_enable"))
 
+	// history pruning
+	flags.Uint64Var(
+		&ftCfgInstance.FtServiceCfg.HistoryRetention,
+		"history_retention",
+		ftCfgInstance.FtServiceCfg.HistoryRetention,
+		"number of blocks before the irreversible block whose bodies, receipts and logs are kept, 0 keeps everything.",
+	)
+	viper.BindPFlag("ftservice.historyretention", flags.Lookup("history_retention"))
+
+	flags.BoolVar(
+		&ftCfgInstance.FtServiceCfg.PruneTxLookup,
+		"history_prunetxlookup",
+		ftCfgInstance.FtServiceCfg.PruneTxLookup,
+		"flag for history pruning to remove the transaction lookup entries too.",
+	)
+	viper.BindPFlag("ftservice.prunetxlookup", flags.Lookup("history_prunetxlookup"))
+
+	// parallel execution
+	flags.IntVar(
+		&ftCfgInstance.FtServiceCfg.ParallelTxs,
+		"parallel_txs",
+		ftCfgInstance.FtServiceCfg.ParallelTxs,
+		"number of transactions of a block executed speculatively at once, below 2 they are executed in sequence.",
+	)
+	viper.BindPFlag("ftservice.paralleltxs", flags.Lookup("parallel_txs"))
+
 	// start number
 	flags.Uint64Var(
 		&ftCfgInstance.FtServiceCfg.StartNumber,
@@ -275,6 +325,15 @@ func addFlags(flags *flag.FlagSet) {
 	)
 	viper.BindPFlag("ftservice.startnumber", flags.Lookup("start_number"))
 
+	// dev mode
+	flags.BoolVar(
+		&ftCfgInstance.FtServiceCfg.Dev,
+		"dev",
+		ftCfgInstance.FtServiceCfg.Dev,
+		"Run a single node dev chain that seals blocks on demand with the miner keys unlocked",
+	)
+	viper.BindPFlag("ftservice.dev", flags.Lookup("dev"))
+
 	// add bad block hashs
 	flags.StringSliceVar(
 		&ftCfgInstance.FtServiceCfg.BadHashes,
@@ -431,6 +490,14 @@ func addFlags(flags *flag.FlagSet) {
 	)
 	viper.BindPFlag("ftservice.miner.name", flags.Lookup("miner_extra"))
 
+	flags.StringVar(
+		&ftCfgInstance.FtServiceCfg.Miner.Signer,
+		"miner_signer",
+		ftCfgInstance.FtServiceCfg.Miner.Signer,
+		"Unix socket path of the remote signer holding the miner keys",
+	)
+	viper.BindPFlag("ftservice.miner.signer", flags.Lookup("miner_signer"))
+
 	// gas price oracle
 	flags.IntVar(
 		&ftCfgInstance.FtServiceCfg.GasPrice.Blocks,
@@ -122,6 +122,25 @@ var setCoinbaseCmd = &cobra.Command{
 	},
 }
 
+var setCoinbaseKeyStoreCmd = &cobra.Command{
+	Use:   "setcoinbasekeystore <name> [public keys]",
+	Short: "Set the coinbase of the miner with unlocked keystore keys.",
+	Long:  `Set the coinbase of the miner with unlocked keystore keys, all the keys bound to the name are used if no public key is given.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pubKeys := make([]common.PubKey, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			if !common.IsHexPubKey(arg) {
+				jww.ERROR.Println("invalid public key", arg)
+				return
+			}
+			pubKeys = append(pubKeys, common.HexToPubKey(arg))
+		}
+		clientCall(ipcEndpoint, nil, "miner_setCoinbaseFromKeyStore", args[0], pubKeys)
+		printJSON(true)
+	},
+}
+
 var setExtraCmd = &cobra.Command{
 	Use:   "setextra <extra>",
 	Short: "Set the extra of the miner.",
@@ -146,6 +165,6 @@ var setDelayCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(minerCmd)
-	minerCmd.AddCommand(startCmd, forceCmd, stopCmd, miningCmd, setCoinbaseCmd, setExtraCmd, setDelayCmd)
+	minerCmd.AddCommand(startCmd, forceCmd, stopCmd, miningCmd, setCoinbaseCmd, setCoinbaseKeyStoreCmd, setExtraCmd, setDelayCmd)
 	minerCmd.PersistentFlags().StringVarP(&ipcEndpoint, "ipcpath", "i", defaultIPCEndpoint(params.ClientIdentifier), "IPC Endpoint path")
 }
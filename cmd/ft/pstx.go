@@ -0,0 +1,173 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/rpcapi"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var (
+	pstxActionIndex int
+	pstxParentIndex uint64
+	pstxSignKeys    []string
+)
+
+var pstxCmd = &cobra.Command{
+	Use:   "pstx",
+	Short: "Create, co-sign and submit partially signed transactions",
+	Long: `Create, co-sign and submit partially signed transactions (PSTX). A PSTX
+argument is either the hex encoded PSTX or a file containing it.`,
+	Args: cobra.NoArgs,
+}
+
+var pstxNewCmd = &cobra.Command{
+	Use:   "new <json args>",
+	Short: "Create an unsigned PSTX",
+	Long: `Create an unsigned PSTX from the json arguments of personal_sendTransaction,
+e.g. '{"actionType":0,"from":"treasury","to":"receiver","assetID":0,"amount":1,"gas":30000,"gasAssetID":0,"gasPrice":1}'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var txArgs rpcapi.SendTxArgs
+		if err := json.Unmarshal([]byte(args[0]), &txArgs); err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		var result hexutil.Bytes
+		clientCall(ipcEndpoint, &result, "ft_newPSTX", txArgs)
+		printJSON(result)
+	},
+}
+
+var pstxSignCmd = &cobra.Command{
+	Use:   "sign <pstx> [--key <public key>[:<index path>]]...",
+	Short: "Append signatures of unlocked keystore keys to a PSTX",
+	Long: `Append signatures of unlocked keystore keys to an action of a PSTX. The index
+path of a key is the list of author indexes separated by '/', e.g. 1/0. If
+no key is given, all the unlocked keys that are authors of the sender sign.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keys := make([]rpcapi.SignKey, 0, len(pstxSignKeys))
+		for _, arg := range pstxSignKeys {
+			keys = append(keys, parseSignKey(arg))
+		}
+		var result hexutil.Bytes
+		clientCall(ipcEndpoint, &result, "personal_signPSTX", readPSTX(args[0]), pstxActionIndex, pstxParentIndex, keys)
+		printJSON(result)
+	},
+}
+
+var pstxStatusCmd = &cobra.Command{
+	Use:   "status <pstx>",
+	Short: "Show the signed weight of a PSTX relative to the thresholds",
+	Long:  `Show the signed weight of every action of a PSTX relative to the thresholds of the accounts.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result rpcapi.PSTXStatus
+		clientCall(ipcEndpoint, &result, "ft_getPSTXStatus", readPSTX(args[0]))
+		printJSON(result)
+	},
+}
+
+var pstxMergeCmd = &cobra.Command{
+	Use:   "merge <pstx> <pstx>...",
+	Short: "Merge the signatures of PSTXs signed in parallel",
+	Long:  `Merge the signatures of PSTXs of the same transaction signed in parallel by different authors.`,
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		pstxs := make([]hexutil.Bytes, 0, len(args))
+		for _, arg := range args {
+			pstxs = append(pstxs, readPSTX(arg))
+		}
+		var result hexutil.Bytes
+		clientCall(ipcEndpoint, &result, "ft_mergePSTX", pstxs)
+		printJSON(result)
+	},
+}
+
+var pstxSendCmd = &cobra.Command{
+	Use:   "send <pstx>",
+	Short: "Submit a PSTX whose signatures reach the thresholds",
+	Long:  `Submit a PSTX to the transaction pool once the signatures of every action reach the thresholds.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result common.Hash
+		clientCall(ipcEndpoint, &result, "ft_sendPSTX", readPSTX(args[0]))
+		printJSON(result)
+	},
+}
+
+// readPSTX decodes the hex encoded PSTX of the argument, or of the file it
+// names.
+func readPSTX(arg string) hexutil.Bytes {
+	if !strings.HasPrefix(arg, "0x") {
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		arg = strings.Trim(strings.TrimSpace(string(data)), `"`)
+	}
+	pstx, err := hexutil.Decode(arg)
+	if err != nil {
+		jww.ERROR.Println("invalid pstx:", err)
+		os.Exit(1)
+	}
+	return pstx
+}
+
+// parseSignKey parses a <public key>[:<index path>] argument, the index
+// path defaults to the first author.
+func parseSignKey(arg string) rpcapi.SignKey {
+	parts := strings.SplitN(arg, ":", 2)
+	if !common.IsHexPubKey(parts[0]) {
+		jww.ERROR.Println("invalid public key", parts[0])
+		os.Exit(1)
+	}
+	key := rpcapi.SignKey{PubKey: common.HexToPubKey(parts[0]), Index: []uint64{0}}
+	if len(parts) == 2 {
+		key.Index = key.Index[:0]
+		for _, idx := range strings.Split(parts[1], "/") {
+			i, err := strconv.ParseUint(idx, 10, 64)
+			if err != nil {
+				jww.ERROR.Println("invalid index path", parts[1])
+				os.Exit(1)
+			}
+			key.Index = append(key.Index, i)
+		}
+	}
+	return key
+}
+
+func init() {
+	RootCmd.AddCommand(pstxCmd)
+	pstxCmd.AddCommand(pstxNewCmd, pstxSignCmd, pstxStatusCmd, pstxMergeCmd, pstxSendCmd)
+	pstxCmd.PersistentFlags().StringVarP(&ipcEndpoint, "ipcpath", "i", defaultIPCEndpoint(params.ClientIdentifier), "IPC Endpoint path")
+	pstxSignCmd.Flags().IntVarP(&pstxActionIndex, "action", "a", 0, "Index of the action to sign")
+	pstxSignCmd.Flags().Uint64VarP(&pstxParentIndex, "parent", "p", 0, "Parent index of the signatures")
+	pstxSignCmd.Flags().StringSliceVarP(&pstxSignKeys, "key", "k", nil, "Public key and index path of a signing key")
+}
@@ -110,9 +110,31 @@ func makeNode() (*node.Node, error) {
 		ftCfgInstance.FtServiceCfg.Genesis = genesis
 	}
 
+	if ftCfgInstance.FtServiceCfg.Dev {
+		setupDevNode(ftCfgInstance.NodeCfg)
+	}
 	return node.New(ftCfgInstance.NodeCfg)
 }
 
+// setupDevNode keeps the dev node away from the network and exposes the
+// miner and personal apis used to drive it.
+func setupDevNode(cfg *node.Config) {
+	cfg.P2PConfig.MaxPeers = 0
+	cfg.P2PConfig.NoDiscovery = true
+	for _, module := range []string{"miner", "personal"} {
+		exist := false
+		for _, m := range cfg.HTTPModules {
+			if m == module {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			cfg.HTTPModules = append(cfg.HTTPModules, module)
+		}
+	}
+}
+
 // SetupMetrics set metrics
 func SetupMetrics() {
 	//need to set metrice.Enabled = true in metrics source code
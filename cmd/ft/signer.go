@@ -0,0 +1,111 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/signer"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var (
+	signerIPCPath       string
+	signerUnlock        []string
+	signerBlockInterval uint64
+)
+
+var signerCmd = &cobra.Command{
+	Use:   "signer -d <datadir> [--unlock <public keys>] [--ipcpath <path>]",
+	Short: "Run a block signer holding the producer keys",
+	Long: `Run a block signer holding the keys of the keystore of the datadir. The node
+signs its blocks through it with --miner_signer, the signer refuses to sign two
+different blocks at the same height or slot and keeps the last signed block of
+every key in the datadir. It only signs blocks at the current slot of its clock,
+and is only served over the unix socket, readable by its user alone.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ftCfgInstance.LogCfg.Setup()
+		stack, err := makeNode()
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		ctx := stack.GetNodeConfig()
+		ks, err := keystore.NewKeyStore(ctx.ResolvePath("keystore"), keystore.StandardScryptN, keystore.StandardScryptP)
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		passphrase := getPassphrase(false)
+		var pubKeys []common.PubKey
+		if len(signerUnlock) == 0 {
+			for _, account := range ks.Accounts() {
+				pubKeys = append(pubKeys, account.PublicKey)
+			}
+		}
+		for _, hex := range signerUnlock {
+			if !common.IsHexPubKey(hex) {
+				jww.ERROR.Println("invalid public key", hex)
+				os.Exit(1)
+			}
+			pubKeys = append(pubKeys, common.HexToPubKey(hex))
+		}
+		for _, pubKey := range pubKeys {
+			if err := ks.Unlock(pubKey, passphrase); err != nil {
+				jww.ERROR.Println(pubKey.String(), err)
+				os.Exit(1)
+			}
+		}
+
+		s, err := signer.New(ks, ctx.ResolvePath("signed_blocks.json"), signerBlockInterval*uint64(time.Millisecond))
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+
+		endpoint := ctx.ResolvePath(signerIPCPath)
+		listener, _, err := rpc.StartIPCEndpoint(endpoint, s.APIs())
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		defer listener.Close()
+		jww.FEEDBACK.Println("signer listening on", endpoint)
+
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(signerCmd)
+	signerCmd.Flags().StringVarP(&ftCfgInstance.NodeCfg.DataDir, "datadir", "d", ftCfgInstance.NodeCfg.DataDir, "Data directory for the keystore and the signed blocks")
+	signerCmd.Flags().StringVarP(&passwordFile, "password", "p", "", "Passphrase file, the passphrase is read from the terminal if not set")
+	signerCmd.Flags().StringSliceVar(&signerUnlock, "unlock", nil, "Public keys to unlock, all the keys of the keystore if not set")
+	signerCmd.Flags().StringVar(&signerIPCPath, "ipcpath", "signer.ipc", "Unix socket of the signer, relative to the datadir")
+	signerCmd.Flags().Uint64Var(&signerBlockInterval, "blockinterval", params.DefaultChainconfig.DposCfg.BlockInterval, "Block interval of the chain in milliseconds, the signed blocks must be at the current slot")
+}
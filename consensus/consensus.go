@@ -80,6 +80,9 @@ type IValidator interface {
 
 	// VerifySeal checks whether the crypto seal on a header is valid according to the consensus rules of the given engine.
 	VerifySeal(chain IChainReader, header *types.Header) error
+
+	// VerifyProducer checks whether the crypto seal on a header was made by a producer known to the given state, for headers whose parent state is not available.
+	VerifyProducer(chain IChainReader, header *types.Header, state *state.StateDB) error
 }
 
 // IEngine is an algorithm agnostic consensus engine.
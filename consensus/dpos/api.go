@@ -137,7 +137,15 @@ func (api *API) Candidate(epoch uint64, name string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	return sys.GetCandidate(epoch, name)
+	candidate, err := sys.GetCandidate(epoch, name)
+	if err != nil || candidate == nil || candidate.Type != Jail {
+		return candidate, err
+	}
+	release, err := sys.GetJail(name)
+	if err != nil {
+		return nil, err
+	}
+	return &JailedCandidateInfo{CandidateInfo: candidate, ReleaseEpoch: release}, nil
 }
 
 // VotersByCandidate get voters info of candidate
@@ -186,6 +194,18 @@ func (api *API) VotersByVoter(epoch uint64, voter string, detail bool) (interfac
 	return candidates, nil
 }
 
+// VoterReward get the reward the voter can claim with its votes of the epoch
+func (api *API) VoterReward(epoch uint64, voter string) (*big.Int, error) {
+	if epoch == 0 {
+		epoch, _ = api.epoch(api.chain.CurrentHeader().Number.Uint64())
+	}
+	sys, err := api.system()
+	if err != nil {
+		return nil, err
+	}
+	return sys.VoterReward(epoch, voter)
+}
+
 // AvailableStake get available stake that can vote candidate
 func (api *API) AvailableStake(epoch uint64, voter string) (*big.Int, error) {
 	if epoch == 0 {
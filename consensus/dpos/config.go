@@ -66,6 +66,9 @@ type Config struct {
 	BackupScheduleSize            uint64   `json:"backupScheduleSize"`
 	EpochInterval                 uint64   `json:"epochInterval"`
 	FreezeEpochSize               uint64   `json:"freezeEpochSize"`
+	JailMissPercent               uint64   `json:"jailMissPercent"`
+	JailEpochSize                 uint64   `json:"jailEpochSize"`
+	SlashPercent                  uint64   `json:"slashPercent"`
 	AccountName                   string   `json:"accountName"`
 	SystemName                    string   `json:"systemName"`
 	SystemURL                     string   `json:"systemURL"`
@@ -41,6 +41,7 @@ type IDB interface {
 	GetAvailableQuantity(uint64, string) (*big.Int, error)
 
 	SetVoter(*VoterInfo) error
+	DelVoter(*VoterInfo) error
 	GetVoter(uint64, string, string) (*VoterInfo, error)
 	GetVotersByVoter(uint64, string) ([]*VoterInfo, error)
 	GetVotersByCandidate(uint64, string) ([]*VoterInfo, error)
@@ -53,6 +54,19 @@ type IDB interface {
 	SetTakeOver(uint64) error
 	GetTakeOver() (uint64, error)
 
+	SetJail(string, uint64) error
+	GetJail(string) (uint64, error)
+	DelJail(string) error
+
+	SetCommission(string, uint64) error
+	GetCommission(string) (uint64, bool, error)
+	SetRewardPerVote(uint64, string, *big.Int) error
+	GetRewardPerVote(uint64, string) (*big.Int, error)
+	SetRewardDebt(*VoterInfo, *big.Int) error
+	GetRewardDebt(*VoterInfo) (*big.Int, error)
+	SetVoterReward(string, *big.Int) error
+	GetVoterReward(string) (*big.Int, error)
+
 	Undelegate(string, *big.Int) (*types.Action, error)
 	IncAsset2Acct(string, string, *big.Int) (*types.Action, error)
 	GetBalanceByTime(name string, timestamp uint64) (*big.Int, error)
@@ -150,6 +164,12 @@ func (candidateInfo *CandidateInfo) invalid() bool {
 	return candidateInfo.Type != Normal
 }
 
+// JailedCandidateInfo jailed candidate info
+type JailedCandidateInfo struct {
+	*CandidateInfo
+	ReleaseEpoch uint64 `json:"releaseEpoch"` // epoch from which it can be unjailed
+}
+
 // VoterInfo info
 type VoterInfo struct {
 	Epoch               uint64   `json:"epoch"`
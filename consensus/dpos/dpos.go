@@ -144,11 +144,16 @@ func Genesis(cfg *Config, state *state.StateDB, timestamp uint64, number uint64)
 // SignFn signature function
 type SignFn func([]byte, *state.StateDB) ([]byte, error)
 
+// SealFn signature function receiving the whole header, used by remote signers
+// that compute the seal hash themselves
+type SealFn func(*types.Header, *state.StateDB) ([]byte, error)
+
 // Dpos dpos engine
 type Dpos struct {
 	rw sync.RWMutex
 
 	signFn SignFn
+	sealFn SealFn
 
 	config *Config
 
@@ -186,6 +191,14 @@ func (dpos *Dpos) SetSignFn(signFn SignFn) {
 	dpos.signFn = signFn
 }
 
+// SetSealFn set header signature function, it takes precedence over the
+// signature function when set
+func (dpos *Dpos) SetSealFn(sealFn SealFn) {
+	dpos.rw.Lock()
+	defer dpos.rw.Unlock()
+	dpos.sealFn = sealFn
+}
+
 // Author implements consensus.Engine, returning the header's coinbase
 func (dpos *Dpos) Author(header *types.Header) (common.Name, error) {
 	return header.Coinbase, nil
@@ -359,7 +372,7 @@ func (dpos *Dpos) prepare1(chain consensus.IChainReader, header *types.Header, t
 		return err
 	}
 	if header.Number.Uint64() == 1 || gstate.TakeOver {
-		sys.UpdateElectedCandidates1(pepoch, epoch, header.Number.Uint64(), header.Coinbase.String())
+		sys.UpdateElectedCandidates1(pepoch, epoch, header.Number.Uint64(), header.Coinbase.String(), header.CurForkID())
 		if candidate, err := sys.GetCandidate(epoch, header.Coinbase.String()); err != nil {
 			return err
 		} else if candidate != nil {
@@ -375,7 +388,7 @@ func (dpos *Dpos) prepare1(chain consensus.IChainReader, header *types.Header, t
 	systemio := strings.Compare(header.Coinbase.String(), dpos.config.SystemName) == 0
 	takeover := (header.Time.Uint64()-parent.Time.Uint64() > 2*dpos.config.mepochInterval() || dpos.CalcProposedIrreversible(chain, parent, true) == 0) && systemio
 	if takeover {
-		sys.UpdateElectedCandidates1(pepoch, epoch, header.Number.Uint64(), header.Coinbase.String())
+		sys.UpdateElectedCandidates1(pepoch, epoch, header.Number.Uint64(), header.Coinbase.String(), header.CurForkID())
 		gstate, err := sys.GetState(epoch)
 		if err != nil {
 			return err
@@ -401,7 +414,7 @@ func (dpos *Dpos) prepare1(chain consensus.IChainReader, header *types.Header, t
 	}
 
 	if len(gstate.ActivatedCandidateSchedule) == 0 {
-		sys.UpdateElectedCandidates1(pepoch, pepoch, header.Number.Uint64(), header.Coinbase.String())
+		sys.UpdateElectedCandidates1(pepoch, pepoch, header.Number.Uint64(), header.Coinbase.String(), header.CurForkID())
 	}
 
 	pstate, err := sys.GetState(gstate.PreEpoch)
@@ -477,7 +490,7 @@ func (dpos *Dpos) prepare1(chain consensus.IChainReader, header *types.Header, t
 			}
 
 			candidates = map[uint64]*CandidateInfo{}
-			sys.UpdateElectedCandidates1(gstate.Epoch, tepoch, header.Number.Uint64(), header.Coinbase.String())
+			sys.UpdateElectedCandidates1(gstate.Epoch, tepoch, header.Number.Uint64(), header.Coinbase.String(), header.CurForkID())
 			gstate, _ = sys.GetState(tepoch)
 			pstate, _ = sys.GetState(gstate.PreEpoch)
 		}
@@ -681,7 +694,17 @@ func (dpos *Dpos) finalize1(chain consensus.IChainReader, header *types.Header,
 	extraCounter := int64(0)
 	extraReward := new(big.Int).Mul(dpos.config.extraBlockReward(), big.NewInt(extraCounter))
 	reward := new(big.Int).Add(dpos.config.blockReward(), extraReward)
-	sys.IncAsset2Acct(dpos.config.SystemName, header.Coinbase.String(), reward)
+	if header.CurForkID() >= params.ForkID3 {
+		epoch, err := sys.GetLastestEpoch()
+		if err != nil {
+			return nil, err
+		}
+		if err := sys.DistributeReward(epoch, header.Coinbase.String(), reward); err != nil {
+			return nil, err
+		}
+	} else {
+		sys.IncAsset2Acct(dpos.config.SystemName, header.Coinbase.String(), reward)
+	}
 
 	blk := types.NewBlock(header, txs, receipts)
 	// first hard fork at a specific number
@@ -726,7 +749,17 @@ func (dpos *Dpos) Seal(chain consensus.IChainReader, block *types.Block, stop <-
 	if err != nil {
 		return nil, err
 	}
-	sighash, err := dpos.signFn(signHash(header, chain.Config().ChainID.Bytes()).Bytes(), state)
+	dpos.rw.RLock()
+	signFn, sealFn := dpos.signFn, dpos.sealFn
+	dpos.rw.RUnlock()
+	var sighash []byte
+	if sealFn != nil {
+		sighash, err = sealFn(header, state)
+	} else if signFn != nil {
+		sighash, err = signFn(signHash(header, chain.Config().ChainID.Bytes()).Bytes(), state)
+	} else {
+		err = errors.New("no signature function")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -763,6 +796,48 @@ func (dpos *Dpos) VerifySeal(chain consensus.IChainReader, header *types.Header)
 	return nil
 }
 
+// VerifyProducer checks whether the crypto seal on a header was made by a key of
+// a candidate of the state. The parent state of the header is not needed, so
+// the schedule is not checked, the headers downloaded without their states are
+// checked against the last state known to be valid.
+func (dpos *Dpos) VerifyProducer(chain consensus.IChainReader, header *types.Header, state *state.StateDB) error {
+	if header.Number.Uint64() == 0 {
+		return errUnknownBlock
+	}
+	if header.Time.Uint64()%dpos.BlockInterval() != 0 {
+		return errInvalidMintBlockTime
+	}
+	pubkey, err := ecrecover(header, chain.Config().ChainID.Bytes())
+	if err != nil {
+		return err
+	}
+
+	producer := header.Coinbase.String()
+	db := &stateDB{
+		name:  dpos.config.AccountName,
+		state: state,
+	}
+	if !db.IsValidSign(producer, pubkey) {
+		return ErrIllegalCandidatePubKey
+	}
+	if strings.Compare(producer, dpos.config.SystemName) == 0 {
+		return nil
+	}
+	sys := NewSystem(state, dpos.config)
+	epoch, err := sys.GetLastestEpoch()
+	if err != nil {
+		return err
+	}
+	candidate, err := sys.GetCandidate(epoch, producer)
+	if err != nil {
+		return err
+	}
+	if candidate == nil {
+		return ErrIllegalCandidateName
+	}
+	return nil
+}
+
 // CalcDifficulty is the difficulty adjustment algorithm.
 // It returns the difficulty that a new block should have when created at time given the parent block's time and difficulty.
 func (dpos *Dpos) CalcDifficulty(chain consensus.IChainReader, time uint64, parent *types.Header) *big.Int {
@@ -1286,6 +1361,11 @@ func ecrecover(header *types.Header, extra []byte) ([]byte, error) {
 	return pubkey, nil
 }
 
+// SealHash returns the hash signed by the producer of the header.
+func SealHash(header *types.Header) common.Hash {
+	return signHash(header, nil)
+}
+
 func signHash(header *types.Header, extra []byte) (hash common.Hash) {
 	theader := types.CopyHeader(header)
 	theader.Extra = theader.Extra[:len(theader.Extra)-extraSeal]
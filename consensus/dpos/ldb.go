@@ -56,6 +56,18 @@ var (
 	// TakeOver key
 	TakeOver = "takeover"
 
+	// JailKeyPrefix jail release epoch
+	JailKeyPrefix = "j"
+
+	// CommissionKeyPrefix candidate commission rate
+	CommissionKeyPrefix = "c"
+	// RewardPerVoteKeyPrefix accumulated voter reward per vote of a candidate
+	RewardPerVoteKeyPrefix = "rv"
+	// RewardDebtKeyPrefix voter reward already accounted
+	RewardDebtKeyPrefix = "rd"
+	// VoterRewardKeyPrefix voter claimable reward
+	VoterRewardKeyPrefix = "ra"
+
 	// StateKeyPrefix globalState
 	StateKeyPrefix = "s"
 	// LastestStateKey lastest
@@ -321,6 +333,15 @@ func (db *LDB) SetVoter(voter *VoterInfo) error {
 	return nil
 }
 
+// DelVoter del voter info, the entry keeps its place in the voter and
+// candidate lists with a zero quantity so it is removed in O(1), voting
+// the candidate again reuses it. GetVotersByCandidate and GetVotersByVoter
+// skip the withdrawn entries.
+func (db *LDB) DelVoter(voter *VoterInfo) error {
+	voter.Quantity = big.NewInt(0)
+	return db.SetVoter(voter)
+}
+
 // GetVoter voter info by name
 func (db *LDB) GetVoter(epoch uint64, voter string, candidate string) (*VoterInfo, error) {
 	vf := &VoterInfo{
@@ -360,7 +381,9 @@ func (db *LDB) GetVotersByCandidate(epoch uint64, candidate string) ([]*VoterInf
 		} else if err := rlp.DecodeBytes(val, next); err != nil {
 			return nil, err
 		}
-		voterInfos = append(voterInfos, next)
+		if next.Quantity == nil || next.Quantity.Sign() != 0 {
+			voterInfos = append(voterInfos, next)
+		}
 		nextKey = next.NextKeyForCandidate
 	}
 	return voterInfos, nil
@@ -386,7 +409,9 @@ func (db *LDB) GetVotersByVoter(epoch uint64, voter string) ([]*VoterInfo, error
 		} else if err := rlp.DecodeBytes(val, next); err != nil {
 			return nil, err
 		}
-		voterInfos = append(voterInfos, next)
+		if next.Quantity == nil || next.Quantity.Sign() != 0 {
+			voterInfos = append(voterInfos, next)
+		}
 		nextKey = next.NextKeyForVoter
 	}
 	return voterInfos, nil
@@ -415,6 +440,122 @@ func (db *LDB) GetTakeOver() (uint64, error) {
 	return epoch, nil
 }
 
+// SetJail set the epoch from which a jailed candidate can be unjailed
+func (db *LDB) SetJail(candidate string, epoch uint64) error {
+	key := strings.Join([]string{JailKeyPrefix, candidate}, Separator)
+	if val, err := rlp.EncodeToBytes(epoch); err != nil {
+		return err
+	} else if err := db.Put(key, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetJail get the release epoch of a jailed candidate
+func (db *LDB) GetJail(candidate string) (uint64, error) {
+	key := strings.Join([]string{JailKeyPrefix, candidate}, Separator)
+	epoch := uint64(0)
+	if val, err := db.Get(key); err != nil {
+		return epoch, err
+	} else if val == nil {
+		return epoch, nil
+	} else if err := rlp.DecodeBytes(val, &epoch); err != nil {
+		return epoch, err
+	}
+	return epoch, nil
+}
+
+// DelJail delete the release epoch of a candidate
+func (db *LDB) DelJail(candidate string) error {
+	key := strings.Join([]string{JailKeyPrefix, candidate}, Separator)
+	return db.Delete(key)
+}
+
+// SetCommission set the commission rate (percent) of a candidate
+func (db *LDB) SetCommission(candidate string, rate uint64) error {
+	key := strings.Join([]string{CommissionKeyPrefix, candidate}, Separator)
+	if val, err := rlp.EncodeToBytes(rate); err != nil {
+		return err
+	} else if err := db.Put(key, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCommission get the commission rate (percent) of a candidate
+func (db *LDB) GetCommission(candidate string) (uint64, bool, error) {
+	key := strings.Join([]string{CommissionKeyPrefix, candidate}, Separator)
+	rate := uint64(0)
+	if val, err := db.Get(key); err != nil {
+		return rate, false, err
+	} else if val == nil {
+		return rate, false, nil
+	} else if err := rlp.DecodeBytes(val, &rate); err != nil {
+		return rate, false, err
+	}
+	return rate, true, nil
+}
+
+// SetRewardPerVote set the accumulated voter reward per vote of a candidate
+func (db *LDB) SetRewardPerVote(epoch uint64, candidate string, reward *big.Int) error {
+	key := strings.Join([]string{RewardPerVoteKeyPrefix, fmt.Sprintf("0x%x_%s", epoch, candidate)}, Separator)
+	return db.setBigInt(key, reward)
+}
+
+// GetRewardPerVote get the accumulated voter reward per vote of a candidate
+func (db *LDB) GetRewardPerVote(epoch uint64, candidate string) (*big.Int, error) {
+	key := strings.Join([]string{RewardPerVoteKeyPrefix, fmt.Sprintf("0x%x_%s", epoch, candidate)}, Separator)
+	return db.getBigInt(key)
+}
+
+// SetRewardDebt set the reward already accounted for a vote
+func (db *LDB) SetRewardDebt(voter *VoterInfo, debt *big.Int) error {
+	key := strings.Join([]string{RewardDebtKeyPrefix, voter.key()}, Separator)
+	return db.setBigInt(key, debt)
+}
+
+// GetRewardDebt get the reward already accounted for a vote
+func (db *LDB) GetRewardDebt(voter *VoterInfo) (*big.Int, error) {
+	key := strings.Join([]string{RewardDebtKeyPrefix, voter.key()}, Separator)
+	return db.getBigInt(key)
+}
+
+// SetVoterReward set the claimable reward of a voter
+func (db *LDB) SetVoterReward(voter string, reward *big.Int) error {
+	key := strings.Join([]string{VoterRewardKeyPrefix, voter}, Separator)
+	return db.setBigInt(key, reward)
+}
+
+// GetVoterReward get the claimable reward of a voter
+func (db *LDB) GetVoterReward(voter string) (*big.Int, error) {
+	key := strings.Join([]string{VoterRewardKeyPrefix, voter}, Separator)
+	return db.getBigInt(key)
+}
+
+func (db *LDB) setBigInt(key string, value *big.Int) error {
+	if value.Sign() == 0 {
+		return db.Delete(key)
+	}
+	if val, err := rlp.EncodeToBytes(value); err != nil {
+		return err
+	} else if err := db.Put(key, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *LDB) getBigInt(key string) (*big.Int, error) {
+	value := big.NewInt(0)
+	if val, err := db.Get(key); err != nil {
+		return nil, err
+	} else if val == nil {
+		return value, nil
+	} else if err := rlp.DecodeBytes(val, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
 // SetState set global state info
 func (db *LDB) SetState(gstate *GlobalState) error {
 	key := strings.Join([]string{StateKeyPrefix, hex.EncodeToString(uint64tobytes(gstate.Epoch))}, Separator)
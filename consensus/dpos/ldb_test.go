@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/types"
 	"github.com/fractalplatform/fractal/utils/fdb"
 	ldb "github.com/fractalplatform/fractal/utils/fdb/leveldb"
@@ -58,8 +59,8 @@ func (ldb *levelDB) Delete(key string) error {
 func (ldb *levelDB) Delegate(string, *big.Int) error {
 	return nil
 }
-func (ldb *levelDB) Undelegate(string, *big.Int) (*types.Action, error) {
-	return nil, nil
+func (ldb *levelDB) Undelegate(to string, amount *big.Int) (*types.Action, error) {
+	return types.NewAction(types.Transfer, common.StrToName(DefaultConfig.AccountName), common.StrToName(to), 0, DefaultConfig.AssetID, 0, amount, nil, nil), nil
 }
 func (ldb *levelDB) IncAsset2Acct(string, string, *big.Int) (*types.Action, error) {
 	return nil, nil
@@ -244,7 +245,7 @@ func TestLDBVoter(t *testing.T) {
 			Epoch:     epoch,
 			Name:      voter,
 			Candidate: candidates[0],
-			Quantity:  big.NewInt(int64(index)),
+			Quantity:  big.NewInt(int64(index + 1)),
 			Number:    uint64(index),
 		}
 		if err := db.SetAvailableQuantity(epoch, voter, big.NewInt(int64(index))); err != nil {
@@ -276,7 +277,7 @@ func TestLDBVoter(t *testing.T) {
 			Epoch:     epoch,
 			Name:      voters[0],
 			Candidate: candidate,
-			Quantity:  big.NewInt(int64(index)),
+			Quantity:  big.NewInt(int64(index + 1)),
 			Number:    uint64(index),
 		}
 		if err := db.SetAvailableQuantity(epoch, voters[0], big.NewInt(int64(index))); err != nil {
@@ -308,7 +309,7 @@ func TestLDBVoter(t *testing.T) {
 			Epoch:     epoch,
 			Name:      voters[index],
 			Candidate: candidate,
-			Quantity:  big.NewInt(int64(index)),
+			Quantity:  big.NewInt(int64(index + 1)),
 			Number:    uint64(index),
 		}
 		if err := db.SetAvailableQuantity(epoch, voters[index], big.NewInt(int64(index))); err != nil {
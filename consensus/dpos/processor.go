@@ -31,12 +31,14 @@ import (
 
 // RegisterCandidate candidate info
 type RegisterCandidate struct {
-	URL string
+	URL        string
+	Commission []uint64 `rlp:"tail"` // optional share (percent) of the block reward kept by the candidate
 }
 
 // UpdateCandidate candidate info
 type UpdateCandidate struct {
-	URL string
+	URL        string
+	Commission []uint64 `rlp:"tail"` // optional share (percent) of the block reward kept by the candidate
 }
 
 // VoteCandidate vote info
@@ -45,6 +47,24 @@ type VoteCandidate struct {
 	Stake     *big.Int
 }
 
+// UnvoteCandidate unvote info
+type UnvoteCandidate struct {
+	Candidate string
+	Stake     *big.Int
+}
+
+// ChangeVote change vote info
+type ChangeVote struct {
+	From  string
+	To    string
+	Stake *big.Int
+}
+
+// ClaimReward claim reward info
+type ClaimReward struct {
+	Epoch uint64 // epoch of the votes to settle, zero means the current epoch
+}
+
 // KickedCandidate kicked info
 type KickedCandidate struct {
 	Candidates []string
@@ -76,6 +96,9 @@ func (dpos *Dpos) processAction(fid uint64, number uint64, chainCfg *params.Chai
 		if err != nil {
 			return nil, err
 		}
+		if err := accountDB.CheckTransferControl(action.Sender(), action.Recipient(), action.AssetID(), action.Value(), fid); err != nil {
+			return nil, err
+		}
 		if err := accountDB.TransferAsset(action.Sender(), action.Recipient(), action.AssetID(), action.Value()); err != nil {
 			return nil, err
 		}
@@ -95,9 +118,17 @@ func (dpos *Dpos) processAction(fid uint64, number uint64, chainCfg *params.Chai
 		if err := rlp.DecodeBytes(action.Data(), &arg); err != nil {
 			return nil, err
 		}
+		if err := checkCommission(fid, arg.Commission, "RegisterCandidate"); err != nil {
+			return nil, err
+		}
 		if err := sys.RegCandidate(epoch, action.Sender().String(), arg.URL, action.Value(), number, fid); err != nil {
 			return nil, err
 		}
+		if len(arg.Commission) > 0 {
+			if err := sys.SetCandidateCommission(action.Sender().String(), arg.Commission[0]); err != nil {
+				return nil, err
+			}
+		}
 	case types.UpdateCandidate:
 		if fid >= params.ForkID2 {
 			if action.Value().Sign() == 1 {
@@ -108,9 +139,17 @@ func (dpos *Dpos) processAction(fid uint64, number uint64, chainCfg *params.Chai
 		if err := rlp.DecodeBytes(action.Data(), &arg); err != nil {
 			return nil, err
 		}
+		if err := checkCommission(fid, arg.Commission, "UpdateCandidate"); err != nil {
+			return nil, err
+		}
 		if err := sys.UpdateCandidate(epoch, action.Sender().String(), arg.URL, action.Value(), number, fid); err != nil {
 			return nil, err
 		}
+		if len(arg.Commission) > 0 {
+			if err := sys.SetCandidateCommission(action.Sender().String(), arg.Commission[0]); err != nil {
+				return nil, err
+			}
+		}
 	case types.UnregCandidate:
 		if strings.Compare(action.Sender().String(), dpos.config.SystemName) == 0 {
 			return nil, fmt.Errorf("no permission")
@@ -132,6 +171,52 @@ func (dpos *Dpos) processAction(fid uint64, number uint64, chainCfg *params.Chai
 		if err := sys.VoteCandidate(epoch, action.Sender().String(), arg.Candidate, arg.Stake, number, fid); err != nil {
 			return nil, err
 		}
+	case types.UnvoteCandidate:
+		if fid < params.ForkID3 {
+			return nil, accountmanager.ErrUnkownTxType
+		}
+		arg := &UnvoteCandidate{}
+		if err := rlp.DecodeBytes(action.Data(), &arg); err != nil {
+			return nil, err
+		}
+		if err := sys.UnvoteCandidate(epoch, action.Sender().String(), arg.Candidate, arg.Stake, number, fid); err != nil {
+			return nil, err
+		}
+	case types.ChangeVote:
+		if fid < params.ForkID3 {
+			return nil, accountmanager.ErrUnkownTxType
+		}
+		arg := &ChangeVote{}
+		if err := rlp.DecodeBytes(action.Data(), &arg); err != nil {
+			return nil, err
+		}
+		if err := sys.ChangeVote(epoch, action.Sender().String(), arg.From, arg.To, arg.Stake, number, fid); err != nil {
+			return nil, err
+		}
+	case types.ClaimReward:
+		if fid < params.ForkID3 {
+			return nil, accountmanager.ErrUnkownTxType
+		}
+		arg := &ClaimReward{}
+		if err := rlp.DecodeBytes(action.Data(), &arg); err != nil {
+			return nil, err
+		}
+		if arg.Epoch > epoch {
+			return nil, fmt.Errorf("invalid epoch %v(future)", arg.Epoch)
+		}
+		if arg.Epoch == 0 {
+			arg.Epoch = epoch
+		}
+		if err := sys.ClaimReward(arg.Epoch, action.Sender().String(), number, fid); err != nil {
+			return nil, err
+		}
+	case types.UnjailCandidate:
+		if fid < params.ForkID3 {
+			return nil, accountmanager.ErrUnkownTxType
+		}
+		if err := sys.UnjailCandidate(epoch, action.Sender().String(), number, fid); err != nil {
+			return nil, err
+		}
 	case types.KickedCandidate:
 		gstate, _ := sys.GetState(epoch)
 		if gstate.TakeOver == false || strings.Compare(action.Sender().String(), dpos.config.SystemName) != 0 {
@@ -179,3 +264,14 @@ func (dpos *Dpos) processAction(fid uint64, number uint64, chainCfg *params.Chai
 	}
 	return sys.internalActions, nil
 }
+
+// checkCommission keeps the decoding error of the url only payload before the commission fork
+func checkCommission(fid uint64, commission []uint64, payload string) error {
+	if fid < params.ForkID3 && len(commission) > 0 {
+		return fmt.Errorf("rlp: input list has too many elements for dpos.%v", payload)
+	}
+	if len(commission) > 1 {
+		return fmt.Errorf("invalid commission %v", commission)
+	}
+	return nil
+}
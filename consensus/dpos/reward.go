@@ -0,0 +1,159 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/fractalplatform/fractal/types"
+)
+
+// SetCandidateCommission set the share (percent) of the block reward kept by the candidate
+func (sys *System) SetCandidateCommission(candidate string, rate uint64) error {
+	if rate > 100 {
+		return fmt.Errorf("invalid commission %v(max 100)", rate)
+	}
+	return sys.SetCommission(candidate, rate)
+}
+
+// DistributeReward pay the block reward to the producer, the share not kept as
+// commission is accrued to the voters of the producer in proportion to their votes.
+// Candidates without commission keep the whole reward.
+func (sys *System) DistributeReward(epoch uint64, candidate string, reward *big.Int) error {
+	share := big.NewInt(0)
+	prod, err := sys.GetCandidate(epoch, candidate)
+	if err != nil {
+		return err
+	}
+	if prod != nil {
+		rate, ok, err := sys.GetCommission(candidate)
+		if err != nil {
+			return err
+		}
+		votes := new(big.Int).Sub(prod.TotalQuantity, prod.Quantity)
+		if ok && rate < 100 && votes.Sign() == 1 {
+			perVote := new(big.Int).Mul(reward, new(big.Int).SetUint64(100-rate))
+			perVote = perVote.Div(perVote, big.NewInt(100))
+			perVote = perVote.Div(perVote, votes)
+			if perVote.Sign() == 1 {
+				accumulated, err := sys.GetRewardPerVote(epoch, candidate)
+				if err != nil {
+					return err
+				}
+				if err := sys.SetRewardPerVote(epoch, candidate, new(big.Int).Add(accumulated, perVote)); err != nil {
+					return err
+				}
+				share = new(big.Int).Mul(perVote, votes)
+				if _, err := sys.IncAsset2Acct(sys.config.SystemName, sys.config.AccountName, share); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	_, err = sys.IncAsset2Acct(sys.config.SystemName, candidate, new(big.Int).Sub(reward, share))
+	return err
+}
+
+// ClaimReward pay the voter the reward accrued by its votes
+func (sys *System) ClaimReward(epoch uint64, voter string, number uint64, fid uint64) error {
+	voterInfos, err := sys.GetVotersByVoter(epoch, voter)
+	if err != nil {
+		return err
+	}
+	for _, voterInfo := range voterInfos {
+		if err := sys.settleReward(voterInfo); err != nil {
+			return err
+		}
+		if err := sys.resetRewardDebt(voterInfo); err != nil {
+			return err
+		}
+	}
+
+	reward, err := sys.GetVoterReward(voter)
+	if err != nil {
+		return err
+	}
+	if reward.Sign() == 0 {
+		return fmt.Errorf("invalid voter %v(no reward)", voter)
+	}
+	action, err := sys.Undelegate(voter, reward)
+	if err != nil {
+		return fmt.Errorf("undelegate %v failed(%v)", reward, err)
+	}
+	sys.internalActions = append(sys.internalActions, &types.InternalAction{
+		Action: action.NewRPCAction(0),
+	})
+	return sys.SetVoterReward(voter, big.NewInt(0))
+}
+
+// VoterReward get the reward the voter can claim with its votes of the epoch
+func (sys *System) VoterReward(epoch uint64, voter string) (*big.Int, error) {
+	reward, err := sys.GetVoterReward(voter)
+	if err != nil {
+		return nil, err
+	}
+	voterInfos, err := sys.GetVotersByVoter(epoch, voter)
+	if err != nil {
+		return nil, err
+	}
+	for _, voterInfo := range voterInfos {
+		pending, err := sys.pendingReward(voterInfo)
+		if err != nil {
+			return nil, err
+		}
+		reward = new(big.Int).Add(reward, pending)
+	}
+	return reward, nil
+}
+
+func (sys *System) pendingReward(voterInfo *VoterInfo) (*big.Int, error) {
+	perVote, err := sys.GetRewardPerVote(voterInfo.Epoch, voterInfo.Candidate)
+	if err != nil {
+		return nil, err
+	}
+	debt, err := sys.GetRewardDebt(voterInfo)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Sub(new(big.Int).Mul(perVote, voterInfo.Quantity), debt), nil
+}
+
+// settleReward move the reward accrued by the vote since the last settlement to the voter
+func (sys *System) settleReward(voterInfo *VoterInfo) error {
+	pending, err := sys.pendingReward(voterInfo)
+	if err != nil {
+		return err
+	}
+	if pending.Sign() != 1 {
+		return nil
+	}
+	reward, err := sys.GetVoterReward(voterInfo.Name)
+	if err != nil {
+		return err
+	}
+	return sys.SetVoterReward(voterInfo.Name, new(big.Int).Add(reward, pending))
+}
+
+// resetRewardDebt mark the reward of the current vote quantity as settled
+func (sys *System) resetRewardDebt(voterInfo *VoterInfo) error {
+	perVote, err := sys.GetRewardPerVote(voterInfo.Epoch, voterInfo.Candidate)
+	if err != nil {
+		return err
+	}
+	return sys.SetRewardDebt(voterInfo, new(big.Int).Mul(perVote, voterInfo.Quantity))
+}
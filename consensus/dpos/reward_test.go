@@ -0,0 +1,108 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/fractalplatform/fractal/params"
+)
+
+func TestVoterReward(t *testing.T) {
+	ldb, function := newTestLDB()
+	db, err := NewLDB(ldb)
+	defer function()
+	if err != nil {
+		panic(fmt.Errorf("create db failed --- %v", err))
+	}
+	sys := &System{
+		config: DefaultConfig,
+		IDB:    db,
+	}
+
+	epoch, fid := uint64(1), params.ForkID3
+	if err := db.SetState(&GlobalState{
+		Epoch:                  epoch,
+		PreEpoch:               epoch,
+		ActivatedTotalQuantity: big.NewInt(0),
+		TotalQuantity:          big.NewInt(0),
+		Dpos:                   true,
+	}); err != nil {
+		panic(fmt.Errorf("SetState --- %v", err))
+	}
+	candidate := candidates[0]
+	if err := sys.RegCandidate(epoch, candidate, fmt.Sprintf("www.%v.com", candidate), minStakeCandidate, epoch, fid); err != nil {
+		panic(fmt.Sprintf("RegCandidate %v", err))
+	}
+	if err := sys.SetCandidateCommission(candidate, 101); err == nil {
+		t.Fatalf("SetCandidateCommission invalid rate")
+	}
+	if err := sys.SetCandidateCommission(candidate, 20); err != nil {
+		t.Fatalf("SetCandidateCommission %v", err)
+	}
+
+	// voter1 votes 1 min stake, voter2 3 min stake
+	for index, voter := range voters[:2] {
+		stake := new(big.Int).Mul(big.NewInt(int64(2*index+1)), minStakeVote)
+		if err := sys.SetAvailableQuantity(epoch, voter, new(big.Int).Mul(big10, DefaultConfig.VoterMinQuantity)); err != nil {
+			panic(fmt.Errorf("SetAvailableQuantity --- %v", err))
+		}
+		if err := sys.VoteCandidate(epoch, voter, candidate, stake, epoch, fid); err != nil {
+			panic(fmt.Sprintf("VoteCandidate --- %v", err))
+		}
+	}
+
+	votes := new(big.Int).Mul(big.NewInt(4), DefaultConfig.VoterMinQuantity)
+	reward := new(big.Int).Mul(votes, big.NewInt(1000))
+	if err := sys.DistributeReward(epoch, candidate, reward); err != nil {
+		t.Fatalf("DistributeReward %v", err)
+	}
+	perVote := new(big.Int).Div(new(big.Int).Div(new(big.Int).Mul(reward, big.NewInt(80)), big.NewInt(100)), votes)
+	if accumulated, _ := sys.GetRewardPerVote(epoch, candidate); accumulated.Cmp(perVote) != 0 {
+		t.Fatalf("GetRewardPerVote %v mismatch %v", accumulated, perVote)
+	}
+
+	expect := new(big.Int).Mul(perVote, DefaultConfig.VoterMinQuantity)
+	if r, _ := sys.VoterReward(epoch, voters[0]); r.Cmp(expect) != 0 {
+		t.Fatalf("VoterReward %v mismatch %v", r, expect)
+	}
+
+	// a new vote settles what was accrued before it and doesn't share it
+	if err := sys.VoteCandidate(epoch, voters[0], candidate, minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("VoteCandidate --- %v", err))
+	}
+	if r, _ := sys.GetVoterReward(voters[0]); r.Cmp(expect) != 0 {
+		t.Fatalf("GetVoterReward %v mismatch %v", r, expect)
+	}
+	if r, _ := sys.VoterReward(epoch, voters[0]); r.Cmp(expect) != 0 {
+		t.Fatalf("VoterReward %v mismatch %v", r, expect)
+	}
+
+	// unvoting everything keeps the accrued reward
+	if err := sys.UnvoteCandidate(epoch, voters[1], candidate, new(big.Int).Mul(big3, minStakeVote), epoch, fid); err != nil {
+		panic(fmt.Sprintf("UnvoteCandidate --- %v", err))
+	}
+	expect = new(big.Int).Mul(perVote, new(big.Int).Mul(big3, DefaultConfig.VoterMinQuantity))
+	if r, _ := sys.VoterReward(epoch, voters[1]); r.Cmp(expect) != 0 {
+		t.Fatalf("VoterReward %v mismatch %v", r, expect)
+	}
+	if debt, _ := sys.GetRewardDebt(&VoterInfo{Epoch: epoch, Name: voters[1], Candidate: candidate}); debt.Sign() != 0 {
+		t.Fatalf("GetRewardDebt %v not deleted", debt)
+	}
+}
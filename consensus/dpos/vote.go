@@ -417,11 +417,21 @@ func (sys *System) VoteCandidate(epoch uint64, voter string, candidate string, s
 		}
 	}
 
+	if fid >= params.ForkID3 {
+		if err := sys.settleReward(voterInfo); err != nil {
+			return err
+		}
+	}
 	voterInfo.Number = number
 	voterInfo.Quantity = new(big.Int).Add(voterInfo.Quantity, q)
 	if err := sys.SetVoter(voterInfo); err != nil {
 		return err
 	}
+	if fid >= params.ForkID3 {
+		if err := sys.resetRewardDebt(voterInfo); err != nil {
+			return err
+		}
+	}
 
 	prod.TotalQuantity = new(big.Int).Add(prod.TotalQuantity, q)
 
@@ -440,6 +450,196 @@ func (sys *System) VoteCandidate(epoch uint64, voter string, candidate string, s
 	return nil
 }
 
+// UnvoteCandidate withdraw the stake a voter approved to a candidate
+func (sys *System) UnvoteCandidate(epoch uint64, voter string, candidate string, stake *big.Int, number uint64, fid uint64) error {
+	// voter validity
+	voterInfo, err := sys.GetVoter(epoch, voter, candidate)
+	if err != nil {
+		return err
+	}
+	if voterInfo == nil || voterInfo.Quantity.Sign() == 0 {
+		return fmt.Errorf("invalid voter %v(not vote %v)", voter, candidate)
+	}
+
+	// stake validity
+	m := big.NewInt(0)
+	q, _ := new(big.Int).DivMod(stake, sys.config.unitStake(), m)
+	if m.Sign() != 0 {
+		return fmt.Errorf("invalid stake %v(non divisibility, unit %v)", stake, sys.config.unitStake())
+	}
+	if q.Sign() != 1 {
+		return fmt.Errorf("invalid stake %v(must be positive)", stake)
+	}
+	remain := new(big.Int).Sub(voterInfo.Quantity, q)
+	if remain.Sign() == -1 {
+		return fmt.Errorf("invalid unvote stake %v(insufficient) %v < %v", voter, new(big.Int).Mul(voterInfo.Quantity, sys.config.unitStake()), stake)
+	}
+	if remain.Sign() == 1 && remain.Cmp(sys.config.VoterMinQuantity) < 0 {
+		return fmt.Errorf("invalid unvote stake %v(remain insufficient, voter min %v)", stake, new(big.Int).Mul(sys.config.VoterMinQuantity, sys.config.unitStake()))
+	}
+
+	// quantity validity
+	quantity, err := sys.getAvailableQuantity(epoch, voter)
+	if err != nil {
+		return err
+	}
+	if err := sys.SetAvailableQuantity(epoch, voter, new(big.Int).Add(quantity, q)); err != nil {
+		return err
+	}
+
+	// db
+	if err := sys.settleReward(voterInfo); err != nil {
+		return err
+	}
+	if remain.Sign() == 0 {
+		if err := sys.DelVoter(voterInfo); err != nil {
+			return err
+		}
+		if err := sys.SetRewardDebt(voterInfo, big.NewInt(0)); err != nil {
+			return err
+		}
+	} else {
+		voterInfo.Number = number
+		voterInfo.Quantity = remain
+		if err := sys.SetVoter(voterInfo); err != nil {
+			return err
+		}
+		if err := sys.resetRewardDebt(voterInfo); err != nil {
+			return err
+		}
+	}
+
+	prod, err := sys.GetCandidate(epoch, candidate)
+	if err != nil {
+		return err
+	}
+	if prod == nil {
+		return nil
+	}
+	if !prod.invalid() {
+		gstate, err := sys.GetState(epoch)
+		if err != nil {
+			return err
+		}
+		gstate.TotalQuantity = new(big.Int).Sub(gstate.TotalQuantity, q)
+		if gstate.Dpos {
+			// take it out of the schedule first, the insertion only moves candidates up
+			tprod := prod.copy()
+			tprod.Type = Freeze
+			if err := sys.updateState(gstate, tprod); err != nil {
+				return err
+			}
+		}
+		prod.TotalQuantity = new(big.Int).Sub(prod.TotalQuantity, q)
+		if err := sys.updateState(gstate, prod); err != nil {
+			return err
+		}
+		if err := sys.SetState(gstate); err != nil {
+			return err
+		}
+	} else {
+		prod.TotalQuantity = new(big.Int).Sub(prod.TotalQuantity, q)
+	}
+	return sys.SetCandidate(prod)
+}
+
+// ChangeVote move the stake a voter approved from one candidate to another
+func (sys *System) ChangeVote(epoch uint64, voter string, from string, to string, stake *big.Int, number uint64, fid uint64) error {
+	if strings.Compare(from, to) == 0 {
+		return fmt.Errorf("invalid candidate %v(same candidate)", to)
+	}
+	if err := sys.UnvoteCandidate(epoch, voter, from, stake, number, fid); err != nil {
+		return err
+	}
+	return sys.VoteCandidate(epoch, voter, to, stake, number, fid)
+}
+
+// UnjailCandidate restore a jailed candidate once its jail period is over
+func (sys *System) UnjailCandidate(epoch uint64, candidate string, number uint64, fid uint64) error {
+	// name validity
+	prod, err := sys.GetCandidate(epoch, candidate)
+	if err != nil {
+		return err
+	}
+	if prod == nil {
+		return fmt.Errorf("invalid candidate %v(not exist)", candidate)
+	}
+	if prod.Type != Jail {
+		return fmt.Errorf("not in jail %v", candidate)
+	}
+	release, err := sys.GetJail(candidate)
+	if err != nil {
+		return err
+	}
+	if epoch < release {
+		return fmt.Errorf("%v jail period has not arrived (release epoch %v)", candidate, release)
+	}
+
+	// db
+	prod.Type = Normal
+	prod.Number = number
+
+	gstate, err := sys.GetState(epoch)
+	if err != nil {
+		return err
+	}
+	gstate.TotalQuantity = new(big.Int).Add(gstate.TotalQuantity, prod.TotalQuantity)
+	if err := sys.updateState(gstate, prod); err != nil {
+		return err
+	}
+	if err := sys.SetState(gstate); err != nil {
+		return err
+	}
+	if err := sys.SetCandidate(prod); err != nil {
+		return err
+	}
+	return sys.DelJail(candidate)
+}
+
+// jailCandidate jail a producer that missed more than JailMissPercent of its slots in the ending epoch
+func (sys *System) jailCandidate(pstate *GlobalState, candidateInfo *CandidateInfo) error {
+	if sys.config.JailMissPercent == 0 || pstate.TakeOver || candidateInfo.invalid() ||
+		strings.Compare(candidateInfo.Name, sys.config.SystemName) == 0 {
+		return nil
+	}
+
+	// counters are cumulative, the ending epoch is the difference with the previous one
+	counter, actualCounter := candidateInfo.Counter, candidateInfo.ActualCounter
+	if pstate.PreEpoch != pstate.Epoch {
+		prev, err := sys.GetCandidate(pstate.PreEpoch, candidateInfo.Name)
+		if err != nil {
+			return err
+		}
+		if prev != nil && prev.Counter <= counter && prev.ActualCounter <= actualCounter {
+			counter -= prev.Counter
+			actualCounter -= prev.ActualCounter
+		}
+	}
+	if counter == 0 || actualCounter >= counter ||
+		(counter-actualCounter)*100 <= counter*sys.config.JailMissPercent {
+		return nil
+	}
+
+	if sys.config.SlashPercent > 0 {
+		slash := new(big.Int).Mul(candidateInfo.Quantity, new(big.Int).SetUint64(sys.config.SlashPercent))
+		slash = slash.Div(slash, big.NewInt(100))
+		if slash.Sign() == 1 {
+			stake := new(big.Int).Mul(slash, sys.config.unitStake())
+			action, err := sys.Undelegate(sys.config.SystemName, stake)
+			if err != nil {
+				return fmt.Errorf("undelegate %v failed(%v)", stake, err)
+			}
+			sys.internalActions = append(sys.internalActions, &types.InternalAction{
+				Action: action.NewRPCAction(0),
+			})
+			candidateInfo.Quantity = new(big.Int).Sub(candidateInfo.Quantity, slash)
+		}
+	}
+	log.Info("Jail candidate", "candidate", candidateInfo.Name, "epoch", candidateInfo.Epoch, "shouldCounter", counter, "actualCounter", actualCounter)
+	candidateInfo.Type = Jail
+	return sys.SetJail(candidateInfo.Name, candidateInfo.Epoch+sys.config.JailEpochSize)
+}
+
 // KickedCandidate kicked
 func (sys *System) KickedCandidate(epoch uint64, candidate string, number uint64, fid uint64) error {
 	// name validity
@@ -653,7 +853,7 @@ func (sys *System) UpdateElectedCandidates0(pepoch uint64, epoch uint64, number
 }
 
 // UpdateElectedCandidates1 update
-func (sys *System) UpdateElectedCandidates1(pepoch uint64, epoch uint64, number uint64, miner string) error {
+func (sys *System) UpdateElectedCandidates1(pepoch uint64, epoch uint64, number uint64, miner string, fid uint64) error {
 	if pepoch > epoch {
 		panic(fmt.Errorf("UpdateElectedCandidates unreached"))
 	}
@@ -803,6 +1003,11 @@ func (sys *System) UpdateElectedCandidates1(pepoch uint64, epoch uint64, number
 		for _, candidateInfo := range candidateInfoArray {
 			tcandidateInfo := candidateInfo.copy()
 			tcandidateInfo.Epoch = epoch
+			if fid >= params.ForkID3 {
+				if err := sys.jailCandidate(pstate, tcandidateInfo); err != nil {
+					return err
+				}
+			}
 			tcandidateInfo.TotalQuantity = tcandidateInfo.Quantity
 			if !tcandidateInfo.invalid() {
 				gstate.TotalQuantity = new(big.Int).Add(gstate.TotalQuantity, tcandidateInfo.TotalQuantity)
@@ -21,6 +21,8 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+
+	"github.com/fractalplatform/fractal/params"
 )
 
 var (
@@ -251,3 +253,246 @@ func TestCandidateVote(t *testing.T) {
 		}
 	}
 }
+
+func TestUnvoteCandidate(t *testing.T) {
+	ldb, function := newTestLDB()
+	db, err := NewLDB(ldb)
+	defer function()
+	if err != nil {
+		panic(fmt.Errorf("create db failed --- %v", err))
+	}
+	sys := &System{
+		config: DefaultConfig,
+		IDB:    db,
+	}
+
+	epoch, fid := uint64(1), params.ForkID3
+	if err := db.SetState(&GlobalState{
+		Epoch:                  epoch,
+		PreEpoch:               epoch,
+		ActivatedTotalQuantity: big.NewInt(0),
+		TotalQuantity:          big.NewInt(0),
+		Dpos:                   true,
+	}); err != nil {
+		panic(fmt.Errorf("SetState --- %v", err))
+	}
+	for _, candidate := range candidates {
+		if err := sys.RegCandidate(epoch, candidate, fmt.Sprintf("www.%v.com", candidate), minStakeCandidate, epoch, fid); err != nil {
+			panic(fmt.Sprintf("RegCandidate %v", err))
+		}
+	}
+
+	voter := voters[0]
+	if err := sys.SetAvailableQuantity(epoch, voter, new(big.Int).Mul(big10, DefaultConfig.VoterMinQuantity)); err != nil {
+		panic(fmt.Errorf("SetAvailableQuantity --- %v", err))
+	}
+	if err := sys.VoteCandidate(epoch, voter, candidates[0], new(big.Int).Mul(big3, minStakeVote), epoch, fid); err != nil {
+		panic(fmt.Sprintf("VoteCandidate --- %v", err))
+	}
+	if err := sys.VoteCandidate(epoch, voter, candidates[1], minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("VoteCandidate --- %v", err))
+	}
+
+	if err := sys.UnvoteCandidate(epoch, voter, candidates[2], minStakeVote, epoch, fid); err == nil || !strings.Contains(err.Error(), "invalid voter") {
+		panic(fmt.Sprintf("UnvoteCandidate invalid voter %v mismatch", err))
+	}
+	if err := sys.UnvoteCandidate(epoch, voter, candidates[0], new(big.Int).Mul(big10, minStakeVote), epoch, fid); err == nil || !strings.Contains(err.Error(), "insufficient") {
+		panic(fmt.Sprintf("UnvoteCandidate insufficient %v mismatch", err))
+	}
+	if err := sys.UnvoteCandidate(epoch, voter, candidates[0], minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("UnvoteCandidate --- %v", err))
+	}
+	if voterInfo, _ := sys.GetVoter(epoch, voter, candidates[0]); voterInfo.Quantity.Cmp(new(big.Int).Mul(big2, DefaultConfig.VoterMinQuantity)) != 0 {
+		panic(fmt.Sprintf("GetVoter quantity mismatch"))
+	}
+	if quantity, _ := sys.GetAvailableQuantity(epoch, voter); quantity.Cmp(new(big.Int).Mul(big.NewInt(7), DefaultConfig.VoterMinQuantity)) != 0 {
+		panic(fmt.Sprintf("GetAvailableQuantity %v mismatch", quantity))
+	}
+
+	// withdraw all, the voter is cleared in both lists
+	if err := sys.UnvoteCandidate(epoch, voter, candidates[1], minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("UnvoteCandidate --- %v", err))
+	}
+	if voterInfo, _ := sys.GetVoter(epoch, voter, candidates[1]); voterInfo == nil || voterInfo.Quantity.Sign() != 0 {
+		panic(fmt.Sprintf("GetVoter not cleared"))
+	}
+	if err := sys.UnvoteCandidate(epoch, voter, candidates[1], minStakeVote, epoch, fid); err == nil || !strings.Contains(err.Error(), "invalid voter") {
+		panic(fmt.Sprintf("UnvoteCandidate cleared voter %v mismatch", err))
+	}
+	if voterInfos, _ := sys.GetVotersByVoter(epoch, voter); len(voterInfos) != 1 || voterInfos[0].Candidate != candidates[0] {
+		panic(fmt.Sprintf("GetVotersByVoter mismatch"))
+	}
+	if voterInfos, _ := sys.GetVotersByCandidate(epoch, candidates[1]); len(voterInfos) != 0 {
+		panic(fmt.Sprintf("GetVotersByCandidate mismatch"))
+	}
+	if candidateInfo, _ := sys.GetCandidate(epoch, candidates[1]); candidateInfo.TotalQuantity.Cmp(candidateInfo.Quantity) != 0 {
+		panic(fmt.Sprintf("GetCandidate total quantity mismatch"))
+	}
+	if gstate, _ := sys.GetState(epoch); gstate.TotalQuantity.Cmp(new(big.Int).Add(new(big.Int).Mul(big3, DefaultConfig.CandidateMinQuantity), new(big.Int).Mul(big2, DefaultConfig.VoterMinQuantity))) != 0 {
+		panic(fmt.Sprintf("GetState total quantity %v mismatch", gstate.TotalQuantity))
+	}
+
+	// voting again reuses the cleared entry without linking it twice
+	if err := sys.VoteCandidate(epoch, voter, candidates[1], minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("VoteCandidate --- %v", err))
+	}
+	if voterInfos, _ := sys.GetVotersByVoter(epoch, voter); len(voterInfos) != 2 {
+		panic(fmt.Sprintf("GetVotersByVoter %v mismatch", len(voterInfos)))
+	}
+	if voterInfos, _ := sys.GetVotersByCandidate(epoch, candidates[1]); len(voterInfos) != 1 || voterInfos[0].Quantity.Cmp(DefaultConfig.VoterMinQuantity) != 0 {
+		panic(fmt.Sprintf("GetVotersByCandidate mismatch"))
+	}
+}
+
+func TestChangeVote(t *testing.T) {
+	ldb, function := newTestLDB()
+	db, err := NewLDB(ldb)
+	defer function()
+	if err != nil {
+		panic(fmt.Errorf("create db failed --- %v", err))
+	}
+	sys := &System{
+		config: DefaultConfig,
+		IDB:    db,
+	}
+
+	epoch, fid := uint64(1), params.ForkID3
+	if err := db.SetState(&GlobalState{
+		Epoch:                  epoch,
+		PreEpoch:               epoch,
+		ActivatedTotalQuantity: big.NewInt(0),
+		TotalQuantity:          big.NewInt(0),
+		Dpos:                   true,
+	}); err != nil {
+		panic(fmt.Errorf("SetState --- %v", err))
+	}
+	for _, candidate := range candidates {
+		if err := sys.RegCandidate(epoch, candidate, fmt.Sprintf("www.%v.com", candidate), minStakeCandidate, epoch, fid); err != nil {
+			panic(fmt.Sprintf("RegCandidate %v", err))
+		}
+	}
+
+	voter := voters[0]
+	if err := sys.SetAvailableQuantity(epoch, voter, new(big.Int).Mul(big2, DefaultConfig.VoterMinQuantity)); err != nil {
+		panic(fmt.Errorf("SetAvailableQuantity --- %v", err))
+	}
+	if err := sys.VoteCandidate(epoch, voter, candidates[0], new(big.Int).Mul(big2, minStakeVote), epoch, fid); err != nil {
+		panic(fmt.Sprintf("VoteCandidate --- %v", err))
+	}
+	if err := sys.ChangeVote(epoch, voter, candidates[0], candidates[0], minStakeVote, epoch, fid); err == nil || !strings.Contains(err.Error(), "same candidate") {
+		panic(fmt.Sprintf("ChangeVote same candidate %v mismatch", err))
+	}
+	if err := sys.ChangeVote(epoch, voter, candidates[0], candidates[2], minStakeVote, epoch, fid); err != nil {
+		panic(fmt.Sprintf("ChangeVote --- %v", err))
+	}
+
+	for index, expect := range []*big.Int{DefaultConfig.VoterMinQuantity, big0, DefaultConfig.VoterMinQuantity} {
+		candidateInfo, _ := sys.GetCandidate(epoch, candidates[index])
+		if new(big.Int).Sub(candidateInfo.TotalQuantity, candidateInfo.Quantity).Cmp(expect) != 0 {
+			panic(fmt.Sprintf("GetCandidate %v total quantity mismatch", candidates[index]))
+		}
+	}
+	if quantity, _ := sys.GetAvailableQuantity(epoch, voter); quantity.Sign() != 0 {
+		panic(fmt.Sprintf("GetAvailableQuantity %v mismatch", quantity))
+	}
+	if gstate, _ := sys.GetState(epoch); strings.Join(gstate.ActivatedCandidateSchedule, ",") != strings.Join([]string{candidates[2], candidates[0], candidates[1]}, ",") {
+		panic(fmt.Sprintf("ActivatedCandidateSchedule %v mismatch", gstate.ActivatedCandidateSchedule))
+	}
+}
+
+func TestJailCandidate(t *testing.T) {
+	ldb, function := newTestLDB()
+	db, err := NewLDB(ldb)
+	defer function()
+	if err != nil {
+		panic(fmt.Errorf("create db failed --- %v", err))
+	}
+	sys := &System{
+		config: DefaultConfig,
+		IDB:    db,
+	}
+	DefaultConfig.JailMissPercent, DefaultConfig.JailEpochSize, DefaultConfig.SlashPercent = 50, 3, 10
+	defer func() {
+		DefaultConfig.JailMissPercent, DefaultConfig.JailEpochSize, DefaultConfig.SlashPercent = 0, 0, 0
+	}()
+
+	candidate := candidates[0]
+	quantity := new(big.Int).Mul(big3, DefaultConfig.CandidateMinQuantity)
+	setCandidate := func(epoch uint64, counter uint64, actualCounter uint64, ctype CandidateType) {
+		if err := db.SetCandidate(&CandidateInfo{
+			Epoch:         epoch,
+			Name:          candidate,
+			Quantity:      quantity,
+			TotalQuantity: quantity,
+			Counter:       counter,
+			ActualCounter: actualCounter,
+			Type:          ctype,
+		}); err != nil {
+			panic(fmt.Errorf("SetCandidate --- %v", err))
+		}
+	}
+	setState := func(epoch uint64, pepoch uint64) *GlobalState {
+		gstate := &GlobalState{
+			Epoch:                  epoch,
+			PreEpoch:               pepoch,
+			ActivatedTotalQuantity: big.NewInt(0),
+			TotalQuantity:          big.NewInt(0),
+			Dpos:                   true,
+		}
+		if err := db.SetState(gstate); err != nil {
+			panic(fmt.Errorf("SetState --- %v", err))
+		}
+		return gstate
+	}
+
+	// 10 slots in epoch 2, only 2 produced
+	setState(1, 1)
+	setCandidate(1, 10, 10, Normal)
+	pstate := setState(2, 1)
+	setCandidate(2, 20, 12, Normal)
+
+	prod, _ := sys.GetCandidate(2, candidate)
+	prod.Epoch = 3
+	if err := sys.jailCandidate(pstate, prod); err != nil {
+		t.Fatalf("jailCandidate %v", err)
+	}
+	if prod.Type != Jail {
+		t.Fatalf("jailCandidate type %v mismatch", prod.Type)
+	}
+	if expect := new(big.Int).Sub(quantity, big3); prod.Quantity.Cmp(expect) != 0 {
+		t.Fatalf("jailCandidate slash %v mismatch %v", prod.Quantity, expect)
+	}
+	if len(sys.internalActions) != 1 || sys.internalActions[0].Action.To.String() != DefaultConfig.SystemName {
+		t.Fatalf("jailCandidate internal actions %v mismatch", sys.internalActions)
+	}
+	if release, _ := sys.GetJail(candidate); release != 3+DefaultConfig.JailEpochSize {
+		t.Fatalf("GetJail %v mismatch", release)
+	}
+
+	// a producer that missed less than JailMissPercent stays normal
+	prod, _ = sys.GetCandidate(1, candidate)
+	if err := sys.jailCandidate(setState(3, 3), prod); err != nil || prod.Type != Normal {
+		t.Fatalf("jailCandidate %v %v mismatch", prod.Type, err)
+	}
+
+	setCandidate(3, 20, 12, Jail)
+	if err := sys.UnjailCandidate(3, candidate, 3, 0); err == nil || !strings.Contains(err.Error(), "jail period") {
+		t.Fatalf("UnjailCandidate %v mismatch", err)
+	}
+
+	release := 3 + DefaultConfig.JailEpochSize
+	setState(release, 3)
+	setCandidate(release, 20, 12, Jail)
+	if err := sys.UnjailCandidate(release, candidate, release, 0); err != nil {
+		t.Fatalf("UnjailCandidate %v", err)
+	}
+	if prod, _ := sys.GetCandidate(release, candidate); prod.Type != Normal {
+		t.Fatalf("UnjailCandidate type %v mismatch", prod.Type)
+	}
+	if gstate, _ := sys.GetState(release); gstate.TotalQuantity.Cmp(quantity) != 0 {
+		t.Fatalf("UnjailCandidate total quantity %v mismatch", gstate.TotalQuantity)
+	}
+	if err := sys.UnjailCandidate(release, candidate, release, 0); err == nil || !strings.Contains(err.Error(), "not in jail") {
+		t.Fatalf("UnjailCandidate %v mismatch", err)
+	}
+}
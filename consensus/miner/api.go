@@ -17,6 +17,7 @@
 package miner
 
 import (
+	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/rpc"
 )
@@ -43,10 +44,30 @@ func (api *API) Mining() bool {
 	return api.miner.Mining()
 }
 
+// Mine seals n blocks right away, only in dev mode.
+func (api *API) Mine(n uint64) (uint64, error) {
+	return api.miner.Mine(n)
+}
+
+// WarpTime moves the timestamp of the next block forward by the given
+// milliseconds, only in dev mode.
+func (api *API) WarpTime(duration uint64) error {
+	return api.miner.WarpTime(duration)
+}
+
 func (api *API) SetCoinbase(name string, privKeys []string) error {
 	return api.miner.SetCoinbase(name, privKeys)
 }
 
+func (api *API) SetCoinbaseFromKeyStore(name string, pubKeys []common.PubKey) error {
+	return api.miner.SetCoinbaseFromKeyStore(name, pubKeys)
+}
+
+// SetSigner makes the miner sign blocks with the keys of a remote signer.
+func (api *API) SetSigner(name string, endpoint string) error {
+	return api.miner.SetSigner(name, endpoint)
+}
+
 func (api *API) SetDelay(delayDuration uint64) error {
 	return api.miner.SetDelayDuration(delayDuration)
 }
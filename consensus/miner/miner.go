@@ -19,18 +19,29 @@ package miner
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/crypto"
 	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/signer"
 )
 
+// KeyStore provides the unlocked keys the miner can sign blocks with.
+type KeyStore interface {
+	KeysByName(name common.Name) []common.PubKey
+	UnlockedKey(pubKey common.PubKey) (*ecdsa.PrivateKey, error)
+}
+
 // Miner creates blocks and searches for proof values.
 type Miner struct {
-	worker *Worker
+	worker   *Worker
+	keyStore KeyStore
 
 	mining      int32
 	canStart    int32 // can start indicates whether we can start the mining operation
@@ -133,6 +144,90 @@ func (miner *Miner) SetCoinbase(name string, privKeys []string) error {
 	return nil
 }
 
+// SetKeyStore sets the keystore of the miner.
+func (miner *Miner) SetKeyStore(keyStore KeyStore) {
+	miner.keyStore = keyStore
+}
+
+// SetCoinbaseFromKeyStore sets the coinbase name with the unlocked keystore
+// keys of pubKeys, or with the keys bound to the name if pubKeys is empty.
+// The keys are kept by the miner, locking them afterwards has no effect.
+func (miner *Miner) SetCoinbaseFromKeyStore(name string, pubKeys []common.PubKey) error {
+	if miner.keyStore == nil {
+		return fmt.Errorf("keystore not available")
+	}
+	if len(pubKeys) == 0 {
+		pubKeys = miner.keyStore.KeysByName(common.Name(name))
+		if len(pubKeys) == 0 {
+			return fmt.Errorf("no keystore key of %v", name)
+		}
+	}
+	privs := make([]*ecdsa.PrivateKey, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		priv, err := miner.keyStore.UnlockedKey(pubKey)
+		if err != nil {
+			return fmt.Errorf("%v: %v", pubKey, err)
+		}
+		privs = append(privs, priv)
+	}
+
+	miner.worker.setCoinbase(name, privs)
+	return nil
+}
+
+// SetSigner makes the miner sign blocks of the coinbase with the keys of the
+// remote signer at endpoint, the unix socket path of the signer.
+func (miner *Miner) SetSigner(name string, endpoint string) error {
+	client, err := signer.Dial(endpoint)
+	if err != nil {
+		return err
+	}
+	pubKeys, err := client.PubKeys()
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("signer %v: %v", endpoint, err)
+	}
+	if len(pubKeys) == 0 {
+		client.Close()
+		return fmt.Errorf("signer %v has no unlocked key", endpoint)
+	}
+	miner.worker.setSigner(name, client, pubKeys)
+	return nil
+}
+
+// SetDevMode makes the miner seal a block as soon as transactions arrive or
+// on demand instead of following the dpos slots. It must be set before Start.
+func (miner *Miner) SetDevMode(dev bool) {
+	miner.worker.dev = dev
+}
+
+// Mine seals n blocks right away and returns the number of the last one,
+// it's only available in dev mode.
+func (miner *Miner) Mine(n uint64) (uint64, error) {
+	if !miner.worker.dev {
+		return 0, errors.New("mine is only available in dev mode")
+	}
+	var number uint64
+	for i := uint64(0); i < n; i++ {
+		block, err := miner.worker.mintDevBlock()
+		if err != nil {
+			return number, err
+		}
+		number = block.NumberU64()
+	}
+	return number, nil
+}
+
+// WarpTime moves the timestamp of the next block forward by the given
+// milliseconds, it's only available in dev mode.
+func (miner *Miner) WarpTime(duration uint64) error {
+	if !miner.worker.dev {
+		return errors.New("warp time is only available in dev mode")
+	}
+	miner.worker.warpTime(duration * uint64(time.Millisecond))
+	return nil
+}
+
 func (miner *Miner) SetDelayDuration(delayDuration uint64) error {
 	return miner.worker.setDelayDuration(delayDuration)
 }
@@ -0,0 +1,240 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/blockchain"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/event"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/processor"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/signer"
+	"github.com/fractalplatform/fractal/snapshot"
+	"github.com/fractalplatform/fractal/txpool"
+	"github.com/fractalplatform/fractal/types"
+	memdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
+)
+
+// founderKey is the key of the founder account of the default genesis.
+const founderKey = "289c2857d4598e37fb9647507e47a309d6133539bf21a8b9cb6df88fd5232032"
+
+type emptyPool struct{}
+
+func (emptyPool) Pending() (map[common.Name][]*types.Transaction, error) {
+	return nil, nil
+}
+
+func newTestMiner(t *testing.T, dev bool) (*Miner, *blockchain.BlockChain) {
+	// mined block announcements go nowhere without the p2p adaptor
+	event.StationRegister(event.NewBroadcastStation("broadcast", nil))
+
+	chainDb := memdb.NewMemDatabase()
+	chainCfg, dposCfg, _, err := blockchain.SetupGenesisBlock(chainDb, blockchain.DefaultDevGenesis())
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := blockchain.NewBlockChain(chainDb, false, vm.Config{}, chainCfg, nil, 0, txpool.SenderCacher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type bc struct {
+		*blockchain.BlockChain
+		consensus.IEngine
+		emptyPool
+		processor.Processor
+	}
+	engine := dpos.New(dposCfg, chain)
+	bcc := &bc{chain, engine, emptyPool{}, nil}
+	txProcessor := processor.NewStateProcessor(bcc, engine)
+	chain.SetValidator(processor.NewBlockValidator(bcc, engine))
+	chain.SetProcessor(txProcessor)
+	bcc.Processor = txProcessor
+
+	miner := NewMiner(bcc)
+	if err := miner.SetCoinbase(chainCfg.SysName, []string{founderKey}); err != nil {
+		t.Fatal(err)
+	}
+	miner.SetDevMode(dev)
+	return miner, chain
+}
+
+// newTestSigner serves a remote signer holding the founder key on the unix
+// socket returned, until stop is called.
+func newTestSigner(t *testing.T, dir string) (endpoint string, stop func()) {
+	ks, err := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := crypto.HexToECDSA(founderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := ks.ImportECDSA(priv, common.Name("fractal.founder"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account.PublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	// the dev blocks are off the wall clock
+	s, err := signer.New(ks, filepath.Join(dir, "signed_blocks.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint = filepath.Join(dir, "signer.ipc")
+	listener, server, err := rpc.StartIPCEndpoint(endpoint, s.APIs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return endpoint, func() {
+		listener.Close()
+		server.Stop()
+	}
+}
+
+func TestSwitchSignerWhileMining(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fractal-miner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	miner, chain := newTestMiner(t, true)
+	defer chain.Stop()
+	miner.Start(true)
+	defer miner.Stop()
+
+	mine := func(number uint64) {
+		if n, err := miner.Mine(1); err != nil || n != number {
+			t.Fatalf("mine block %v: %v %v", number, n, err)
+		}
+	}
+	mine(1)
+
+	// switching to the remote signer installs its seal function
+	endpoint, stop := newTestSigner(t, dir)
+	if err := miner.SetSigner(chain.Config().SysName, endpoint); err != nil {
+		t.Fatal(err)
+	}
+	mine(2)
+	stop()
+	if _, err := miner.Mine(1); err == nil {
+		t.Fatal("sealed without the remote signer")
+	}
+
+	// switching back to the local keys drops it
+	if err := miner.SetCoinbase(chain.Config().SysName, []string{founderKey}); err != nil {
+		t.Fatal(err)
+	}
+	mine(3)
+}
+
+func TestDevMineAndWarpTime(t *testing.T) {
+	miner, chain := newTestMiner(t, true)
+	defer chain.Stop()
+	if _, err := miner.Mine(1); err == nil {
+		t.Fatal("mined before start")
+	}
+	miner.Start(true)
+	defer miner.Stop()
+
+	if n, err := miner.Mine(3); err != nil || n != 3 {
+		t.Fatalf("mine 3 blocks: %v %v", n, err)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Fatalf("head %v mismatch", head)
+	}
+
+	engine := miner.worker.Engine().(*dpos.Dpos)
+	interval := engine.BlockInterval()
+	epoch := func(block *types.Block) uint64 {
+		state, err := chain.StateAt(block.Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		epoch, _, err := engine.GetEpoch(state, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return epoch
+	}
+	parent := chain.CurrentBlock()
+	if parent.Time().Uint64() != chain.GetBlockByNumber(2).Time().Uint64()+interval {
+		t.Fatalf("dev block time %v not the next slot", parent.Time())
+	}
+
+	// warp past the next epoch, and so past the next snapshot, off the slot
+	warp := engine.Config().EpochInterval + engine.Config().BlockInterval/2
+	if err := miner.WarpTime(warp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := miner.Mine(1); err != nil {
+		t.Fatal(err)
+	}
+	block := chain.CurrentBlock()
+	expect := parent.Time().Uint64() + interval + warp*uint64(time.Millisecond)
+	expect -= expect % interval
+	if block.Time().Uint64() != expect {
+		t.Fatalf("warped block time %v mismatch %v", block.Time(), expect)
+	}
+	if epoch(block) <= epoch(parent) {
+		t.Fatalf("epoch %v not past %v", epoch(block), epoch(parent))
+	}
+	state, err := chain.StateAt(block.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotInterval := chain.Config().SnapshotInterval * uint64(time.Millisecond)
+	if last, err := snapshot.NewSnapshotManager(state).GetLastSnapshotTime(); err != nil || last != block.Time().Uint64()/snapshotInterval*snapshotInterval {
+		t.Fatalf("last snapshot time %v mismatch: %v", last, err)
+	}
+
+	// the warp only applies to the next block
+	if _, err := miner.Mine(1); err != nil {
+		t.Fatal(err)
+	}
+	if next := chain.CurrentBlock().Time().Uint64(); next != block.Time().Uint64()+interval {
+		t.Fatalf("block time %v after warp mismatch", next)
+	}
+}
+
+func TestMineOutsideDevMode(t *testing.T) {
+	miner, chain := newTestMiner(t, false)
+	defer chain.Stop()
+
+	if _, err := miner.Mine(1); err == nil {
+		t.Fatal("mine allowed outside dev mode")
+	}
+	if err := miner.WarpTime(1000); err == nil {
+		t.Fatal("warp time allowed outside dev mode")
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("head %v mismatch", head)
+	}
+}
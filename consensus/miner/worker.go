@@ -38,13 +38,14 @@ import (
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor"
 	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/signer"
 	"github.com/fractalplatform/fractal/state"
 	"github.com/fractalplatform/fractal/types"
 )
 
 const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
-	// txChanSize = 4096
+	txChanSize = 4096
 	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
 	chainHeadChanSize = 10
 )
@@ -58,6 +59,7 @@ type Worker struct {
 	coinbase      string
 	privKeys      []*ecdsa.PrivateKey
 	pubKeys       [][]byte
+	signer        *signer.Client // remote signer holding the keys of pubKeys
 	extra         []byte
 
 	wg         sync.WaitGroup
@@ -67,6 +69,12 @@ type Worker struct {
 	wgWork     sync.WaitGroup
 	quit       chan struct{}
 	force      bool
+
+	// dev mode seals a block as soon as transactions arrive or on demand,
+	// instead of following the wall-clock dpos slots.
+	dev   bool
+	devMu sync.Mutex
+	warp  uint64 // time added to the timestamp of the next dev block
 }
 
 func newWorker(consensus consensus.IConsensus) *Worker {
@@ -89,7 +97,8 @@ out:
 		case ev := <-chainHeadCh:
 			// Handle ChainHeadEvent
 			if atomic.LoadInt32(&worker.mining) != 0 {
-				if blk := ev.Data.(*types.Block); strings.Compare(blk.Coinbase().String(), worker.coinbase) != 0 {
+				coinbase, _, _, _ := worker.keys()
+				if blk := ev.Data.(*types.Block); strings.Compare(blk.Coinbase().String(), coinbase) != 0 {
 					worker.quitWorkRW.Lock()
 					if worker.quitWork != nil {
 						log.Debug("old parent hash coming, will be closing current work")
@@ -111,28 +120,80 @@ func (worker *Worker) start(force bool) {
 		return
 	}
 	worker.force = force
-	go worker.mintLoop()
+	// install the signature functions before any block can be minted
+	cdpos, err := worker.setSignFn()
+	if err != nil {
+		log.Error("failed to start worker", "err", err)
+		atomic.StoreInt32(&worker.mining, 0)
+		return
+	}
+	if worker.dev {
+		go worker.devLoop()
+		return
+	}
+	go worker.mintLoop(cdpos)
 }
 
-func (worker *Worker) mintLoop() {
-	worker.wg.Add(1)
-	defer worker.wg.Done()
-	dpos, ok := worker.Engine().(*dpos.Dpos)
+// keys returns the coinbase and the keys its blocks are signed with.
+func (worker *Worker) keys() (string, []*ecdsa.PrivateKey, [][]byte, *signer.Client) {
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	return worker.coinbase, worker.privKeys, worker.pubKeys, worker.signer
+}
+
+// setSignFn makes the engine sign blocks with the coinbase keys.
+func (worker *Worker) setSignFn() (*dpos.Dpos, error) {
+	cdpos, ok := worker.Engine().(*dpos.Dpos)
 	if !ok {
-		panic("only support dpos engine")
+		return nil, errors.New("only support dpos engine")
 	}
-	dpos.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+	cdpos.SetSignFn(func(content []byte, state *state.StateDB) ([]byte, error) {
+		coinbase, privKeys, pubKeys, _ := worker.keys()
 		accountDB, err := accountmanager.NewAccountManager(state)
 		if err != nil {
 			return nil, err
 		}
-		for index, privKey := range worker.privKeys {
-			if err := accountDB.IsValidSign(common.StrToName(worker.coinbase), common.BytesToPubKey(worker.pubKeys[index])); err == nil {
+		for index, privKey := range privKeys {
+			if err := accountDB.IsValidSign(common.StrToName(coinbase), common.BytesToPubKey(pubKeys[index])); err == nil {
 				return crypto.Sign(content, privKey)
 			}
 		}
 		return nil, fmt.Errorf("not found match private key for sign")
 	})
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	worker.setSealFn(cdpos)
+	return cdpos, nil
+}
+
+// setSealFn makes the engine seal blocks with the remote signer, or with the
+// local keys if the worker has none. worker.mu must be held.
+func (worker *Worker) setSealFn(cdpos *dpos.Dpos) {
+	if worker.signer == nil {
+		cdpos.SetSealFn(nil)
+		return
+	}
+	cdpos.SetSealFn(func(header *types.Header, state *state.StateDB) ([]byte, error) {
+		coinbase, _, pubKeys, client := worker.keys()
+		if client == nil {
+			return nil, errors.New("remote signer not set")
+		}
+		accountDB, err := accountmanager.NewAccountManager(state)
+		if err != nil {
+			return nil, err
+		}
+		for _, pubKey := range pubKeys {
+			if err := accountDB.IsValidSign(common.StrToName(coinbase), common.BytesToPubKey(pubKey)); err == nil {
+				return client.SignHeader(common.BytesToPubKey(pubKey), header)
+			}
+		}
+		return nil, fmt.Errorf("not found match remote key for sign")
+	})
+}
+
+func (worker *Worker) mintLoop(dpos *dpos.Dpos) {
+	worker.wg.Add(1)
+	defer worker.wg.Done()
 	interval := int64(dpos.BlockInterval())
 	c := make(chan time.Time)
 	to := time.Now()
@@ -162,6 +223,64 @@ func (worker *Worker) mintLoop() {
 	}
 }
 
+// devLoop seals a block whenever new transactions enter the pool.
+func (worker *Worker) devLoop() {
+	worker.wg.Add(1)
+	defer worker.wg.Done()
+	txsCh := make(chan *event.Event, txChanSize)
+	txsSub := event.Subscribe(nil, txsCh, event.NewTxs, []*types.Transaction{})
+	defer txsSub.Unsubscribe()
+	for {
+		select {
+		case <-txsCh:
+			if _, err := worker.mintDevBlock(); err != nil {
+				log.Warn("failed to mint dev block", "err", err)
+			}
+		case <-worker.quit:
+			worker.quit = make(chan struct{})
+			return
+		}
+	}
+}
+
+// mintDevBlock seals a block on top of the current head right away. Its
+// timestamp is the slot following the parent plus the pending time warp.
+func (worker *Worker) mintDevBlock() (*types.Block, error) {
+	worker.devMu.Lock()
+	defer worker.devMu.Unlock()
+
+	if atomic.LoadInt32(&worker.mining) == 0 {
+		return nil, errors.New("miner not started")
+	}
+	cdpos := worker.Engine().(*dpos.Dpos)
+	interval := cdpos.BlockInterval()
+	header := worker.CurrentHeader()
+	timestamp := header.Time.Uint64() + interval + atomic.SwapUint64(&worker.warp, 0)
+	timestamp -= timestamp % interval
+
+	state, err := worker.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	theader := &types.Header{}
+	worker.FillForkID(theader, state)
+	coinbase, _, pubKeys, _ := worker.keys()
+	if err := cdpos.IsValidateCandidate(worker, header, timestamp, coinbase, pubKeys, state, worker.force, theader.CurForkID()); err != nil {
+		return nil, err
+	}
+	block, err := worker.commitNewWork(int64(timestamp), header, nil)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Mined new dev block", "candidate", block.Coinbase(), "number", block.Number(), "hash", block.Hash().String(), "time", block.Time().Int64(), "txs", len(block.Txs), "gas", block.GasUsed())
+	return block, nil
+}
+
+// warpTime moves the timestamp of the next dev block forward.
+func (worker *Worker) warpTime(duration uint64) {
+	atomic.AddUint64(&worker.warp, duration)
+}
+
 func (worker *Worker) mintBlock(timestamp int64, quit chan struct{}) {
 	worker.quitWorkRW.Lock()
 	worker.quitWork = quit
@@ -191,7 +310,8 @@ func (worker *Worker) mintBlock(timestamp int64, quit chan struct{}) {
 		}
 		theader := &types.Header{}
 		worker.FillForkID(theader, state)
-		if err := cdpos.IsValidateCandidate(worker, header, uint64(timestamp), worker.coinbase, worker.pubKeys, state, worker.force, theader.CurForkID()); err != nil {
+		coinbase, _, pubKeys, _ := worker.keys()
+		if err := cdpos.IsValidateCandidate(worker, header, uint64(timestamp), coinbase, pubKeys, state, worker.force, theader.CurForkID()); err != nil {
 			switch err {
 			case dpos.ErrSystemTakeOver:
 				fallthrough
@@ -200,7 +320,7 @@ func (worker *Worker) mintBlock(timestamp int64, quit chan struct{}) {
 			case dpos.ErrIllegalCandidateName:
 				fallthrough
 			case dpos.ErrIllegalCandidatePubKey:
-				log.Warn("failed to mint the block", "timestamp", timestamp, "err", err, "candidate", worker.coinbase)
+				log.Warn("failed to mint the block", "timestamp", timestamp, "err", err, "candidate", coinbase)
 			default:
 				log.Debug("failed to mint the block", "timestamp", timestamp, "err", err)
 			}
@@ -243,11 +363,38 @@ func (worker *Worker) setCoinbase(name string, privKeys []*ecdsa.PrivateKey) {
 	worker.coinbase = name
 	worker.privKeys = privKeys
 	worker.pubKeys = nil
+	if worker.signer != nil {
+		worker.signer.Close()
+		worker.signer = nil
+	}
 	for index, privkey := range privKeys {
 		pubkey := crypto.FromECDSAPub(&privkey.PublicKey)
 		log.Info("setCoinbase", "coinbase", name, fmt.Sprintf("pubKey_%03d", index), common.BytesToPubKey(pubkey).String())
 		worker.pubKeys = append(worker.pubKeys, pubkey)
 	}
+	if cdpos, ok := worker.Engine().(*dpos.Dpos); ok {
+		worker.setSealFn(cdpos)
+	}
+}
+
+// setSigner makes the worker sign blocks with the keys of a remote signer.
+func (worker *Worker) setSigner(name string, client *signer.Client, pubKeys []common.PubKey) {
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	if worker.signer != nil {
+		worker.signer.Close()
+	}
+	worker.coinbase = name
+	worker.privKeys = nil
+	worker.pubKeys = nil
+	worker.signer = client
+	for index, pubKey := range pubKeys {
+		log.Info("setSigner", "coinbase", name, fmt.Sprintf("pubKey_%03d", index), pubKey.String())
+		worker.pubKeys = append(worker.pubKeys, pubKey.Bytes())
+	}
+	if cdpos, ok := worker.Engine().(*dpos.Dpos); ok {
+		worker.setSealFn(cdpos)
+	}
 }
 
 func (worker *Worker) setExtra(extra []byte) {
@@ -258,14 +405,14 @@ func (worker *Worker) setExtra(extra []byte) {
 
 func (worker *Worker) commitNewWork(timestamp int64, parent *types.Header, quit chan struct{}) (*types.Block, error) {
 	dpos := worker.Engine().(*dpos.Dpos)
-	if t := time.Now(); t.UnixNano() >= timestamp+int64(dpos.BlockInterval()) {
+	if t := time.Now(); !worker.dev && t.UnixNano() >= timestamp+int64(dpos.BlockInterval()) {
 		return nil, fmt.Errorf("mint the ingore block, need %v, now %v, sub %v", timestamp, t.UnixNano(), t.Sub(time.Unix(timestamp/int64(time.Second), timestamp%int64(time.Second))))
 	}
 	if parent.Time.Int64() >= timestamp {
 		return nil, errors.New("mint the old block")
 	}
 	// if dpos.IsFirst(uint64(timestamp)) && parent.Time.Int64() != timestamp-int64(dpos.BlockInterval()) && timestamp-time.Now().UnixNano() >= int64(dpos.BlockInterval())/10 {
-	if parent.Number.Uint64() > 0 &&
+	if !worker.dev && parent.Number.Uint64() > 0 &&
 		parent.Time.Int64()+int64(dpos.BlockInterval()) < timestamp &&
 		time.Now().UnixNano()-timestamp <= 2*int64(dpos.BlockInterval())/5 {
 		return nil, errors.New("wait for last block arrived")
@@ -280,7 +427,8 @@ func (worker *Worker) commitNewWork(timestamp int64, parent *types.Header, quit
 		Time:       big.NewInt(timestamp),
 		Difficulty: worker.CalcDifficulty(worker.IConsensus, uint64(timestamp), parent),
 	}
-	header.Coinbase = common.StrToName(worker.coinbase)
+	coinbase, _, _, _ := worker.keys()
+	header.Coinbase = common.StrToName(coinbase)
 	header.ProposedIrreversible = dpos.CalcProposedIrreversible(worker, parent, false)
 
 	state, err := worker.StateAt(parent.Root)
@@ -319,7 +467,11 @@ func (worker *Worker) commitNewWork(timestamp int64, parent *types.Header, quit
 	log.Debug("worker get pending txs from txpool", "len", txsLen, "since", time.Since(start))
 
 	txs := types.NewTransactionsByPriceAndNonce(pending)
-	if err := worker.commitTransactions(work, txs, dpos.BlockInterval()); err != nil {
+	interval := dpos.BlockInterval()
+	if worker.dev {
+		interval = math.MaxUint64
+	}
+	if err := worker.commitTransactions(work, txs, interval); err != nil {
 		return nil, err
 	}
 
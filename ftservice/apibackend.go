@@ -18,14 +18,17 @@ package ftservice
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/blockchain"
 	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/feemanager"
 	"github.com/fractalplatform/fractal/ftservice/gasprice"
+	"github.com/fractalplatform/fractal/keystore"
 	"github.com/fractalplatform/fractal/p2p/enode"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor"
@@ -69,6 +72,16 @@ func (b *APIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.
 	return logs, nil
 }
 
+// KeyStore returns the keystore of the node.
+func (b *APIBackend) KeyStore() *keystore.KeyStore {
+	return b.ftservice.keyStore
+}
+
+// BloomStatus returns the bloom bits section size and the number of indexed sections.
+func (b *APIBackend) BloomStatus() (uint64, uint64) {
+	return b.ftservice.bloomIndexer.Status()
+}
+
 func (b *APIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	return b.ftservice.txPool.AddLocal(signedTx)
 }
@@ -86,11 +99,20 @@ func (b *APIBackend) CurrentBlock() *types.Block {
 }
 
 func (b *APIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.ftservice.blockchain.GetBlockByHash(hash), nil
+	block := b.ftservice.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		if number := rawdb.ReadHeaderNumber(b.ftservice.chainDb, hash); number != nil {
+			return nil, b.historyErr(*number)
+		}
+	}
+	return block, nil
 }
 
 func (b *APIBackend) GetReceipts(ctx context.Context, hash common.Hash) ([]*types.Receipt, error) {
 	if number := rawdb.ReadHeaderNumber(b.ftservice.chainDb, hash); number != nil {
+		if err := b.historyErr(*number); err != nil {
+			return nil, err
+		}
 		return rawdb.ReadReceipts(b.ftservice.chainDb, hash, *number), nil
 	}
 	return nil, nil
@@ -98,11 +120,23 @@ func (b *APIBackend) GetReceipts(ctx context.Context, hash common.Hash) ([]*type
 
 func (b *APIBackend) GetDetailTxsLog(ctx context.Context, hash common.Hash) ([]*types.DetailTx, error) {
 	if number := rawdb.ReadHeaderNumber(b.ftservice.chainDb, hash); number != nil {
+		if err := b.historyErr(*number); err != nil {
+			return nil, err
+		}
 		return rawdb.ReadDetailTxs(b.ftservice.chainDb, hash, *number), nil
 	}
 	return nil, nil
 }
 
+// historyErr returns an error if the body, receipts and logs of the block
+// were removed by history pruning.
+func (b *APIBackend) historyErr(number uint64) error {
+	if b.ftservice.blockchain.HistoryPruned(number) {
+		return fmt.Errorf("block #%d: %v", number, blockchain.ErrHistoryPruned)
+	}
+	return nil
+}
+
 func (b *APIBackend) GetBlockDetailLog(ctx context.Context, blockNr rpc.BlockNumber) *types.BlockAndResult {
 	hash := rawdb.ReadCanonicalHash(b.ftservice.chainDb, uint64(blockNr))
 	if hash == (common.Hash{}) {
@@ -116,6 +150,11 @@ func (b *APIBackend) GetBlockDetailLog(ctx context.Context, blockNr rpc.BlockNum
 	}
 }
 
+// AccountIndexEnabled returns whether the account name -> action index is maintained.
+func (b *APIBackend) AccountIndexEnabled() bool {
+	return b.ftservice.blockchain.AccountIndexEnabled()
+}
+
 func (b *APIBackend) GetTxsByFilter(ctx context.Context, filterFn func(common.Name) bool, blockNr, lookbackNum uint64) []common.Hash {
 	var lastnum int64
 	if lookbackNum > blockNr {
@@ -196,6 +235,11 @@ func (b *APIBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.ftservice.blockchain.GetTdByHash(blockHash)
 }
 
+// IrreversibleNumber returns the irreversible number of the chain.
+func (b *APIBackend) IrreversibleNumber() uint64 {
+	return b.ftservice.blockchain.IrreversibleNumber()
+}
+
 func (b *APIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ftservice.blockchain.CurrentBlock().Header(), nil
@@ -207,7 +251,11 @@ func (b *APIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber)
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ftservice.blockchain.CurrentBlock(), nil
 	}
-	return b.ftservice.blockchain.GetBlockByNumber(uint64(blockNr)), nil
+	block := b.ftservice.blockchain.GetBlockByNumber(uint64(blockNr))
+	if block == nil {
+		return nil, b.historyErr(uint64(blockNr))
+	}
+	return block, nil
 }
 
 func (b *APIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
@@ -232,6 +280,36 @@ func (b *APIBackend) GetEVM(ctx context.Context, account *accountmanager.Account
 	return vm.NewEVM(context, account, state, b.ChainConfig(), vmCfg), vmError, nil
 }
 
+// StateAt returns the state at the given root.
+func (b *APIBackend) StateAt(root common.Hash) (*state.StateDB, error) {
+	return b.ftservice.blockchain.StateAt(root)
+}
+
+// StateAtBlock returns the state a block's transactions are executed on, that
+// is the parent state prepared by the consensus engine, together with the
+// prepared header.
+func (b *APIBackend) StateAtBlock(ctx context.Context, block *types.Block) (*state.StateDB, *types.Header, error) {
+	bc := b.ftservice.blockchain
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, nil, fmt.Errorf("parent block %v not found", block.ParentHash().Hex())
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	header := block.Header()
+	if err := b.ftservice.engine.Prepare(bc, header, block.Transactions(), nil, statedb); err != nil {
+		return nil, nil, err
+	}
+	return statedb, header, nil
+}
+
+// ApplyTransaction applies a transaction on the given state with the block processor.
+func (b *APIBackend) ApplyTransaction(gp *common.GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, vmCfg vm.Config) (*types.Receipt, uint64, error) {
+	return b.ftservice.blockchain.Processor().ApplyTransaction(nil, gp, statedb, header, tx, usedGas, vmCfg)
+}
+
 func (b *APIBackend) SetGasPrice(gasPrice *big.Int) bool {
 	b.ftservice.SetGasPrice(gasPrice)
 	return true
@@ -245,7 +323,7 @@ func (b *APIBackend) GetAccountManager() (*accountmanager.AccountManager, error)
 	return accountmanager.NewAccountManager(sdb)
 }
 
-//GetFeeManager get fee manager
+// GetFeeManager get fee manager
 func (b *APIBackend) GetFeeManager() (*feemanager.FeeManager, error) {
 	sdb, err := b.ftservice.blockchain.State()
 	if err != nil {
@@ -260,7 +338,7 @@ func (b *APIBackend) GetFeeManager() (*feemanager.FeeManager, error) {
 	return fm, nil
 }
 
-//GetFeeManagerByTime get fee manager
+// GetFeeManagerByTime get fee manager
 func (b *APIBackend) GetFeeManagerByTime(time uint64) (*feemanager.FeeManager, error) {
 	sdb, err := b.ftservice.blockchain.State()
 	if err != nil {
@@ -376,7 +454,7 @@ func (b *APIBackend) Engine() consensus.IEngine {
 	return b.ftservice.engine
 }
 
-//SetStatePruning set state pruning
+// SetStatePruning set state pruning
 func (b *APIBackend) SetStatePruning(enable bool) (bool, uint64) {
 	return b.ftservice.blockchain.StatePruning(enable)
 }
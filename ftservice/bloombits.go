@@ -0,0 +1,165 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ftservice
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/bitutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/blockchain"
+	"github.com/fractalplatform/fractal/blockchain/bloombits"
+	"github.com/fractalplatform/fractal/event"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
+)
+
+const (
+	// bloomBitsBlocks is the number of blocks a single bloom bit section vector
+	// contains.
+	bloomBitsBlocks uint64 = 4096
+
+	// bloomThrottling is the time to wait between processing two consecutive
+	// index sections.
+	bloomThrottling = 100 * time.Millisecond
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+)
+
+// BloomIndexer rotates the header blooms of every section of bloomBitsBlocks
+// irreversible blocks into bloom bits, so that log filters can skip whole
+// sections. Only irreversible blocks are indexed, a section never has to be
+// rolled back.
+type BloomIndexer struct {
+	db       fdb.Database
+	chain    *blockchain.BlockChain
+	size     uint64
+	sections uint64 // number of processed sections, accessed atomically
+
+	chainHeadCh  chan *event.Event
+	chainHeadSub event.Subscription
+	quit         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewBloomIndexer returns a bloom indexer for the sections of size blocks.
+func NewBloomIndexer(db fdb.Database, chain *blockchain.BlockChain, size uint64) *BloomIndexer {
+	return &BloomIndexer{
+		db:       db,
+		chain:    chain,
+		size:     size,
+		sections: rawdb.ReadBloomBitsSections(db),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start starts indexing the sections in the background.
+func (b *BloomIndexer) Start() {
+	b.chainHeadCh = make(chan *event.Event, chainHeadChanSize)
+	b.chainHeadSub = event.Subscribe(nil, b.chainHeadCh, event.ChainHeadEv, &types.Block{})
+	b.wg.Add(1)
+	go b.loop()
+}
+
+// Stop stops the indexer.
+func (b *BloomIndexer) Stop() {
+	b.chainHeadSub.Unsubscribe()
+	close(b.quit)
+	b.wg.Wait()
+}
+
+// Status returns the section size and the number of processed sections.
+func (b *BloomIndexer) Status() (uint64, uint64) {
+	return b.size, atomic.LoadUint64(&b.sections)
+}
+
+func (b *BloomIndexer) loop() {
+	defer b.wg.Done()
+
+	b.process()
+	for {
+		select {
+		case <-b.chainHeadCh:
+			b.process()
+		case <-b.chainHeadSub.Err():
+			return
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// process indexes every section whose blocks are all irreversible.
+func (b *BloomIndexer) process() {
+	for {
+		sections := atomic.LoadUint64(&b.sections)
+		if (b.chain.IrreversibleNumber()+1)/b.size <= sections {
+			return
+		}
+		if err := b.processSection(sections); err != nil {
+			log.Error("Failed to index bloom bits section", "section", sections, "err", err)
+			return
+		}
+		atomic.StoreUint64(&b.sections, sections+1)
+
+		select {
+		case <-b.quit:
+			return
+		case <-time.After(bloomThrottling):
+		}
+	}
+}
+
+func (b *BloomIndexer) processSection(section uint64) error {
+	gen, err := bloombits.NewGenerator(uint(b.size))
+	if err != nil {
+		return err
+	}
+	var head *types.Header
+	for i := uint64(0); i < b.size; i++ {
+		number := section*b.size + i
+		header := b.chain.GetHeaderByNumber(number)
+		if header == nil {
+			// the chain may be started from a specified block number
+			header = &types.Header{}
+		}
+		if err := gen.AddBloom(uint(i), header.Bloom); err != nil {
+			return err
+		}
+		head = header
+	}
+
+	batch := b.db.NewBatch()
+	for i := 0; i < types.BloomBitLength; i++ {
+		bits, err := gen.Bitset(uint(i))
+		if err != nil {
+			return err
+		}
+		rawdb.WriteBloomBits(batch, uint(i), section, head.Hash(), bitutil.CompressBytes(bits))
+	}
+	rawdb.WriteBloomBitsSectionHead(batch, section, head.Hash())
+	rawdb.WriteBloomBitsSections(batch, section+1)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Debug("Indexed bloom bits section", "section", section, "head", head.Hash())
+	return nil
+}
@@ -46,9 +46,28 @@ type Config struct {
 
 	StatePruning    bool `mapstructure:"statepruning"`
 	ContractLogFlag bool `mapstructure:"contractlog"`
+	AccountIndex    bool `mapstructure:"accountindex"`
+	FastSync        bool `mapstructure:"fastsync"`
+
+	// FastSyncCheckpoint is the hash of a trusted block the chain downloaded
+	// by fast sync must hold.
+	FastSyncCheckpoint string `mapstructure:"fastsynccheckpoint"`
+
+	// HistoryRetention is the number of blocks before the irreversible block
+	// whose body, receipts and logs are kept, 0 keeps the whole history.
+	HistoryRetention uint64 `mapstructure:"historyretention"`
+	PruneTxLookup    bool   `mapstructure:"prunetxlookup"`
+
+	// ParallelTxs is the number of transactions of a block executed
+	// speculatively at once, below 2 they are executed in sequence.
+	ParallelTxs int `mapstructure:"paralleltxs"`
 
 	BadHashes   []string `mapstructure:"badhashes"`
 	StartNumber uint64   `mapstructure:"startnumber"`
+
+	// Dev runs a single node chain that seals a block as soon as
+	// transactions arrive, with the miner keys unlocked in the keystore.
+	Dev bool `mapstructure:"dev"`
 }
 
 // MinerConfig miner config
@@ -58,4 +77,5 @@ type MinerConfig struct {
 	Name        string   `mapstructure:"name"`
 	PrivateKeys []string `mapstructure:"private"`
 	ExtraData   string   `mapstructure:"extra"`
+	Signer      string   `mapstructure:"signer"`
 }
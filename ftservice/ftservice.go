@@ -17,14 +17,18 @@
 package ftservice
 
 import (
+	"encoding/hex"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/fractalplatform/fractal/blockchain"
+	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/consensus/dpos"
 	"github.com/fractalplatform/fractal/consensus/miner"
+	"github.com/fractalplatform/fractal/crypto"
 	"github.com/fractalplatform/fractal/ftservice/gasprice"
+	"github.com/fractalplatform/fractal/keystore"
 	"github.com/fractalplatform/fractal/node"
 	"github.com/fractalplatform/fractal/p2p"
 	adaptor "github.com/fractalplatform/fractal/p2p/protoadaptor"
@@ -48,6 +52,8 @@ type FtService struct {
 	engine       consensus.IEngine
 	miner        *miner.Miner
 	p2pServer    *adaptor.ProtoAdaptor
+	bloomIndexer *BloomIndexer
+	keyStore     *keystore.KeyStore
 	APIBackend   *APIBackend
 }
 
@@ -58,6 +64,9 @@ func New(ctx *node.ServiceContext, config *Config) (*FtService, error) {
 		return nil, err
 	}
 
+	if config.Dev && config.Genesis == nil {
+		config.Genesis = blockchain.DefaultDevGenesis()
+	}
 	chainCfg, dposCfg, _, err := blockchain.SetupGenesisBlock(chainDb, config.Genesis)
 	if err != nil {
 		return nil, err
@@ -82,6 +91,25 @@ func New(ctx *node.ServiceContext, config *Config) (*FtService, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.AccountIndex {
+		if err := ftservice.blockchain.EnableAccountIndex(); err != nil {
+			return nil, err
+		}
+	}
+	ftservice.blockchain.SetFastSync(config.FastSync, common.HexToHash(config.FastSyncCheckpoint))
+	ftservice.blockchain.SetHistoryRetention(config.HistoryRetention, config.PruneTxLookup)
+	ftservice.bloomIndexer = NewBloomIndexer(chainDb, ftservice.blockchain, bloomBitsBlocks)
+
+	ftservice.keyStore, err = keystore.NewKeyStore(ctx.ResolvePath("keystore"), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return nil, err
+	}
+	if config.Dev {
+		if err := setupDevKeys(ftservice.keyStore, config.Miner); err != nil {
+			return nil, err
+		}
+	}
+
 	// used to generate MagicNetID
 	ftservice.p2pServer.GenesisHash = ftservice.blockchain.Genesis().Hash()
 
@@ -111,6 +139,7 @@ func New(ctx *node.ServiceContext, config *Config) (*FtService, error) {
 
 	validator := processor.NewBlockValidator(bcc, ftservice.engine)
 	txProcessor := processor.NewStateProcessor(bcc, ftservice.engine)
+	txProcessor.SetParallel(config.ParallelTxs)
 
 	ftservice.blockchain.SetValidator(validator)
 	ftservice.blockchain.SetProcessor(txProcessor)
@@ -119,9 +148,16 @@ func New(ctx *node.ServiceContext, config *Config) (*FtService, error) {
 	ftservice.miner = miner.NewMiner(bcc)
 	ftservice.miner.SetDelayDuration(config.Miner.Delay)
 	ftservice.miner.SetCoinbase(config.Miner.Name, config.Miner.PrivateKeys)
+	if config.Miner.Signer != "" {
+		if err := ftservice.miner.SetSigner(config.Miner.Name, config.Miner.Signer); err != nil {
+			return nil, err
+		}
+	}
+	ftservice.miner.SetKeyStore(ftservice.keyStore)
 	ftservice.miner.SetExtra([]byte(config.Miner.ExtraData))
-	if config.Miner.Start {
-		ftservice.miner.Start(false)
+	ftservice.miner.SetDevMode(config.Dev)
+	if config.Miner.Start || config.Dev {
+		ftservice.miner.Start(config.Dev)
 	}
 
 	ftservice.APIBackend = &APIBackend{ftservice: ftservice}
@@ -130,6 +166,31 @@ func New(ctx *node.ServiceContext, config *Config) (*FtService, error) {
 	return ftservice, nil
 }
 
+// setupDevKeys imports the miner keys into the keystore of the datadir with
+// an empty passphrase and unlocks them, so that the pre-funded system account
+// can sign transactions right away.
+func setupDevKeys(ks *keystore.KeyStore, cfg *MinerConfig) error {
+	for _, privKey := range cfg.PrivateKeys {
+		bts, err := hex.DecodeString(privKey)
+		if err != nil {
+			return err
+		}
+		priv, err := crypto.ToECDSA(bts)
+		if err != nil {
+			return err
+		}
+		if _, err := ks.ImportECDSA(priv, common.Name(cfg.Name), ""); err != nil && err != keystore.ErrExists {
+			return err
+		}
+		pubKey := common.BytesToPubKey(crypto.FromECDSAPub(&priv.PublicKey))
+		if err := ks.Unlock(pubKey, ""); err != nil {
+			return err
+		}
+		log.Info("Dev key unlocked", "name", cfg.Name, "pubKey", pubKey.String())
+	}
+	return nil
+}
+
 // APIs return the collection of RPC services the ftservice package offers.
 func (fs *FtService) APIs() []rpc.API {
 	return rpcapi.GetAPIs(fs.APIBackend)
@@ -138,11 +199,13 @@ func (fs *FtService) APIs() []rpc.API {
 // Start implements node.Service, starting all internal goroutines.
 func (fs *FtService) Start() error {
 	log.Info("start fractal service...")
+	fs.bloomIndexer.Start()
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutine
 func (fs *FtService) Stop() error {
+	fs.bloomIndexer.Stop()
 	fs.blockchain.Stop()
 	fs.txPool.Stop()
 	fs.chainDb.Close()
@@ -176,4 +239,5 @@ func (s *FtService) BlockChain() *blockchain.BlockChain { return s.blockchain }
 func (s *FtService) TxPool() *txpool.TxPool             { return s.txPool }
 func (s *FtService) Engine() consensus.IEngine          { return s.engine }
 func (s *FtService) ChainDb() fdb.Database              { return s.chainDb }
+func (s *FtService) KeyStore() *keystore.KeyStore       { return s.keyStore }
 func (s *FtService) Protocols() []p2p.Protocol          { return nil }
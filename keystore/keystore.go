@@ -0,0 +1,390 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package keystore implements encrypted storage of secp256k1 private keys.
+//
+// Keys are stored as encrypted JSON files in a directory, indexed by their
+// public key and by the name of the account they are bound to.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+)
+
+var (
+	// ErrLocked is returned if a key is requested before it is unlocked.
+	ErrLocked = errors.New("key is locked")
+	// ErrNoMatch is returned if no key of the keystore matches the public key.
+	ErrNoMatch = errors.New("no key for given public key")
+	// ErrExists is returned when importing a key already in the keystore.
+	ErrExists = errors.New("key already exists")
+)
+
+// Account is a key of the keystore.
+type Account struct {
+	Name      common.Name   `json:"name"`
+	PublicKey common.PubKey `json:"publicKey"`
+	Path      string        `json:"path"`
+}
+
+type unlocked struct {
+	*Key
+	abort chan struct{}
+}
+
+// KeyStore manages the key files of a directory.
+type KeyStore struct {
+	dir     string
+	scryptN int
+	scryptP int
+
+	mu       sync.RWMutex
+	accounts map[common.PubKey]*Account
+	unlocked map[common.PubKey]*unlocked
+}
+
+// NewKeyStore creates a keystore for the given directory and loads the
+// accounts of the key files found in it.
+func NewKeyStore(dir string, scryptN, scryptP int) (*KeyStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ks := &KeyStore{
+		dir:      dir,
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+		accounts: make(map[common.PubKey]*Account),
+		unlocked: make(map[common.PubKey]*unlocked),
+	}
+	if err := ks.scan(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// scan loads the accounts of the key files of the directory, without
+// decrypting them.
+func (ks *KeyStore) scan() error {
+	files, err := ioutil.ReadDir(ks.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		// Skip any non-key files from the folder
+		if fi.IsDir() || fi.Name()[0] == '.' {
+			continue
+		}
+		path := filepath.Join(ks.dir, fi.Name())
+		account, err := readAccount(path)
+		if err != nil {
+			log.Debug("Failed to decode keystore key", "path", path, "err", err)
+			continue
+		}
+		ks.accounts[account.PublicKey] = account
+	}
+	return nil
+}
+
+func readAccount(path string) (*Account, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k := new(encryptedKeyJSON)
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+	if k.PublicKey == (common.PubKey{}) {
+		return nil, errors.New("missing public key")
+	}
+	return &Account{Name: k.Name, PublicKey: k.PublicKey, Path: path}, nil
+}
+
+// Dir returns the directory of the key files.
+func (ks *KeyStore) Dir() string {
+	return ks.dir
+}
+
+// Accounts returns all the accounts of the keystore, sorted by path.
+func (ks *KeyStore) Accounts() []Account {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	accounts := make([]Account, 0, len(ks.accounts))
+	for _, account := range ks.accounts {
+		accounts = append(accounts, *account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Path < accounts[j].Path })
+	return accounts
+}
+
+// Find returns the account of the public key.
+func (ks *KeyStore) Find(pubKey common.PubKey) (Account, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	account, ok := ks.accounts[pubKey]
+	if !ok {
+		return Account{}, ErrNoMatch
+	}
+	return *account, nil
+}
+
+// KeysByName returns the public keys bound to the account name.
+func (ks *KeyStore) KeysByName(name common.Name) []common.PubKey {
+	var pubKeys []common.PubKey
+	for _, account := range ks.Accounts() {
+		if account.Name == name {
+			pubKeys = append(pubKeys, account.PublicKey)
+		}
+	}
+	return pubKeys
+}
+
+// NewAccount generates a new key bound to the account name and stores it
+// encrypted with the passphrase.
+func (ks *KeyStore) NewAccount(name common.Name, passphrase string) (Account, error) {
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		return Account{}, err
+	}
+	return ks.storeKey(newKey(name, priv), passphrase)
+}
+
+// ImportECDSA stores the given key encrypted with the passphrase.
+func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, name common.Name, passphrase string) (Account, error) {
+	key := newKey(name, priv)
+	if _, err := ks.Find(key.PublicKey); err == nil {
+		return Account{}, ErrExists
+	}
+	return ks.storeKey(key, passphrase)
+}
+
+// Export decrypts the key of the public key with the passphrase.
+func (ks *KeyStore) Export(pubKey common.PubKey, passphrase string) (*ecdsa.PrivateKey, error) {
+	_, key, err := ks.getDecryptedKey(pubKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+// SetName binds the key of the public key to the account name, the
+// passphrase is needed to rewrite the key file.
+func (ks *KeyStore) SetName(pubKey common.PubKey, name common.Name, passphrase string) error {
+	account, key, err := ks.getDecryptedKey(pubKey, passphrase)
+	if err != nil {
+		return err
+	}
+	key.Name = name
+	if err := ks.writeKey(account.Path, key, passphrase); err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	ks.accounts[pubKey].Name = name
+	if u, ok := ks.unlocked[pubKey]; ok {
+		u.Name = name
+	}
+	ks.mu.Unlock()
+	return nil
+}
+
+// Delete removes the key file of the public key.
+func (ks *KeyStore) Delete(pubKey common.PubKey, passphrase string) error {
+	account, _, err := ks.getDecryptedKey(pubKey, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(account.Path); err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	delete(ks.accounts, pubKey)
+	ks.expire(pubKey)
+	ks.mu.Unlock()
+	return nil
+}
+
+// Unlock unlocks the key indefinitely.
+func (ks *KeyStore) Unlock(pubKey common.PubKey, passphrase string) error {
+	return ks.TimedUnlock(pubKey, passphrase, 0)
+}
+
+// Lock removes the private key from memory.
+func (ks *KeyStore) Lock(pubKey common.PubKey) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.accounts[pubKey]; !ok {
+		return ErrNoMatch
+	}
+	ks.expire(pubKey)
+	return nil
+}
+
+// TimedUnlock unlocks the key with the passphrase. The key stays unlocked
+// for the duration of timeout. A timeout of 0 unlocks the key until the
+// program exits or the key is locked.
+//
+// If the key is already unlocked, TimedUnlock replaces its timeout.
+func (ks *KeyStore) TimedUnlock(pubKey common.PubKey, passphrase string, timeout time.Duration) error {
+	_, key, err := ks.getDecryptedKey(pubKey, passphrase)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.expire(pubKey)
+	u := &unlocked{Key: key}
+	if timeout > 0 {
+		u.abort = make(chan struct{})
+		go ks.expireAfter(pubKey, u, timeout)
+	}
+	ks.unlocked[pubKey] = u
+	return nil
+}
+
+// expire locks the key, the caller must hold the lock.
+func (ks *KeyStore) expire(pubKey common.PubKey) {
+	if u, ok := ks.unlocked[pubKey]; ok {
+		if u.abort != nil {
+			close(u.abort)
+		}
+		delete(ks.unlocked, pubKey)
+	}
+}
+
+func (ks *KeyStore) expireAfter(pubKey common.PubKey, u *unlocked, timeout time.Duration) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-u.abort:
+		// just quit
+	case <-t.C:
+		ks.mu.Lock()
+		// only drop if it's still the same key instance that expireAfter was
+		// launched with. we can check that using pointer equality because the
+		// map stores a new pointer every time the key is unlocked.
+		if ks.unlocked[pubKey] == u {
+			delete(ks.unlocked, pubKey)
+		}
+		ks.mu.Unlock()
+	}
+}
+
+// Unlocked returns whether the key of the public key is unlocked.
+func (ks *KeyStore) Unlocked(pubKey common.PubKey) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	_, ok := ks.unlocked[pubKey]
+	return ok
+}
+
+// UnlockedKey returns the private key of an unlocked key.
+func (ks *KeyStore) UnlockedKey(pubKey common.PubKey) (*ecdsa.PrivateKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if _, ok := ks.accounts[pubKey]; !ok {
+		return nil, ErrNoMatch
+	}
+	u, ok := ks.unlocked[pubKey]
+	if !ok {
+		return nil, ErrLocked
+	}
+	return u.PrivateKey, nil
+}
+
+// SignHash signs the hash with the unlocked key of the public key.
+func (ks *KeyStore) SignHash(pubKey common.PubKey, hash []byte) ([]byte, error) {
+	priv, err := ks.UnlockedKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash, priv)
+}
+
+func (ks *KeyStore) getDecryptedKey(pubKey common.PubKey, passphrase string) (Account, *Key, error) {
+	account, err := ks.Find(pubKey)
+	if err != nil {
+		return account, nil, err
+	}
+	data, err := ioutil.ReadFile(account.Path)
+	if err != nil {
+		return account, nil, err
+	}
+	key, err := DecryptKey(data, passphrase)
+	if err != nil {
+		return account, nil, err
+	}
+	if key.PublicKey != pubKey {
+		return account, nil, fmt.Errorf("key content mismatch: have public key %v, want %v", key.PublicKey, pubKey)
+	}
+	return account, key, nil
+}
+
+func (ks *KeyStore) storeKey(key *Key, passphrase string) (Account, error) {
+	path := filepath.Join(ks.dir, keyFileName(key.PublicKey))
+	if err := ks.writeKey(path, key, passphrase); err != nil {
+		return Account{}, err
+	}
+	account := &Account{Name: key.Name, PublicKey: key.PublicKey, Path: path}
+	ks.mu.Lock()
+	ks.accounts[key.PublicKey] = account
+	ks.mu.Unlock()
+	return *account, nil
+}
+
+// writeKey encrypts the key and writes it atomically to path.
+func (ks *KeyStore) writeKey(path string, key *Key, passphrase string) error {
+	content, err := EncryptKey(key, passphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return err
+	}
+	// Create the keystore directory with appropriate permissions
+	// in case it is not present yet.
+	const dirPerm = 0700
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return err
+	}
+	// Atomic write: create a temporary hidden file first
+	// then move it into place. TempFile assigns mode 0600.
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), path)
+}
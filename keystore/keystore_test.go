@@ -0,0 +1,101 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func tmpKeyStore(t *testing.T) (string, *KeyStore) {
+	dir, err := ioutil.TempDir("", "fractal-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeyStore(dir, LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, ks
+}
+
+func TestKeyStore(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	account, err := ks.NewAccount("testaccount", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, common.Name("testaccount"), account.Name)
+	assert.Equal(t, []common.PubKey{account.PublicKey}, ks.KeysByName("testaccount"))
+
+	// the accounts are loaded back from the directory
+	reopened, err := NewKeyStore(dir, LightScryptN, LightScryptP)
+	assert.NoError(t, err)
+	assert.Equal(t, ks.Accounts(), reopened.Accounts())
+
+	_, err = ks.Export(account.PublicKey, "bar")
+	assert.Equal(t, ErrDecrypt, err)
+	priv, err := ks.Export(account.PublicKey, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, account.PublicKey, common.BytesToPubKey(crypto.FromECDSAPub(&priv.PublicKey)))
+
+	_, err = ks.ImportECDSA(priv, "other", "foo")
+	assert.Equal(t, ErrExists, err)
+
+	assert.NoError(t, ks.SetName(account.PublicKey, "renamed", "foo"))
+	assert.Equal(t, 0, len(ks.KeysByName("testaccount")))
+	assert.Equal(t, 1, len(ks.KeysByName("renamed")))
+
+	assert.NoError(t, ks.Delete(account.PublicKey, "foo"))
+	assert.Equal(t, 0, len(ks.Accounts()))
+}
+
+func TestTimedUnlock(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	priv, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(priv, "testaccount", "foo")
+	assert.NoError(t, err)
+
+	_, err = ks.SignHash(account.PublicKey, make([]byte, 32))
+	assert.Equal(t, ErrLocked, err)
+	assert.Equal(t, ErrDecrypt, ks.TimedUnlock(account.PublicKey, "bar", 100*time.Millisecond))
+
+	assert.NoError(t, ks.TimedUnlock(account.PublicKey, "foo", 100*time.Millisecond))
+	key, err := ks.UnlockedKey(account.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, priv.D, key.D)
+	_, err = ks.SignHash(account.PublicKey, make([]byte, 32))
+	assert.NoError(t, err)
+
+	// the key is locked again once the timeout expired
+	time.Sleep(250 * time.Millisecond)
+	_, err = ks.UnlockedKey(account.PublicKey)
+	assert.Equal(t, ErrLocked, err)
+
+	assert.NoError(t, ks.Unlock(account.PublicKey, "foo"))
+	assert.True(t, ks.Unlocked(account.PublicKey))
+	assert.NoError(t, ks.Lock(account.PublicKey))
+	assert.False(t, ks.Unlocked(account.PublicKey))
+}
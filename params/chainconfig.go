@@ -79,6 +79,9 @@ type DposConfig struct {
 	FreezeEpochSize               uint64   `json:"freezeEpochSize"`
 	ExtraBlockReward              *big.Int `json:"extraBlockReward"`
 	BlockReward                   *big.Int `json:"blockReward"`
+	JailMissPercent               uint64   `json:"jailMissPercent,omitempty"` // missed slots share that jails a producer, 0 disables
+	JailEpochSize                 uint64   `json:"jailEpochSize,omitempty"`   // epochs a jailed producer has to wait
+	SlashPercent                  uint64   `json:"slashPercent,omitempty"`    // share of the stake slashed on jailing
 }
 
 var DefaultChainconfig = &ChainConfig{
@@ -150,7 +153,15 @@ const (
 	ForkID1 = uint64(1)
 	//ForkID2 dpos
 	ForkID2 = uint64(2)
+	//ForkID3 account deletion, candidate jailing and slashing, UnjailCandidate,
+	//UnvoteCandidate and ChangeVote, candidate commission, reward sharing with
+	//voters and ClaimReward, SetSponsorship and payer transactions, atomic
+	//transactions
+	ForkID3 = uint64(3)
+	//ForkID4 transaction expiration and reference block, asset transfer controls, vesting, account recovery
+	ForkID4 = uint64(4)
 
-	// NextForkID is the id of next fork
-	NextForkID uint64 = 2
+	// NextForkID is the id of next fork. The fork controller jumps straight
+	// to the voted id, so it is only raised to ForkID4 once ForkID3 is active.
+	NextForkID uint64 = 3
 )
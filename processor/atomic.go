@@ -0,0 +1,68 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package processor
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/types"
+)
+
+var errAtomicReverted = errors.New("reverted by atomic transaction")
+
+// appliedAction is an action of an atomic transaction applied to the state,
+// it is kept to charge its gas again once the transaction is reverted.
+type appliedAction struct {
+	action     *types.Action
+	gasUsed    uint64
+	founderGas map[vm.DistributeKey]vm.DistributeGas
+}
+
+// revertAtomic reverts the state changes of an atomic transaction one of
+// whose actions failed. The gas of the work done is charged again to the
+// payers and the nonces of the senders are increased as if the actions had
+// been applied, the transaction is invalid if that can't be done.
+func revertAtomic(statedb *state.StateDB, accountDB *accountmanager.AccountManager, snapshot int,
+	tx *types.Transaction, applied []*appliedAction, assetID uint64, config *params.ChainConfig) error {
+	statedb.RevertToSnapshot(snapshot)
+	for _, a := range applied {
+		sender := a.action.Sender()
+		nonce, err := accountDB.GetNonce(sender)
+		if err != nil {
+			return err
+		}
+		if err := accountDB.SetNonce(sender, nonce+1); err != nil {
+			return err
+		}
+		fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(a.gasUsed))
+		if fee.Sign() > 0 {
+			if err := accountDB.TransferAsset(tx.GasPayer(a.action), common.Name(config.FeeName), assetID, fee); err != nil {
+				return err
+			}
+		}
+		if err := recordFee(statedb, accountDB, a.founderGas, tx.GasPrice(), assetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
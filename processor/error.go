@@ -56,6 +56,19 @@ var (
 	errParentBlock = errors.New("parent block not exist")
 	//
 	ErrActionInvalid = errors.New("action field invalid")
+
+	// ErrPayerNotSupported is returned if a transaction sponsored by a payer is
+	// applied before the fork introducing the sponsorship.
+	ErrPayerNotSupported = errors.New("transaction payer not supported")
+
+	// ErrAtomicNotSupported is returned if an atomic transaction is applied
+	// before the fork introducing the all or nothing execution.
+	ErrAtomicNotSupported = errors.New("atomic transaction not supported")
+
+	// ErrBindingNotSupported is returned if a transaction bound to an
+	// expiration or a reference block is applied before the fork introducing
+	// the binding.
+	ErrBindingNotSupported = errors.New("transaction binding not supported")
 )
 
 // GenesisMismatchError is raised when trying to overwrite an existing
@@ -30,6 +30,10 @@ type Validator interface {
 	// ValidateHeader validates the given header's content.
 	ValidateHeader(header *types.Header, seal bool) error
 
+	// ValidateProducer validates the seal of the given header against the
+	// producers of the given state.
+	ValidateProducer(header *types.Header, state *state.StateDB) error
+
 	// ValidateBody validates the given block's content.
 	ValidateBody(block *types.Block) error
 
@@ -0,0 +1,201 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package processor
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// feeCharge is the gas fee of an action executed speculatively, the payer is
+// charged by the execution and the fee account is credited when the execution
+// is committed, so that transactions don't conflict on it.
+type feeCharge struct {
+	payer      common.Name
+	value      *big.Int
+	founderGas map[vm.DistributeKey]vm.DistributeGas
+}
+
+// speculation is the result of the execution of a transaction on a copy of
+// the state of the beginning of the block.
+type speculation struct {
+	state   *state.StateDB
+	receipt *types.Receipt
+	fees    []*feeCharge
+	err     error
+}
+
+// applyParallel applies the transactions of the block executing them
+// speculatively at once, then commits them in order. A transaction whose
+// execution read state changed by the transactions before it is executed
+// again in order, so the result is the one of the sequential execution.
+func (p *StateProcessor) applyParallel(block *types.Block, gp *common.GasPool, statedb *state.StateDB, usedGas *uint64, cfg vm.Config) ([]*types.Receipt, []*types.Log, error) {
+	var (
+		receipts []*types.Receipt
+		allLogs  []*types.Log
+		header   = block.Header()
+		specs    = p.speculate(block, header, statedb, cfg)
+	)
+	for i, tx := range block.Transactions() {
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		receipt, ok := p.commit(statedb, tx, specs[i], gp, usedGas)
+		if !ok {
+			var err error
+			receipt, _, err = p.ApplyTransaction(nil, gp, statedb, header, tx, usedGas, cfg)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	return receipts, allLogs, nil
+}
+
+// speculate executes the transactions of the block on copies of the state,
+// by p.parallel at once. The speculation of a transaction not speculated is
+// nil.
+func (p *StateProcessor) speculate(block *types.Block, header *types.Header, statedb *state.StateDB, cfg vm.Config) []*speculation {
+	var (
+		txs   = block.Transactions()
+		specs = make([]*speculation, len(txs))
+		seen  = make(map[common.Name]bool)
+		jobs  = make(chan int, len(txs))
+		wg    sync.WaitGroup
+	)
+	for i, tx := range txs {
+		if p.speculable(tx, seen) {
+			jobs <- i
+		}
+	}
+	close(jobs)
+
+	for n := 0; n < p.parallel; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				spec := &speculation{state: statedb.Speculate()}
+				spec.state.Prepare(txs[i].Hash(), block.Hash(), i)
+				gp := new(common.GasPool).AddGas(block.GasLimit())
+				spec.receipt, _, spec.err = p.applyTransaction(nil, gp, spec.state, header, txs[i], new(uint64), cfg, &spec.fees)
+				specs[i] = spec
+			}
+		}()
+	}
+	wg.Wait()
+	return specs
+}
+
+// speculable reports whether the transaction is executed speculatively, only
+// the transactions made of transfers whose accounts aren't used by an earlier
+// transaction of the block are, the others would likely conflict.
+func (p *StateProcessor) speculable(tx *types.Transaction, seen map[common.Name]bool) bool {
+	ok := !tx.Atomic()
+	feeName := common.Name(p.bc.Config().FeeName)
+	names := make(map[common.Name]bool)
+	for _, action := range tx.GetActions() {
+		payer := tx.GasPayer(action)
+		if action.Type() != types.Transfer || payer == feeName {
+			ok = false
+		}
+		names[action.Sender()] = true
+		names[action.Recipient()] = true
+		names[payer] = true
+	}
+	for name := range names {
+		if seen[name] {
+			ok = false
+		}
+		seen[name] = true
+	}
+	return ok
+}
+
+// commit applies the speculation of the transaction to the state and settles
+// its fees if the execution read the state the sequential execution would
+// have, it returns false if the transaction must be executed again.
+func (p *StateProcessor) commit(statedb *state.StateDB, tx *types.Transaction, spec *speculation, gp *common.GasPool, usedGas *uint64) (*types.Receipt, bool) {
+	if spec == nil || spec.err != nil || spec.state.Error() != nil {
+		return nil, false
+	}
+	if !statedb.Validate(spec.state.Access()) {
+		return nil, false
+	}
+	// the block gas pool must hold the gas of every action when it's bought
+	pool := *gp
+	for i, action := range tx.GetActions() {
+		if pool.Gas() < action.Gas() {
+			return nil, false
+		}
+		pool.SubGas(spec.receipt.ActionResults[i].GasUsed)
+	}
+
+	snapshot := statedb.Snapshot()
+	statedb.Merge(spec.state)
+	settled := state.NewAccessSet()
+	statedb.TrackAccess(settled)
+	err := p.settle(statedb, tx, spec.fees)
+	statedb.TrackAccess(nil)
+	// in order the fee account is credited before the execution of the next
+	// action, so the execution mustn't have read the state changed by it.
+	if err != nil || !statedb.Validate(settled.Overlap(spec.state.Access())) {
+		statedb.RevertToSnapshot(snapshot)
+		return nil, false
+	}
+
+	*gp = pool
+	*usedGas += spec.receipt.TotalGasUsed
+	receipt := spec.receipt
+	receipt.PostState = statedb.ReceiptRoot().Bytes()
+	receipt.CumulativeGasUsed = *usedGas
+	receipt.Logs = statedb.GetLogs(tx.Hash())
+	receipt.Bloom = types.CreateBloom([]*types.Receipt{receipt})
+	return receipt, true
+}
+
+// settle transfers the fees charged to the payers by a speculative execution
+// to the fee account and records them in the fee manager.
+func (p *StateProcessor) settle(statedb *state.StateDB, tx *types.Transaction, fees []*feeCharge) error {
+	accountDB, err := accountmanager.NewAccountManager(statedb)
+	if err != nil {
+		return err
+	}
+	config := p.bc.Config()
+	for _, fee := range fees {
+		if fee.value.Sign() > 0 {
+			// the fee is given back to the payer to be transferred as in
+			// the sequential execution.
+			if err := accountDB.AddAccountBalanceByID(fee.payer, config.SysTokenID, fee.value); err != nil {
+				return err
+			}
+			if err := accountDB.TransferAsset(fee.payer, common.Name(config.FeeName), config.SysTokenID, fee.value); err != nil {
+				return err
+			}
+		}
+		if err := recordFee(statedb, accountDB, fee.founderGas, tx.GasPrice(), config.SysTokenID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -18,11 +18,13 @@ package processor
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/fractalplatform/fractal/accountmanager"
 	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
+	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor/vm"
 	"github.com/fractalplatform/fractal/state"
 	"github.com/fractalplatform/fractal/types"
@@ -33,8 +35,9 @@ import (
 //
 // StateProcessor implements Processor.
 type StateProcessor struct {
-	bc     ChainContext      // Canonical block chain
-	engine consensus.IEngine // Consensus engine used for block rewards
+	bc       ChainContext      // Canonical block chain
+	engine   consensus.IEngine // Consensus engine used for block rewards
+	parallel int               // Number of transactions executed speculatively at once
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -45,6 +48,13 @@ func NewStateProcessor(bc ChainContext, engine consensus.IEngine) *StateProcesso
 	}
 }
 
+// SetParallel sets the number of transactions of a block executed
+// speculatively at once, the transactions are executed in sequence if it is
+// below 2.
+func (p *StateProcessor) SetParallel(workers int) {
+	p.parallel = workers
+}
+
 // Process processes the state changes according to the rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
@@ -64,15 +74,23 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	// Prepare the block, applying any consensus engine specific extras (e.g. update last)
 	p.engine.Prepare(p.bc, header, block.Transactions(), receipts, statedb)
 
-	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
-		statedb.Prepare(tx.Hash(), block.Hash(), i)
-		receipt, _, err := p.ApplyTransaction(nil, gp, statedb, header, tx, usedGas, cfg)
+	if p.parallel > 1 && !cfg.Debug {
+		var err error
+		receipts, allLogs, err = p.applyParallel(block, gp, statedb, usedGas, cfg)
 		if err != nil {
 			return nil, nil, 0, err
 		}
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
+	} else {
+		// Iterate over and process the individual transactions
+		for i, tx := range block.Transactions() {
+			statedb.Prepare(tx.Hash(), block.Hash(), i)
+			receipt, _, err := p.ApplyTransaction(nil, gp, statedb, header, tx, usedGas, cfg)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+		}
 	}
 
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
@@ -86,6 +104,13 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func (p *StateProcessor) ApplyTransaction(author *common.Name, gp *common.GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error) {
+	return p.applyTransaction(author, gp, statedb, header, tx, usedGas, cfg, nil)
+}
+
+// applyTransaction applies the transaction, when fees isn't nil the gas fee
+// of every action is added to it instead of being credited to the fee account
+// and the receipt has no post state.
+func (p *StateProcessor) applyTransaction(author *common.Name, gp *common.GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config, fees *[]*feeCharge) (*types.Receipt, uint64, error) {
 	bc := p.bc
 	config := bc.Config()
 	accountDB, err := accountmanager.NewAccountManager(statedb)
@@ -101,6 +126,32 @@ func (p *StateProcessor) ApplyTransaction(author *common.Name, gp *common.GasPoo
 	}
 	gasPrice := tx.GasPrice()
 
+	if tx.Payer() != "" {
+		if header.CurForkID() < params.ForkID3 {
+			return nil, 0, ErrPayerNotSupported
+		}
+		if err := accountDB.CheckSponsorship(tx); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := checkTxBinding(p.bc, header, tx); err != nil {
+		return nil, 0, err
+	}
+
+	// the state changes of an atomic transaction are reverted as a whole if
+	// any of its actions fails.
+	var (
+		snapshot int
+		applied  []*appliedAction
+	)
+	if tx.Atomic() {
+		if header.CurForkID() < params.ForkID3 {
+			return nil, 0, ErrAtomicNotSupported
+		}
+		snapshot = statedb.Snapshot()
+	}
+
 	var totalGas uint64
 	var ios []*types.ActionResult
 	detailTx := &types.DetailTx{}
@@ -129,10 +180,19 @@ func (p *StateProcessor) ApplyTransaction(author *common.Name, gp *common.GasPoo
 		context := NewEVMContext(action.Sender(), action.Recipient(), assetID, tx.GasPrice(), header, evmcontext, author)
 		vmenv := vm.NewEVM(context, accountDB, statedb, config, cfg)
 
-		_, gas, failed, err, vmerr := ApplyMessage(accountDB, vmenv, action, gp, gasPrice, assetID, config, p.engine)
+		st := NewStateTransition(accountDB, vmenv, action, tx.Payer(), gp, gasPrice, assetID, config, p.engine)
+		st.deferFee = fees != nil
+		_, gas, failed, err, vmerr := st.TransitionDb()
 		if err != nil {
 			return nil, 0, err
 		}
+		if fees != nil {
+			*fees = append(*fees, &feeCharge{
+				payer:      st.payer,
+				value:      new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice),
+				founderGas: vmenv.FounderGasMap,
+			})
+		}
 
 		*usedGas += gas
 		totalGas += gas
@@ -155,9 +215,36 @@ func (p *StateProcessor) ApplyTransaction(author *common.Name, gp *common.GasPoo
 		}
 		ios = append(ios, &types.ActionResult{Status: status, Index: uint64(i), GasUsed: gas, GasAllot: gasAllot, Error: vmerrstr})
 		detailActions = append(detailActions, &types.DetailAction{InternalActions: vmenv.InternalTxs})
+
+		if !tx.Atomic() {
+			continue
+		}
+		applied = append(applied, &appliedAction{action: action, gasUsed: gas, founderGas: vmenv.FounderGasMap})
+		if !failed {
+			continue
+		}
+		if err := revertAtomic(statedb, accountDB, snapshot, tx, applied, assetID, config); err != nil {
+			return nil, 0, err
+		}
+		// the actions applied before the failing one are reverted and the
+		// following ones are not executed.
+		for j := range ios[:i] {
+			ios[j].Status = types.ReceiptStatusReverted
+			ios[j].Error = errAtomicReverted.Error()
+			detailActions[j].InternalActions = nil
+		}
+		detailActions[i].InternalActions = nil
+		for j := i + 1; j < len(tx.GetActions()); j++ {
+			ios = append(ios, &types.ActionResult{Status: types.ReceiptStatusReverted, Index: uint64(j), Error: errAtomicReverted.Error()})
+			detailActions = append(detailActions, &types.DetailAction{})
+		}
+		break
+	}
+	var root []byte
+	if fees == nil {
+		root = statedb.ReceiptRoot().Bytes()
 	}
-	root := statedb.ReceiptRoot()
-	receipt := types.NewReceipt(root[:], *usedGas, totalGas)
+	receipt := types.NewReceipt(root, *usedGas, totalGas)
 	receipt.TxHash = tx.Hash()
 	receipt.ActionResults = ios
 	// Set the receipt logs and create a bloom for filtering
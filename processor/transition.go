@@ -28,8 +28,10 @@ import (
 	"github.com/fractalplatform/fractal/feemanager"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/state"
 	"github.com/fractalplatform/fractal/txpool"
 	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
 )
 
 var (
@@ -39,6 +41,7 @@ var (
 type StateTransition struct {
 	engine      EngineContext
 	from        common.Name
+	payer       common.Name
 	gp          *common.GasPool
 	action      *types.Action
 	gas         uint64
@@ -48,15 +51,21 @@ type StateTransition struct {
 	account     *accountmanager.AccountManager
 	evm         *vm.EVM
 	chainConfig *params.ChainConfig
+	deferFee    bool // the fee account is credited when a speculative execution is committed
 }
 
 // NewStateTransition initialises and returns a new state transition object.
+// The gas is paid by the payer, the sender of the action if it's empty.
 func NewStateTransition(accountDB *accountmanager.AccountManager, evm *vm.EVM,
-	action *types.Action, gp *common.GasPool, gasPrice *big.Int, assetID uint64,
+	action *types.Action, payer common.Name, gp *common.GasPool, gasPrice *big.Int, assetID uint64,
 	config *params.ChainConfig, engine EngineContext) *StateTransition {
+	if payer == "" {
+		payer = action.Sender()
+	}
 	return &StateTransition{
 		engine:      engine,
 		from:        action.Sender(),
+		payer:       payer,
 		gp:          gp,
 		evm:         evm,
 		action:      action,
@@ -69,9 +78,9 @@ func NewStateTransition(accountDB *accountmanager.AccountManager, evm *vm.EVM,
 
 // ApplyMessage computes the new state by applying the given message against the old state within the environment.
 func ApplyMessage(accountDB *accountmanager.AccountManager, evm *vm.EVM,
-	action *types.Action, gp *common.GasPool, gasPrice *big.Int,
+	action *types.Action, payer common.Name, gp *common.GasPool, gasPrice *big.Int,
 	assetID uint64, config *params.ChainConfig, engine EngineContext) ([]byte, uint64, bool, error, error) {
-	return NewStateTransition(accountDB, evm, action, gp, gasPrice,
+	return NewStateTransition(accountDB, evm, action, payer, gp, gasPrice,
 		assetID, config, engine).TransitionDb()
 }
 
@@ -89,7 +98,7 @@ func (st *StateTransition) preCheck() error {
 
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.action.Gas()), st.gasPrice)
-	balance, err := st.account.GetAccountBalanceByID(st.from, st.assetID, 0)
+	balance, err := st.account.GetAccountBalanceByID(st.payer, st.assetID, 0)
 	if err != nil {
 		return err
 	}
@@ -101,7 +110,13 @@ func (st *StateTransition) buyGas() error {
 	}
 	st.gas += st.action.Gas()
 	st.initialGas = st.action.Gas()
-	return st.account.TransferAsset(st.from, common.Name(st.chainConfig.FeeName), st.assetID, mgval)
+	if st.deferFee {
+		if mgval.Sign() == 0 {
+			return nil
+		}
+		return st.account.SubAccountBalanceByID(st.payer, st.assetID, mgval)
+	}
+	return st.account.TransferAsset(st.payer, common.Name(st.chainConfig.FeeName), st.assetID, mgval)
 }
 
 // TransitionDb will transition the state by applying the current message and
@@ -145,6 +160,14 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		fallthrough
 	case actionType == types.RefundCandidate:
 		fallthrough
+	case actionType == types.UnjailCandidate:
+		fallthrough
+	case actionType == types.UnvoteCandidate:
+		fallthrough
+	case actionType == types.ChangeVote:
+		fallthrough
+	case actionType == types.ClaimReward:
+		fallthrough
 	case actionType == types.KickedCandidate:
 		fallthrough
 	case actionType == types.RemoveKickedCandidate:
@@ -158,6 +181,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		internalLogs, err := st.account.Process(&types.AccountManagerContext{
 			Action:      st.action,
 			Number:      st.evm.Context.BlockNumber.Uint64(),
+			Time:        st.evm.Context.Time.Uint64(),
 			CurForkID:   st.evm.Context.ForkID,
 			ChainConfig: st.chainConfig,
 		})
@@ -181,18 +205,44 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if err != nil {
 		return nil, st.gasUsed(), true, err, vmerr
 	}
-	st.refundGas()
+	if err := st.refundGas(); err != nil {
+		return nil, st.gasUsed(), true, err, vmerr
+	}
 
 	st.distributeGas(intrinsicGas)
 
 	if err := st.distributeFee(); err != nil {
 		return ret, st.gasUsed(), true, err, vmerr
 	}
+
+	if vmerr == nil && actionType == types.DeleteAccount && st.evm.Context.ForkID >= params.ForkID3 {
+		vmerr = st.destroyAccount()
+	}
 	return ret, st.gasUsed(), vmerr != nil, nil, vmerr
 }
 
+// destroyAccount deletes the sender of the DeleteAccount action once its
+// nonce is updated and the gas refunded, the remaining balances including
+// the refund are swept to the beneficiary.
+func (st *StateTransition) destroyAccount() error {
+	var del accountmanager.DeleteAccountAction
+	if err := rlp.DecodeBytes(st.action.Data(), &del); err != nil {
+		return err
+	}
+	snapshot := st.evm.StateDB.Snapshot()
+	internalLogs, err := st.account.DestroyAccount(st.from, del.Beneficiary, st.evm.Context.ForkID)
+	if err != nil {
+		st.evm.StateDB.RevertToSnapshot(snapshot)
+		return err
+	}
+	st.evm.InternalTxs = append(st.evm.InternalTxs, internalLogs...)
+	return nil
+}
+
 func (st *StateTransition) distributeGas(intrinsicGas uint64) {
 	switch st.action.Type() {
+	case types.TransferLocked:
+		fallthrough
 	case types.Transfer:
 		assetInfo, _ := st.evm.AccountDB.GetAssetInfoByID(st.action.AssetID())
 		assetName := common.Name(assetInfo.GetAssetName())
@@ -224,6 +274,16 @@ func (st *StateTransition) distributeGas(intrinsicGas uint64) {
 	case types.DeleteAccount:
 		fallthrough
 	case types.UpdateAccountAuthor:
+		fallthrough
+	case types.SetSponsorship:
+		fallthrough
+	case types.SetRecovery:
+		fallthrough
+	case types.ProposeRecovery:
+		fallthrough
+	case types.CancelRecovery:
+		fallthrough
+	case types.ExecuteRecovery:
 		st.distributeToSystemAccount(common.Name(st.chainConfig.AccountName))
 		return
 	case types.IncreaseAsset:
@@ -236,6 +296,12 @@ func (st *StateTransition) distributeGas(intrinsicGas uint64) {
 		fallthrough
 	case types.UpdateAssetContract:
 		fallthrough
+	case types.FreezeAsset:
+		fallthrough
+	case types.PauseAsset:
+		fallthrough
+	case types.SetAssetWhitelist:
+		fallthrough
 	case types.UpdateAsset:
 		st.distributeToSystemAccount(common.Name(st.chainConfig.AssetName))
 		return
@@ -249,6 +315,14 @@ func (st *StateTransition) distributeGas(intrinsicGas uint64) {
 		fallthrough
 	case types.RefundCandidate:
 		fallthrough
+	case types.UnjailCandidate:
+		fallthrough
+	case types.UnvoteCandidate:
+		fallthrough
+	case types.ChangeVote:
+		fallthrough
+	case types.ClaimReward:
+		fallthrough
 	case types.KickedCandidate:
 		fallthrough
 	case types.RemoveKickedCandidate:
@@ -308,21 +382,30 @@ func (st *StateTransition) distributeToSystemAccount(name common.Name) {
 }
 
 func (st *StateTransition) distributeFee() error {
-	fm := feemanager.NewFeeManager(st.evm.StateDB, st.evm.AccountDB)
+	if st.deferFee {
+		return nil
+	}
+	return recordFee(st.evm.StateDB, st.evm.AccountDB, st.evm.FounderGasMap, st.gasPrice, st.assetID)
+}
+
+// recordFee records in the fee manager the share of the gas of every object.
+func recordFee(statedb *state.StateDB, accountDB *accountmanager.AccountManager,
+	founderGasMap map[vm.DistributeKey]vm.DistributeGas, gasPrice *big.Int, assetID uint64) error {
+	fm := feemanager.NewFeeManager(statedb, accountDB)
 
 	var keys vm.DistributeKeys
-	for key, _ := range st.evm.FounderGasMap {
+	for key, _ := range founderGasMap {
 		keys = append(keys, key)
 	}
 	sort.Sort(keys)
 
 	for _, key := range keys {
-		gas := st.evm.FounderGasMap[key]
+		gas := founderGasMap[key]
 		if gas.Value > 0 {
-			value := new(big.Int).Mul(st.gasPrice, big.NewInt(gas.Value))
-			err := fm.RecordFeeInSystem(key.ObjectName.String(), gas.TypeID, st.assetID, value)
+			value := new(big.Int).Mul(gasPrice, big.NewInt(gas.Value))
+			err := fm.RecordFeeInSystem(key.ObjectName.String(), gas.TypeID, assetID, value)
 			if err != nil {
-				return fmt.Errorf("record fee err(%v), key:%v,assetID:%d", err, key, st.assetID)
+				return fmt.Errorf("record fee err(%v), key:%v,assetID:%d", err, key, assetID)
 			}
 		}
 	}
@@ -330,10 +413,15 @@ func (st *StateTransition) distributeFee() error {
 	return nil
 }
 
-func (st *StateTransition) refundGas() {
+func (st *StateTransition) refundGas() error {
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	st.account.TransferAsset(common.Name(st.chainConfig.FeeName), st.from, st.assetID, remaining)
 	st.gp.AddGas(st.gas)
+	if !st.deferFee {
+		return st.account.TransferAsset(common.Name(st.chainConfig.FeeName), st.payer, st.assetID, remaining)
+	} else if remaining.Sign() > 0 {
+		return st.account.AddAccountBalanceByID(st.payer, st.assetID, remaining)
+	}
+	return nil
 }
 
 // gasUsed returns the amount of gas used up by the state transition.
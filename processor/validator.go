@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/state"
@@ -126,6 +127,12 @@ func (v *BlockValidator) ValidateHeader(header *types.Header, seal bool) error {
 	return nil
 }
 
+// ValidateProducer verifies the seal of a header whose parent state is not
+// available against the producers of the state.
+func (v *BlockValidator) ValidateProducer(header *types.Header, state *state.StateDB) error {
+	return v.engine.VerifyProducer(v.bc, header, state)
+}
+
 // ValidateBody verifies the the block header's transaction roots.
 // The headers are assumed to be already validated at this point.
 func (v *BlockValidator) ValidateBody(block *types.Block) error {
@@ -137,9 +144,51 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveTxsMerkleRoot(block.Txs); hash != block.TxHash() {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, block.TxHash())
 	}
+
+	header := block.Header()
+	for _, tx := range block.Txs {
+		if err := checkTxBinding(v.bc, header, tx); err != nil {
+			return fmt.Errorf("transaction %x: %v", tx.Hash(), err)
+		}
+	}
 	return nil
 }
 
+// checkTxBinding checks the expiration and the reference block of the
+// transaction against the block including it.
+func checkTxBinding(bc ChainContext, header *types.Header, tx *types.Transaction) error {
+	if tx.Binding() == nil {
+		return nil
+	}
+	if header.CurForkID() < params.ForkID4 {
+		return ErrBindingNotSupported
+	}
+	return tx.CheckBinding(header.Time.Uint64(), header.Number.Uint64(), func(number uint64) common.Hash {
+		return ancestorHash(bc, header, number)
+	})
+}
+
+// ancestorHash returns the hash of the ancestor of the header with the given
+// number. The headers are walked back until the canonical chain is reached,
+// the canonical index is used from there.
+func ancestorHash(bc ChainContext, header *types.Header, number uint64) common.Hash {
+	for h := header; h.Number.Uint64() > number; {
+		if h = bc.GetHeader(h.ParentHash, h.Number.Uint64()-1); h == nil {
+			break
+		}
+		if h.Number.Uint64() == number {
+			return h.Hash()
+		}
+		if canon := bc.GetHeaderByNumber(h.Number.Uint64()); canon != nil && canon.Hash() == h.Hash() {
+			if ancestor := bc.GetHeaderByNumber(number); ancestor != nil {
+				return ancestor.Hash()
+			}
+			break
+		}
+	}
+	return common.Hash{}
+}
+
 // ValidateState validates the various changes that happen after a state
 // transition, such as amount of used gas, the receipt roots and the state root
 // itself. ValidateState returns a database batch if the validation was a success
@@ -0,0 +1,175 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// CallFrame is a node of the call tree built by CallTracer.
+type CallFrame struct {
+	Type       string        `json:"type"`
+	ActionType uint64        `json:"actionType"`
+	From       common.Name   `json:"from"`
+	To         common.Name   `json:"to"`
+	AssetID    uint64        `json:"assetID"`
+	Value      *big.Int      `json:"value"`
+	Gas        uint64        `json:"gas"`
+	GasUsed    uint64        `json:"gasUsed"`
+	Depth      uint64        `json:"depth"`
+	Input      hexutil.Bytes `json:"input,omitempty"`
+	Output     hexutil.Bytes `json:"output,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Calls      []*CallFrame  `json:"calls,omitempty"`
+}
+
+// CallTracer is a Tracer that records the top level EVM frames of a
+// transaction. Combined with the internal actions (CALL, CALLEX, asset
+// transfers, ...) recorded by the EVM when ContractLogFlag is set, it
+// rebuilds the whole call tree of every action of the transaction.
+type CallTracer struct {
+	frames []*CallFrame
+}
+
+// NewCallTracer returns a new call tracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// CaptureStart opens a new top level frame.
+func (t *CallTracer) CaptureStart(from common.Name, to common.Name, call bool, input []byte, gas uint64, value *big.Int) error {
+	frame := &CallFrame{
+		From:  from,
+		To:    to,
+		Gas:   gas,
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = new(big.Int).Set(value)
+	}
+	t.frames = append(t.frames, frame)
+	return nil
+}
+
+// CaptureState implements Tracer, the call tracer doesn't record single steps.
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureFault implements Tracer, the call tracer doesn't record single steps.
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd closes the current top level frame.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if len(t.frames) == 0 {
+		return nil
+	}
+	frame := t.frames[len(t.frames)-1]
+	frame.Output = common.CopyBytes(output)
+	frame.GasUsed = gasUsed
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	return nil
+}
+
+// CallTree returns one call tree per action of the traced transaction. The
+// receipt must be the one produced while tracing, it carries the action
+// results and the internal actions.
+func (t *CallTracer) CallTree(tx *types.Transaction, receipt *types.Receipt) []*CallFrame {
+	var (
+		frames  = t.frames
+		detail  = receipt.GetInternalTxsLog()
+		results []*CallFrame
+	)
+	for i, action := range tx.GetActions() {
+		root := &CallFrame{
+			Type:       "action",
+			ActionType: uint64(action.Type()),
+			From:       action.Sender(),
+			To:         action.Recipient(),
+			AssetID:    action.AssetID(),
+			Value:      action.Value(),
+			Gas:        action.Gas(),
+			Input:      hexutil.Bytes(action.Data()),
+		}
+		// actions that entered the EVM own a top level frame, take the output from it
+		if len(frames) > 0 && frames[0].From == action.Sender() &&
+			frames[0].To == action.Recipient() && bytes.Equal(frames[0].Input, action.Data()) {
+			root.Output = frames[0].Output
+			frames = frames[1:]
+		}
+		if i < len(receipt.ActionResults) {
+			result := receipt.ActionResults[i]
+			root.GasUsed = result.GasUsed
+			root.Error = result.Error
+		}
+		if detail != nil && i < len(detail.Actions) {
+			root.Calls = buildCallTree(detail.Actions[i].InternalActions)
+		}
+		results = append(results, root)
+	}
+	return results
+}
+
+// buildCallTree turns the flat internal action list into a tree. The EVM
+// appends an internal action once the call returned, so the children of an
+// action at depth d are the actions at depth d+1 recorded right before it.
+func buildCallTree(internalActions []*types.InternalAction) []*CallFrame {
+	pending := make(map[uint64][]*CallFrame)
+	for _, ia := range internalActions {
+		frame := &CallFrame{
+			Type:    ia.ActionType,
+			Gas:     ia.GasLimit,
+			GasUsed: ia.GasUsed,
+			Depth:   ia.Depth,
+			Error:   ia.Error,
+		}
+		if ia.Action != nil {
+			frame.ActionType = ia.Action.Type
+			frame.From = ia.Action.From
+			frame.To = ia.Action.To
+			frame.AssetID = ia.Action.AssetID
+			frame.Value = ia.Action.Amount
+			frame.Input = ia.Action.Payload
+		}
+		frame.Calls = pending[ia.Depth+1]
+		delete(pending, ia.Depth+1)
+		pending[ia.Depth] = append(pending[ia.Depth], frame)
+	}
+
+	depths := make([]uint64, 0, len(pending))
+	for depth := range pending {
+		depths = append(depths, depth)
+	}
+	sort.Slice(depths, func(i, j int) bool { return depths[i] < depths[j] })
+
+	var calls []*CallFrame
+	for _, depth := range depths {
+		calls = append(calls, pending[depth]...)
+	}
+	return calls
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallTracer(t *testing.T) {
+	var (
+		from   = common.Name("fromname")
+		to     = common.Name("contractname")
+		other  = common.Name("othercontract")
+		action = types.NewAction(types.CallContract, from, to, 0, 0, 100000, big.NewInt(0), []byte{0x1}, nil)
+		tx     = types.NewTransaction(0, big.NewInt(1), action)
+	)
+
+	tracer := NewCallTracer()
+	tracer.CaptureStart(from, to, true, action.Data(), action.Gas(), action.Value())
+	tracer.CaptureEnd([]byte{0x2}, 300, 0, nil)
+
+	// internal actions are appended once the call returned: the nested
+	// transferex of the second contract comes before the call itself.
+	inner := types.NewAction(types.CallContract, other, from, 0, 1, 0, big.NewInt(10), nil, nil)
+	outer := types.NewAction(types.CallContract, to, other, 0, 0, 200, big.NewInt(0), nil, nil)
+	receipt := types.NewReceipt(nil, 300, 300)
+	receipt.ActionResults = []*types.ActionResult{{Status: types.ReceiptStatusSuccessful, GasUsed: 300}}
+	receipt.SetInternalTxsLog(&types.DetailTx{
+		Actions: []*types.DetailAction{{
+			InternalActions: []*types.InternalAction{
+				{Action: inner.NewRPCAction(0), ActionType: "transferex", Depth: 2},
+				{Action: outer.NewRPCAction(0), ActionType: "call", GasLimit: 200, GasUsed: 150, Depth: 1},
+			},
+		}},
+	})
+
+	frames := tracer.CallTree(tx, receipt)
+	assert.Equal(t, 1, len(frames))
+	assert.Equal(t, []byte{0x2}, []byte(frames[0].Output))
+	assert.Equal(t, uint64(300), frames[0].GasUsed)
+	assert.Equal(t, 1, len(frames[0].Calls))
+	assert.Equal(t, "call", frames[0].Calls[0].Type)
+	assert.Equal(t, other, frames[0].Calls[0].To)
+	assert.Equal(t, 1, len(frames[0].Calls[0].Calls))
+	assert.Equal(t, "transferex", frames[0].Calls[0].Calls[0].Type)
+	assert.Equal(t, big.NewInt(10), frames[0].Calls[0].Calls[0].Value)
+}
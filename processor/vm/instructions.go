@@ -1233,6 +1233,7 @@ func execAddAsset(evm *EVM, contract *Contract, assetID uint64, toName common.Na
 	internalActions, err := evm.AccountDB.Process(&types.AccountManagerContext{
 		Action:      action,
 		Number:      evm.Context.BlockNumber.Uint64(),
+		Time:        evm.Context.Time.Uint64(),
 		CurForkID:   evm.Context.ForkID,
 		ChainConfig: evm.chainConfig,
 	})
@@ -1262,6 +1263,7 @@ func opDestroyAsset(pc *uint64, evm *EVM, contract *Contract, memory *Memory, st
 	internalActions, err := evm.AccountDB.Process(&types.AccountManagerContext{
 		Action:      action,
 		Number:      evm.Context.BlockNumber.Uint64(),
+		Time:        evm.Context.Time.Uint64(),
 		CurForkID:   evm.Context.ForkID,
 		ChainConfig: evm.chainConfig,
 	})
@@ -1464,6 +1466,7 @@ func executeIssuseAsset(evm *EVM, contract *Contract, desc string) (uint64, erro
 	internalActions, err := evm.AccountDB.Process(&types.AccountManagerContext{
 		Action:      action,
 		Number:      evm.Context.BlockNumber.Uint64(),
+		Time:        evm.Context.Time.Uint64(),
 		CurForkID:   evm.Context.ForkID,
 		ChainConfig: evm.chainConfig,
 	})
@@ -1527,6 +1530,7 @@ func execSetAssetOwner(evm *EVM, contract *Contract, assetID uint64, owner commo
 	internalActions, err := evm.AccountDB.Process(&types.AccountManagerContext{
 		Action:      action,
 		Number:      evm.Context.BlockNumber.Uint64(),
+		Time:        evm.Context.Time.Uint64(),
 		CurForkID:   evm.Context.ForkID,
 		ChainConfig: evm.chainConfig,
 	})
@@ -1629,7 +1633,10 @@ func opCallEx(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 		return nil, nil
 	}
 
-	err = evm.AccountDB.TransferAsset(action.Sender(), action.Recipient(), action.AssetID(), action.Value())
+	err = evm.AccountDB.CheckTransferControl(action.Sender(), action.Recipient(), action.AssetID(), action.Value(), evm.Context.ForkID)
+	if err == nil {
+		err = evm.AccountDB.TransferAsset(action.Sender(), action.Recipient(), action.AssetID(), action.Value())
+	}
 	//distribute gas
 	var assetName common.Name
 	assetFounder, _ := evm.AccountDB.GetAssetFounder(action.AssetID()) //get asset founder name
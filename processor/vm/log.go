@@ -111,7 +111,7 @@ func NewStructLogger(cfg *LogConfig) *StructLogger {
 	return logger
 }
 
-func (l *StructLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+func (l *StructLogger) CaptureStart(from common.Name, to common.Name, call bool, input []byte, gas uint64, value *big.Int) error {
 	return nil
 }
 
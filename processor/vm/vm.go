@@ -299,6 +299,9 @@ func (evm *EVM) Call(caller ContractRef, action *types.Action, gas uint64) (ret
 		}
 	}
 
+	if err := evm.AccountDB.CheckTransferControl(action.Sender(), action.Recipient(), action.AssetID(), action.Value(), evm.Context.ForkID); err != nil {
+		return nil, gas, err
+	}
 	if err := evm.AccountDB.TransferAsset(action.Sender(), action.Recipient(), action.AssetID(), action.Value()); err != nil {
 		return nil, gas, err
 	}
@@ -331,6 +334,11 @@ func (evm *EVM) Call(caller ContractRef, action *types.Action, gas uint64) (ret
 	code, _ := acct.GetCode()
 	contract.SetCallCode(&toName, codeHash, code)
 
+	if evm.vmConfig.Debug && evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureStart(caller.Name(), toName, true, action.Data(), gas, action.Value())
+	}
+	start := time.Now()
+
 	ret, err = run(evm, contract, action.Data())
 	runGas := gas - contract.Gas
 
@@ -352,6 +360,9 @@ func (evm *EVM) Call(caller ContractRef, action *types.Action, gas uint64) (ret
 		}
 	}
 	actualUsedGas := gas - contract.Gas
+	if evm.vmConfig.Debug && evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureEnd(ret, actualUsedGas, time.Since(start), err)
+	}
 	evm.distributeGasByScale(actualUsedGas, runGas)
 	return ret, contract.Gas, err
 }
@@ -556,6 +567,9 @@ func (evm *EVM) Create(caller ContractRef, action *types.Action, gas uint64) (re
 		return nil, 0, ErrContractCodeCollision
 	}
 
+	if err := evm.AccountDB.CheckTransferControl(action.Sender(), action.Recipient(), evm.AssetID, action.Value(), evm.Context.ForkID); err != nil {
+		return nil, gas, err
+	}
 	if err := evm.AccountDB.TransferAsset(action.Sender(), action.Recipient(), evm.AssetID, action.Value()); err != nil {
 		evm.StateDB.RevertToSnapshot(snapshot)
 		return nil, gas, err
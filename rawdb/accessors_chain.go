@@ -112,6 +112,69 @@ func WriteHeadBlockHash(db DatabaseWriter, hash common.Hash) {
 	}
 }
 
+// ReadHistoryTail retrieves the number of the oldest block whose history
+// (body, receipts, internal tx logs) wasn't pruned.
+func ReadHistoryTail(db DatabaseReader) uint64 {
+	data, _ := db.Get(historyTailKey)
+	if len(data) == 0 {
+		return 0
+	}
+	return decodeBlockNumber(data)
+}
+
+// WriteHistoryTail stores the number of the oldest block whose history wasn't pruned.
+func WriteHistoryTail(db DatabaseWriter, number uint64) {
+	if err := db.Put(historyTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store history tail", "err", err)
+	}
+}
+
+// ReadHeadFastBlockHash retrieves the hash of the latest block downloaded by fast sync.
+func ReadHeadFastBlockHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(headFastBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteHeadFastBlockHash stores the hash of the latest block downloaded by fast sync.
+func WriteHeadFastBlockHash(db DatabaseWriter, hash common.Hash) {
+	if err := db.Put(headFastBlockKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last fast block's hash", "err", err)
+	}
+}
+
+// DeleteHeadFastBlockHash removes the fast sync head block marker.
+func DeleteHeadFastBlockHash(db DatabaseDeleter) {
+	if err := db.Delete(headFastBlockKey); err != nil {
+		log.Crit("Failed to delete last fast block's hash", "err", err)
+	}
+}
+
+// ReadFastSyncPivot retrieves the hash of the pivot block of an unfinished fast sync.
+func ReadFastSyncPivot(db DatabaseReader) common.Hash {
+	data, _ := db.Get(fastSyncPivotKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteFastSyncPivot stores the hash of the pivot block of the running fast sync.
+func WriteFastSyncPivot(db DatabaseWriter, hash common.Hash) {
+	if err := db.Put(fastSyncPivotKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store fast sync pivot", "err", err)
+	}
+}
+
+// DeleteFastSyncPivot removes the fast sync pivot marker.
+func DeleteFastSyncPivot(db DatabaseDeleter) {
+	if err := db.Delete(fastSyncPivotKey); err != nil {
+		log.Crit("Failed to delete fast sync pivot", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
@@ -70,6 +70,10 @@ func ReadTransaction(db DatabaseReader, hash common.Hash) (*types.Transaction, c
 		return nil, common.Hash{}, 0, 0
 	}
 	block := ReadBlock(db, blockHash, blockNumber)
+	if block == nil && blockNumber < ReadHistoryTail(db) {
+		// the body was removed by history pruning, the lookup entry was kept
+		return nil, common.Hash{}, 0, 0
+	}
 	if block == nil || len(block.Txs) <= int(txIndex) {
 		log.Crit("Transaction referenced missing", "number", blockNumber, "hash", blockHash, "index", txIndex)
 		return nil, common.Hash{}, 0, 0
@@ -85,6 +89,9 @@ func ReadReceipt(db DatabaseReader, hash common.Hash) (*types.Receipt, common.Ha
 		return nil, common.Hash{}, 0, 0
 	}
 	receipts := ReadReceipts(db, blockHash, blockNumber)
+	if receipts == nil && blockNumber < ReadHistoryTail(db) {
+		return nil, common.Hash{}, 0, 0
+	}
 	if len(receipts) <= int(receiptIndex) {
 		log.Crit("Receipt refereced missing", "number", blockNumber, "hash", blockHash, "index", receiptIndex)
 		return nil, common.Hash{}, 0, 0
@@ -105,3 +112,115 @@ func WriteBloomBits(db DatabaseWriter, bit uint, section uint64, head common.Has
 		log.Crit("Failed to store bloom bits", "err", err)
 	}
 }
+
+// ReadBloomBitsSections retrieves the number of sections processed by the
+// bloom bits indexer.
+func ReadBloomBitsSections(db DatabaseReader) uint64 {
+	data, _ := db.Get(bloomBitsSectionsKey())
+	if len(data) != 8 {
+		return 0
+	}
+	return decodeBlockNumber(data)
+}
+
+// WriteBloomBitsSections stores the number of sections processed by the bloom
+// bits indexer.
+func WriteBloomBitsSections(db DatabaseWriter, sections uint64) {
+	if err := db.Put(bloomBitsSectionsKey(), encodeBlockNumber(sections)); err != nil {
+		log.Crit("Failed to store bloom bits sections", "err", err)
+	}
+}
+
+// ReadBloomBitsSectionHead retrieves the hash of the last block of a section
+// processed by the bloom bits indexer.
+func ReadBloomBitsSectionHead(db DatabaseReader, section uint64) common.Hash {
+	data, _ := db.Get(bloomBitsSectionHeadKey(section))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteBloomBitsSectionHead stores the hash of the last block of a section
+// processed by the bloom bits indexer.
+func WriteBloomBitsSectionHead(db DatabaseWriter, section uint64, hash common.Hash) {
+	if err := db.Put(bloomBitsSectionHeadKey(section), hash.Bytes()); err != nil {
+		log.Crit("Failed to store bloom bits section head", "err", err)
+	}
+}
+
+// ReadAccountIndexHead retrieves the latest block indexed by the account indexer.
+func ReadAccountIndexHead(db DatabaseReader) *AccountIndexHead {
+	data, _ := db.Get(accountIndexHeadKey)
+	if len(data) == 0 {
+		return nil
+	}
+	head := new(AccountIndexHead)
+	if err := rlp.DecodeBytes(data, head); err != nil {
+		log.Crit("Invalid account index head RLP", "err", err)
+		return nil
+	}
+	return head
+}
+
+// WriteAccountIndexHead stores the latest block indexed by the account indexer.
+func WriteAccountIndexHead(db DatabaseWriter, number uint64, hash common.Hash) {
+	data, err := rlp.EncodeToBytes(&AccountIndexHead{Number: number, Hash: hash})
+	if err != nil {
+		log.Crit("Failed to encode account index head", "err", err)
+	}
+	if err := db.Put(accountIndexHeadKey, data); err != nil {
+		log.Crit("Failed to store account index head", "err", err)
+	}
+}
+
+// DeleteAccountIndexHead removes the account index head.
+func DeleteAccountIndexHead(db DatabaseDeleter) {
+	db.Delete(accountIndexHeadKey)
+}
+
+// ReadAccountTxCount retrieves the number of account tx entries of an account.
+func ReadAccountTxCount(db DatabaseReader, name common.Name) uint64 {
+	data, _ := db.Get(accountTxCountKey(name))
+	if len(data) == 0 {
+		return 0
+	}
+	return decodeBlockNumber(data)
+}
+
+// WriteAccountTxCount stores the number of account tx entries of an account.
+func WriteAccountTxCount(db DatabaseWriter, name common.Name, count uint64) {
+	if err := db.Put(accountTxCountKey(name), encodeBlockNumber(count)); err != nil {
+		log.Crit("Failed to store account tx count", "err", err)
+	}
+}
+
+// ReadAccountTxEntry retrieves the seq-th account tx entry of an account.
+func ReadAccountTxEntry(db DatabaseReader, name common.Name, seq uint64) *AccountTxEntry {
+	data, _ := db.Get(accountTxKey(name, seq))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(AccountTxEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Crit("Invalid account tx entry RLP", "name", name, "seq", seq, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteAccountTxEntry stores the seq-th account tx entry of an account.
+func WriteAccountTxEntry(db DatabaseWriter, name common.Name, seq uint64, entry *AccountTxEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode account tx entry", "err", err)
+	}
+	if err := db.Put(accountTxKey(name, seq), data); err != nil {
+		log.Crit("Failed to store account tx entry", "err", err)
+	}
+}
+
+// DeleteAccountTxEntry removes the seq-th account tx entry of an account.
+func DeleteAccountTxEntry(db DatabaseDeleter, name common.Name, seq uint64) {
+	db.Delete(accountTxKey(name, seq))
+}
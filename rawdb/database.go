@@ -0,0 +1,224 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// Iterator iterates over the key/value pairs of a database in key order.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// recordType is a kind of database record following the schema, a key
+// matches it if it has the prefix and the length.
+type recordType struct {
+	name   string
+	prefix []byte
+	length int // length of the key, 0 if not fixed
+	decode func(key, value []byte) (interface{}, error)
+}
+
+// SnapshotRecord is a decoded dpos snapshot record.
+type SnapshotRecord struct {
+	Block types.SnapshotBlock
+	Info  types.SnapshotInfo
+}
+
+var (
+	hashLen = common.HashLength
+	numLen  = 8
+
+	// recordTypes lists the record types of the schema, the more specific
+	// ones first. Trie nodes are keyed by their hash, they come before the
+	// prefixes of any key length.
+	recordTypes = []*recordType{
+		{"irreversible-number", irreversibleNumberKey, len(irreversibleNumberKey), decodeNumber},
+		{"head-header", headHeaderKey, len(headHeaderKey), decodeHash},
+		{"head-block", headBlockKey, len(headBlockKey), decodeHash},
+		{"head-fast-block", headFastBlockKey, len(headFastBlockKey), decodeHash},
+		{"history-tail", historyTailKey, len(historyTailKey), decodeNumber},
+		{"fast-sync-pivot", fastSyncPivotKey, len(fastSyncPivotKey), decodeHash},
+		{"opt-block", blockOptHash, len(blockOptHash), decodeHash},
+		{"account-index-head", accountIndexHeadKey, len(accountIndexHeadKey), decodeRLP(func() interface{} { return new(AccountIndexHead) })},
+		{"header", headerPrefix, 1 + numLen + hashLen, decodeRLP(func() interface{} { return new(types.Header) })},
+		{"td", headerPrefix, 1 + numLen + hashLen + len(headerTDSuffix), decodeRLP(func() interface{} { return new(big.Int) })},
+		{"canonical-hash", headerPrefix, 1 + numLen + len(headerHashSuffix), decodeHash},
+		{"header-number", headerNumberPrefix, 1 + hashLen, decodeNumber},
+		{"body", blockBodyPrefix, 1 + numLen + hashLen, decodeRLP(func() interface{} { return new(types.Body) })},
+		{"receipts", blockReceiptsPrefix, 1 + numLen + hashLen, decodeRLP(func() interface{} { return new([]*types.Receipt) })},
+		{"detail-txs", blockDetailTxsPrefix, 1 + numLen + hashLen, decodeRLP(func() interface{} { return new([]*types.DetailTx) })},
+		{"tx-lookup", txLookupPrefix, 1 + hashLen, decodeRLP(func() interface{} { return new(TxLookupEntry) })},
+		{"state-out", blockStateOutPrefix, 1 + hashLen, decodeRLP(func() interface{} { return new(types.StateOut) })},
+		{"trie-node", nil, hashLen, decodeRaw},
+		{"snapshot", blockSnapshotPrefix, 0, decodeSnapshot},
+		{"bloombits", bloomBitsPrefix, 1 + 2 + numLen + hashLen, decodeRaw},
+		{"bloombits-index", BloomBitsIndexPrefix, 0, decodeRaw},
+		{"preimage", preimagePrefix, len(preimagePrefix) + hashLen, decodeRaw},
+		{"chain-config", configPrefix, len(configPrefix) + hashLen, decodeConfig},
+		{"account-tx", accountTxPrefix, 0, decodeRLP(func() interface{} { return new(AccountTxEntry) })},
+		{"account-tx-count", accountTxCountPrefix, 0, decodeNumber},
+	}
+)
+
+func decodeNumber(key, value []byte) (interface{}, error) {
+	if len(value) != numLen {
+		return nil, fmt.Errorf("invalid number length %d", len(value))
+	}
+	return decodeBlockNumber(value), nil
+}
+
+func decodeHash(key, value []byte) (interface{}, error) {
+	if len(value) != hashLen {
+		return nil, fmt.Errorf("invalid hash length %d", len(value))
+	}
+	return common.BytesToHash(value), nil
+}
+
+func decodeRaw(key, value []byte) (interface{}, error) {
+	return hexutil.Bytes(value), nil
+}
+
+func decodeRLP(alloc func() interface{}) func(key, value []byte) (interface{}, error) {
+	return func(key, value []byte) (interface{}, error) {
+		record := alloc()
+		if err := rlp.DecodeBytes(value, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+func decodeSnapshot(key, value []byte) (interface{}, error) {
+	record := new(SnapshotRecord)
+	if err := rlp.DecodeBytes(key[len(blockSnapshotPrefix):], &record.Block); err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(value, &record.Info); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func decodeConfig(key, value []byte) (interface{}, error) {
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(value, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func findRecordType(key []byte) *recordType {
+	for _, t := range recordTypes {
+		if bytes.HasPrefix(key, t.prefix) && (t.length == 0 || len(key) == t.length) {
+			return t
+		}
+	}
+	return nil
+}
+
+// RecordTypes returns the names of the record types of the schema.
+func RecordTypes() []string {
+	names := make([]string, len(recordTypes))
+	for i, t := range recordTypes {
+		names[i] = t.name
+	}
+	return names
+}
+
+// RecordPrefix returns the key prefix of a record type.
+func RecordPrefix(name string) ([]byte, error) {
+	for _, t := range recordTypes {
+		if t.name == name {
+			return common.CopyBytes(t.prefix), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown record type %s", name)
+}
+
+// RecordType returns the name of the record type of the key, or an empty
+// string if the key isn't part of the schema.
+func RecordType(key []byte) string {
+	if t := findRecordType(key); t != nil {
+		return t.name
+	}
+	return ""
+}
+
+// DecodeRecord decodes the value of a record following the schema.
+func DecodeRecord(key, value []byte) (interface{}, error) {
+	t := findRecordType(key)
+	if t == nil {
+		return nil, fmt.Errorf("unknown record key %x", key)
+	}
+	return t.decode(key, value)
+}
+
+// DatabaseStat is the number of records of a type and the size of their keys
+// and values.
+type DatabaseStat struct {
+	Type  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+// InspectDatabase iterates over the database and returns the statistics of
+// every record type, sorted by size. Keys outside of the schema are
+// reported as "unknown".
+func InspectDatabase(it Iterator) ([]*DatabaseStat, error) {
+	defer it.Release()
+
+	stats := make(map[string]*DatabaseStat)
+	for it.Next() {
+		name := RecordType(it.Key())
+		if name == "" {
+			name = "unknown"
+		}
+		stat, ok := stats[name]
+		if !ok {
+			stat = &DatabaseStat{Type: name}
+			stats[name] = stat
+		}
+		stat.Count++
+		stat.Size += common.StorageSize(len(it.Key()) + len(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	list := make([]*DatabaseStat, 0, len(stats))
+	for _, stat := range stats {
+		list = append(list, stat)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Size > list[j].Size })
+	return list, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+	mdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
+)
+
+// memIterator iterates over the keys of a memory database in key order.
+type memIterator struct {
+	db   *mdb.MemDatabase
+	keys [][]byte
+	pos  int
+}
+
+func newMemIterator(db *mdb.MemDatabase) *memIterator {
+	keys := db.Keys()
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return &memIterator{db: db, keys: keys, pos: -1}
+}
+
+func (it *memIterator) Next() bool    { it.pos++; return it.pos < len(it.keys) }
+func (it *memIterator) Key() []byte   { return it.keys[it.pos] }
+func (it *memIterator) Value() []byte { v, _ := it.db.Get(it.keys[it.pos]); return v }
+func (it *memIterator) Release()      {}
+func (it *memIterator) Error() error  { return nil }
+
+// Tests that the records are classified and decoded following the schema.
+func TestDecodeRecord(t *testing.T) {
+	db := mdb.NewMemDatabase()
+
+	header := &types.Header{Number: big.NewInt(42), Extra: []byte("test header")}
+	hash := header.Hash()
+	WriteHeader(db, header)
+	WriteTd(db, hash, 42, big.NewInt(7))
+	WriteCanonicalHash(db, hash, 42)
+	WriteHeadBlockHash(db, hash)
+	WriteIrreversibleNumber(db, 40)
+	WriteSnapshot(db, types.SnapshotBlock{Number: 42, BlockHash: header.ParentHash}, types.SnapshotInfo{Root: common.HexToHash("0x01")})
+	db.Put(common.HexToHash("0x02").Bytes(), []byte("trie node"))
+	db.Put([]byte("junk"), []byte("junk"))
+
+	want := map[string]uint64{
+		"header":              1,
+		"td":                  1,
+		"canonical-hash":      1,
+		"header-number":       1,
+		"head-block":          1,
+		"irreversible-number": 1,
+		"snapshot":            1,
+		"trie-node":           1,
+		"unknown":             1,
+	}
+	stats, err := InspectDatabase(newMemIterator(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("record types mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for _, stat := range stats {
+		if stat.Count != want[stat.Type] {
+			t.Fatalf("%s count mismatch: have %d, want %d", stat.Type, stat.Count, want[stat.Type])
+		}
+	}
+
+	it := newMemIterator(db)
+	for it.Next() {
+		switch RecordType(it.Key()) {
+		case "header":
+			record, err := DecodeRecord(it.Key(), it.Value())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.(*types.Header).Hash() != hash {
+				t.Fatalf("header mismatch")
+			}
+		case "canonical-hash", "head-block":
+			if record, err := DecodeRecord(it.Key(), it.Value()); err != nil || record.(common.Hash) != hash {
+				t.Fatalf("hash mismatch: %v %v", record, err)
+			}
+		case "irreversible-number":
+			if record, err := DecodeRecord(it.Key(), it.Value()); err != nil || record.(uint64) != 40 {
+				t.Fatalf("irreversible number mismatch: %v %v", record, err)
+			}
+		case "snapshot":
+			record, err := DecodeRecord(it.Key(), it.Value())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot := record.(*SnapshotRecord); snapshot.Block.Number != 42 || snapshot.Info.Root != common.HexToHash("0x01") {
+				t.Fatalf("snapshot mismatch: %v", snapshot)
+			}
+		case "":
+			if _, err := DecodeRecord(it.Key(), it.Value()); err == nil {
+				t.Fatalf("unknown key %x decoded", it.Key())
+			}
+		}
+	}
+}
@@ -33,6 +33,15 @@ var (
 	// headBlockKey tracks the latest know full block's hash.
 	headBlockKey = []byte("LastBlock")
 
+	// headFastBlockKey tracks the latest block downloaded by fast sync, whose state is unknown.
+	headFastBlockKey = []byte("LastFast")
+
+	// historyTailKey tracks the oldest block whose body, receipts and logs are kept by history pruning.
+	historyTailKey = []byte("HistoryTail")
+
+	// fastSyncPivotKey tracks the pivot block whose state an interrupted fast sync was downloading.
+	fastSyncPivotKey = []byte("FastSyncPivot")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -57,6 +66,10 @@ var (
 	blockOptHash = []byte("LastOptHash")
 
 	blockSnapshotPrefix = []byte("sn")
+
+	accountIndexHeadKey  = []byte("LastAccountIndex") // accountIndexHeadKey tracks the latest block indexed by the account indexer
+	accountTxPrefix      = []byte("at")               // accountTxPrefix + name + seq (uint64 big endian) -> account tx entry
+	accountTxCountPrefix = []byte("ac")               // accountTxCountPrefix + name -> number of account tx entries
 )
 
 // TxLookupEntry is a positional metadata to help looking up the data content of
@@ -67,6 +80,22 @@ type TxLookupEntry struct {
 	Index      uint64
 }
 
+// AccountTxEntry is a positional metadata of an action an account took part
+// in, either as sender/recipient of the action or only in its internal actions.
+type AccountTxEntry struct {
+	BlockNumber uint64
+	TxIndex     uint64
+	ActionIndex uint64
+	TxHash      common.Hash
+	Internal    bool
+}
+
+// AccountIndexHead is the latest block indexed by the account indexer.
+type AccountIndexHead struct {
+	Number uint64
+	Hash   common.Hash
+}
+
 // encodeBlockNumber encodes a block number as big endian uint64
 func encodeBlockNumber(number uint64) []byte {
 	enc := make([]byte, 8)
@@ -127,6 +156,26 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// bloomBitsSectionsKey = BloomBitsIndexPrefix + "count"
+func bloomBitsSectionsKey() []byte {
+	return append(append([]byte{}, BloomBitsIndexPrefix...), []byte("count")...)
+}
+
+// bloomBitsSectionHeadKey = BloomBitsIndexPrefix + "shead" + section (uint64 big endian)
+func bloomBitsSectionHeadKey(section uint64) []byte {
+	return append(append(append([]byte{}, BloomBitsIndexPrefix...), []byte("shead")...), encodeBlockNumber(section)...)
+}
+
+// accountTxKey = accountTxPrefix + name + seq (uint64 big endian)
+func accountTxKey(name common.Name, seq uint64) []byte {
+	return append(append(append([]byte{}, accountTxPrefix...), []byte(name)...), encodeBlockNumber(seq)...)
+}
+
+// accountTxCountKey = accountTxCountPrefix + name
+func accountTxCountKey(name common.Name) []byte {
+	return append(append([]byte{}, accountTxCountPrefix...), []byte(name)...)
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
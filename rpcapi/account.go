@@ -134,6 +134,51 @@ func (aapi *AccountAPI) GetAccountBalanceByTime(accountName common.Name, assetID
 	return am.GetBalanceByTime(accountName, assetID, typeID, time)
 }
 
+//GetVestingSchedules get the vesting schedules of the account in the asset
+func (aapi *AccountAPI) GetVestingSchedules(accountName common.Name, assetID uint64) ([]*accountmanager.VestingSchedule, error) {
+	am, err := aapi.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	return am.GetVestingSchedules(accountName, assetID)
+}
+
+//GetLockedBalance get the balance of the account in the asset not released by its vesting schedules
+func (aapi *AccountAPI) GetLockedBalance(accountName common.Name, assetID uint64) (*big.Int, error) {
+	am, err := aapi.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	return am.GetLockedBalance(accountName, assetID)
+}
+
+//GetRecoveryConfig get the recovery guardians of the account
+func (aapi *AccountAPI) GetRecoveryConfig(accountName common.Name) (*accountmanager.RecoveryConfig, error) {
+	am, err := aapi.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	return am.GetRecoveryConfig(accountName)
+}
+
+//GetRecoveryRequest get the pending recovery request of the account
+func (aapi *AccountAPI) GetRecoveryRequest(accountName common.Name) (*accountmanager.RecoveryRequest, error) {
+	am, err := aapi.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	return am.GetRecoveryRequest(accountName)
+}
+
+//GetRecoveryVote get the author set the guardian proposed for the account
+func (aapi *AccountAPI) GetRecoveryVote(accountName common.Name, guardian common.Name) (*accountmanager.RecoveryAction, error) {
+	am, err := aapi.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	return am.GetRecoveryVote(accountName, guardian)
+}
+
 //GetSnapshotLast  get last snapshot time
 func (aapi *AccountAPI) GetSnapshotLast() (uint64, error) {
 	am, err := aapi.b.GetAccountManager()
@@ -27,6 +27,7 @@ import (
 	"github.com/fractalplatform/fractal/consensus"
 	"github.com/fractalplatform/fractal/debug"
 	"github.com/fractalplatform/fractal/feemanager"
+	"github.com/fractalplatform/fractal/keystore"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor/vm"
 	"github.com/fractalplatform/fractal/rpc"
@@ -46,19 +47,26 @@ type Backend interface {
 
 	// BlockChain API
 	CurrentBlock() *types.Block
+	IrreversibleNumber() uint64
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
 	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
 	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error)
+	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
+	BloomStatus() (uint64, uint64)
 	GetDetailTxsLog(ctx context.Context, hash common.Hash) ([]*types.DetailTx, error)
 	GetBlockDetailLog(ctx context.Context, blockNr rpc.BlockNumber) *types.BlockAndResult
 	GetTd(blockHash common.Hash) *big.Int
 	GetEVM(ctx context.Context, account *accountmanager.AccountManager, state *state.StateDB, from common.Name, to common.Name, assetID uint64, gasPrice *big.Int, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error)
 	GetDetailTxByFilter(ctx context.Context, filterFn func(common.Name) bool, blockNr, lookbackNum uint64) []*types.DetailTx
 	GetTxsByFilter(ctx context.Context, filterFn func(common.Name) bool, blockNr, lookbackNum uint64) []common.Hash
+	AccountIndexEnabled() bool
 	GetBadBlocks(ctx context.Context) ([]*types.Block, error)
 	SetStatePruning(enable bool) (bool, uint64)
+	StateAt(root common.Hash) (*state.StateDB, error)
+	StateAtBlock(ctx context.Context, block *types.Block) (*state.StateDB, *types.Header, error)
+	ApplyTransaction(gp *common.GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, vmCfg vm.Config) (*types.Receipt, uint64, error)
 
 	// TxPool
 	TxPool() *txpool.TxPool
@@ -68,6 +76,7 @@ type Backend interface {
 
 	//Account API
 	GetAccountManager() (*accountmanager.AccountManager, error)
+	KeyStore() *keystore.KeyStore
 
 	//fee manager
 	GetFeeManager() (*feemanager.FeeManager, error)
@@ -111,6 +120,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicFractalAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "ft",
+			Version:   "1.0",
+			Service:   NewPublicFilterAPI(apiBackend),
+			Public:    true,
 		},
 		{
 			Namespace: "account",
@@ -123,6 +137,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Service:   NewFeeAPI(apiBackend),
 			Public:    true,
 		},
+		{
+			Namespace: "personal",
+			Version:   "1.0",
+			Service:   NewPrivatePersonalAPI(apiBackend),
+		},
 		{
 			Namespace: "p2p",
 			Version:   "1.0",
@@ -133,6 +152,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   debug.Handler,
 		},
+		{
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   NewPrivateDebugAPI(apiBackend),
+		},
 	}
 	return append(apis, apiBackend.APIs()...)
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/blockchain"
 	"github.com/fractalplatform/fractal/common"
 	"github.com/fractalplatform/fractal/params"
 	"github.com/fractalplatform/fractal/processor"
@@ -33,6 +34,7 @@ import (
 	"github.com/fractalplatform/fractal/rawdb"
 	"github.com/fractalplatform/fractal/rpc"
 	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/fdb"
 )
 
 // PublicBlockChainAPI provides an API to access the blockchain.
@@ -81,17 +83,30 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(chainID *big.Int, b *types.Block, i
 }
 
 // GetTransactionByHash returns the transaction for the given hash
-func (s *PublicBlockChainAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) *types.RPCTransaction {
+func (s *PublicBlockChainAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*types.RPCTransaction, error) {
 	// Try to return an already finalized transaction
 	if tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash); tx != nil {
-		return tx.NewRPCTransaction(blockHash, blockNumber, index)
+		return tx.NewRPCTransaction(blockHash, blockNumber, index), nil
+	}
+	if err := txHistoryErr(s.b.ChainDb(), hash); err != nil {
+		return nil, err
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.TxPool().Get(hash); tx != nil {
-		return tx.NewRPCTransaction(common.Hash{}, 0, 0)
+		return tx.NewRPCTransaction(common.Hash{}, 0, 0), nil
 	}
 
 	// Transaction unknown, return as such
+	return nil, nil
+}
+
+// txHistoryErr returns an error if the transaction is known by its lookup
+// entry, but the block including it was removed by history pruning.
+func txHistoryErr(db fdb.Database, hash common.Hash) error {
+	_, number, _ := rawdb.ReadTxLookupEntry(db, hash)
+	if number > 0 && number < rawdb.ReadHistoryTail(db) {
+		return fmt.Errorf("transaction %v in block #%d: %v", hash.Hex(), number, blockchain.ErrHistoryPruned)
+	}
 	return nil
 }
 
@@ -99,7 +114,7 @@ func (s *PublicBlockChainAPI) GetTransactionByHash(ctx context.Context, hash com
 func (s *PublicBlockChainAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.RPCReceipt, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash)
 	if tx == nil {
-		return nil, nil
+		return nil, txHistoryErr(s.b.ChainDb(), hash)
 	}
 
 	receipts, err := s.b.GetReceipts(ctx, blockHash)
@@ -204,11 +219,104 @@ func (s *PublicBlockChainAPI) GetInternalTxByBloom(ctx context.Context, bloomByt
 	return s.b.GetDetailTxByFilter(ctx, filterFn, ui64BlockNr, lookbackNum), nil
 }
 
+// AccountTx is an action an account took part in.
+type AccountTx struct {
+	Seq         uint64      `json:"seq"`
+	BlockNumber uint64      `json:"blockNumber"`
+	TxIndex     uint64      `json:"txIndex"`
+	ActionIndex uint64      `json:"actionIndex"`
+	TxHash      common.Hash `json:"txHash"`
+	Internal    bool        `json:"internal"`
+}
+
+// AccountTxsPage is a page of the actions of an account.
+type AccountTxsPage struct {
+	Txs        []*AccountTx `json:"txs"`
+	Total      uint64       `json:"total"`
+	NextCursor *uint64      `json:"nextCursor"`
+}
+
+const (
+	defaultAccountTxsLimit = 100
+	maxAccountTxsLimit     = 1000
+)
+
+// GetAccountTxCount returns the number of actions an account took part in.
+func (s *PublicBlockChainAPI) GetAccountTxCount(ctx context.Context, acctName common.Name) (uint64, error) {
+	if !s.b.AccountIndexEnabled() {
+		return 0, fmt.Errorf("account index is not enabled")
+	}
+	return rawdb.ReadAccountTxCount(s.b.ChainDb(), acctName), nil
+}
+
+// GetTxsByAccountPaged returns a page of the actions an account took part in,
+// read from the account index. The cursor is the seq of the first action of
+// the page, direction is "asc" (oldest first, default) or "desc" (newest
+// first). The nextCursor of the page is nil once the history is exhausted.
+func (s *PublicBlockChainAPI) GetTxsByAccountPaged(ctx context.Context, acctName common.Name, cursor *uint64, limit uint64, direction string) (*AccountTxsPage, error) {
+	if !s.b.AccountIndexEnabled() {
+		return nil, fmt.Errorf("account index is not enabled")
+	}
+	if limit == 0 {
+		limit = defaultAccountTxsLimit
+	}
+	if limit > maxAccountTxsLimit {
+		return nil, fmt.Errorf("limit cant bigger than %d", maxAccountTxsLimit)
+	}
+
+	var (
+		db    = s.b.ChainDb()
+		total = rawdb.ReadAccountTxCount(db, acctName)
+		page  = &AccountTxsPage{Txs: make([]*AccountTx, 0), Total: total}
+		seq   uint64
+		step  int64
+	)
+	switch direction {
+	case "", "asc":
+		step = 1
+	case "desc":
+		step = -1
+		seq = total - 1
+	default:
+		return nil, fmt.Errorf("unknown direction %v", direction)
+	}
+	if cursor != nil {
+		seq = *cursor
+	}
+	if total == 0 || seq >= total {
+		return page, nil
+	}
+
+	for {
+		entry := rawdb.ReadAccountTxEntry(db, acctName, seq)
+		if entry == nil {
+			return nil, fmt.Errorf("account %v tx %d not found", acctName, seq)
+		}
+		page.Txs = append(page.Txs, &AccountTx{
+			Seq:         seq,
+			BlockNumber: entry.BlockNumber,
+			TxIndex:     entry.TxIndex,
+			ActionIndex: entry.ActionIndex,
+			TxHash:      entry.TxHash,
+			Internal:    entry.Internal,
+		})
+		if (step < 0 && seq == 0) || (step > 0 && seq+1 >= total) {
+			break
+		}
+		seq = uint64(int64(seq) + step)
+		if uint64(len(page.Txs)) == limit {
+			page.NextCursor = &seq
+			break
+		}
+	}
+	return page, nil
+}
+
 // GetInternalTxByHash return logs of interal txs include by a transcastion
 func (s *PublicBlockChainAPI) GetInternalTxByHash(ctx context.Context, hash common.Hash) (*types.DetailTx, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash)
 	if tx == nil {
-		return nil, nil
+		return nil, txHistoryErr(s.b.ChainDb(), hash)
 	}
 
 	detailtxs := rawdb.ReadDetailTxs(s.b.ChainDb(), blockHash, blockNumber)
@@ -286,7 +394,7 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// and apply the message.
 	gp := new(common.GasPool).AddGas(math.MaxUint64)
 	action := types.NewAction(args.ActionType, args.From, args.To, 0, assetID, gas, value, args.Data, args.Remark)
-	res, gas, failed, err, _ := processor.ApplyMessage(account, evm, action, gp, gasPrice, assetID, s.b.ChainConfig(), s.b.Engine())
+	res, gas, failed, err, _ := processor.ApplyMessage(account, evm, action, "", gp, gasPrice, assetID, s.b.ChainConfig(), s.b.Engine())
 	if err := vmError(); err != nil {
 		return nil, 0, false, err
 	}
@@ -357,6 +465,46 @@ func (s *PublicBlockChainAPI) GetChainConfig(ctx context.Context) (*params.Chain
 	return rawdb.ReadChainConfig(s.b.ChainDb(), g.Hash()), nil
 }
 
+// AccountProofResult is the merkle proof of an account against the state
+// root of a block.
+type AccountProofResult struct {
+	BlockHash common.Hash `json:"blockHash"`
+	Number    uint64      `json:"number"`
+	StateRoot common.Hash `json:"stateRoot"`
+	*accountmanager.AccountProof
+}
+
+// GetProof returns the merkle proofs of the account record, which carries the
+// asset balances, and of the given contract storage slots against the state
+// root of the block.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, accountName common.Name, keys []common.Hash, blockNr rpc.BlockNumber) (*AccountProofResult, error) {
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	statedb, err := s.b.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	am, err := accountmanager.NewAccountManager(statedb)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := am.GetAccountProof(accountName, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountProofResult{
+		BlockHash:    header.Hash(),
+		Number:       header.Number.Uint64(),
+		StateRoot:    header.Root,
+		AccountProof: proof,
+	}, nil
+}
+
 // PrivateBlockChainAPI provides an API to access the blockchain.
 // It offers only methods that operate on private data that is freely available to anyone.
 type PrivateBlockChainAPI struct {
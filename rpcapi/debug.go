@@ -0,0 +1,257 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/processor/vm"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/types"
+)
+
+const (
+	// StructLoggerTracer is the default tracer, it records every executed opcode.
+	StructLoggerTracer = "structLogger"
+	// CallTracerName records the call tree of each action.
+	CallTracerName = "callTracer"
+)
+
+// TraceConfig holds extra parameters to trace functions.
+type TraceConfig struct {
+	*vm.LogConfig
+	Tracer string `json:"tracer"`
+}
+
+// StructLogRes stores a structured log emitted by the EVM while replaying a
+// transaction in debug mode
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   string             `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// ExecutionResult groups all structured logs emitted by the EVM
+// while replaying a transaction in debug mode as well as transaction
+// execution status, the amount of gas used and the return value
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue hexutil.Bytes  `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// CallTraceResult is the result of the call tracer for a transaction.
+type CallTraceResult struct {
+	Gas     uint64          `json:"gas"`
+	Failed  bool            `json:"failed"`
+	Actions []*vm.CallFrame `json:"actions"`
+}
+
+// TxTraceResult is the result of a single transaction trace of a block.
+type TxTraceResult struct {
+	TxHash common.Hash `json:"txHash"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// PrivateDebugAPI is the collection of debug APIs that re-execute
+// transactions with the EVM tracer enabled.
+type PrivateDebugAPI struct {
+	b Backend
+}
+
+// NewPrivateDebugAPI creates a new debug API.
+func NewPrivateDebugAPI(b Backend) *PrivateDebugAPI {
+	return &PrivateDebugAPI{b}
+}
+
+// TraceTransaction replays the block containing the transaction on top of its
+// parent state and returns the trace of the transaction.
+func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.b.ChainDb(), hash)
+	if tx == nil {
+		if err := txHistoryErr(api.b.ChainDb(), hash); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("transaction %v not found", hash.Hex())
+	}
+	block, err := api.b.GetBlock(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockHash.Hex())
+	}
+	results, err := api.traceBlock(ctx, block, config, int(index))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("transaction %v not found in block", hash.Hex())
+	}
+	if results[0].Error != "" {
+		return nil, errors.New(results[0].Error)
+	}
+	return results[0].Result, nil
+}
+
+// TraceBlockByNumber replays the block with the given number on top of its
+// parent state and returns the trace of every transaction.
+func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, config *TraceConfig) ([]*TxTraceResult, error) {
+	block, err := api.b.BlockByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	return api.traceBlock(ctx, block, config, -1)
+}
+
+// traceBlock re-executes the transactions of the block. If only is not
+// negative, the transactions before it are executed without tracing and
+// only its trace is returned.
+func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig, only int) ([]*TxTraceResult, error) {
+	if block.NumberU64() == 0 {
+		return nil, fmt.Errorf("genesis is not traceable")
+	}
+	statedb, header, err := api.b.StateAtBlock(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &TraceConfig{}
+	}
+
+	var (
+		results []*TxTraceResult
+		usedGas = new(uint64)
+		gp      = new(common.GasPool).AddGas(block.GasLimit())
+	)
+	for i, tx := range block.Transactions() {
+		if only >= 0 && i > only {
+			break
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+
+		if only >= 0 && i < only {
+			if _, _, err := api.b.ApplyTransaction(gp, statedb, header, tx, usedGas, vm.Config{}); err != nil {
+				return nil, fmt.Errorf("tx %v failed: %v", tx.Hash().Hex(), err)
+			}
+			continue
+		}
+
+		var (
+			structLogger *vm.StructLogger
+			callTracer   *vm.CallTracer
+			tracer       vm.Tracer
+		)
+		switch config.Tracer {
+		case "", StructLoggerTracer:
+			structLogger = vm.NewStructLogger(config.LogConfig)
+			tracer = structLogger
+		case CallTracerName:
+			callTracer = vm.NewCallTracer()
+			tracer = callTracer
+		default:
+			return nil, fmt.Errorf("unknown tracer %v", config.Tracer)
+		}
+
+		result := &TxTraceResult{TxHash: tx.Hash()}
+		receipt, gas, err := api.b.ApplyTransaction(gp, statedb, header, tx, usedGas,
+			vm.Config{Debug: true, Tracer: tracer, ContractLogFlag: true})
+		if err != nil {
+			// the block has already been validated, the remaining txs can't be traced
+			result.Error = err.Error()
+			results = append(results, result)
+			return results, nil
+		}
+
+		failed := false
+		for _, ar := range receipt.ActionResults {
+			if ar.Status == types.ReceiptStatusFailed {
+				failed = true
+				break
+			}
+		}
+		if structLogger != nil {
+			result.Result = &ExecutionResult{
+				Gas:         gas,
+				Failed:      failed,
+				ReturnValue: hexutil.Bytes(structLogger.Output()),
+				StructLogs:  FormatLogs(structLogger.StructLogs()),
+			}
+		} else {
+			result.Result = &CallTraceResult{
+				Gas:     gas,
+				Failed:  failed,
+				Actions: callTracer.CallTree(tx, receipt),
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// FormatLogs formats EVM returned structured logs for json output
+func FormatLogs(logs []vm.StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.Op.String(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, stackValue := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", common.LeftPadBytes(stackValue.Bytes(), 32))
+			}
+			formatted[index].Stack = &stack
+		}
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = &memory
+		}
+		if trace.Storage != nil {
+			storage := make(map[string]string)
+			for i, storageValue := range trace.Storage {
+				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
+			}
+			formatted[index].Storage = &storage
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,408 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/bitutil"
+	"github.com/fractalplatform/fractal/blockchain/bloombits"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rawdb"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// FilterCriteria contains options for contract log filtering.
+type FilterCriteria struct {
+	BlockHash *common.Hash    // used by ft_getLogs, return logs only from block with this hash
+	FromBlock rpc.BlockNumber // beginning of the queried range, latest by default
+	ToBlock   rpc.BlockNumber // end of the range, latest by default
+	Names     []common.Name   // restricts matches to events created by specific contracts
+	Topics    [][]common.Hash // restricts matches to particular event topics
+}
+
+// UnmarshalJSON sets *args fields with given data. The fields are
+//
+//	blockHash, fromBlock, toBlock: optional
+//	names: a contract name or a list of names
+//	topics: a list whose entries are null (wildcard), a topic, or a list of
+//	        topics any of which may match
+func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
+	type input struct {
+		BlockHash *common.Hash     `json:"blockHash"`
+		FromBlock *rpc.BlockNumber `json:"fromBlock"`
+		ToBlock   *rpc.BlockNumber `json:"toBlock"`
+		Names     interface{}      `json:"names"`
+		Topics    []interface{}    `json:"topics"`
+	}
+
+	var raw input
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	args.FromBlock, args.ToBlock = rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if raw.BlockHash != nil {
+		if raw.FromBlock != nil || raw.ToBlock != nil {
+			// BlockHash is mutually exclusive with FromBlock/ToBlock criteria
+			return errors.New("cannot specify both blockHash and fromBlock/toBlock, choose one or the other")
+		}
+		args.BlockHash = raw.BlockHash
+	} else {
+		if raw.FromBlock != nil {
+			args.FromBlock = *raw.FromBlock
+		}
+		if raw.ToBlock != nil {
+			args.ToBlock = *raw.ToBlock
+		}
+	}
+
+	switch names := raw.Names.(type) {
+	case nil:
+	case string:
+		args.Names = []common.Name{common.Name(names)}
+	case []interface{}:
+		for i, name := range names {
+			str, ok := name.(string)
+			if !ok {
+				return fmt.Errorf("invalid name at index %d", i)
+			}
+			args.Names = append(args.Names, common.Name(str))
+		}
+	default:
+		return errors.New("invalid names in argument")
+	}
+
+	for i, t := range raw.Topics {
+		switch topic := t.(type) {
+		case nil:
+			// ignore topic when matching logs
+			args.Topics = append(args.Topics, nil)
+		case string:
+			// match specific topic
+			hash, err := decodeTopic(topic)
+			if err != nil {
+				return err
+			}
+			args.Topics = append(args.Topics, []common.Hash{hash})
+		case []interface{}:
+			// or case e.g. [null, "topic0", "topic1"]
+			var topics []common.Hash
+			for _, rawTopic := range topic {
+				if rawTopic == nil {
+					// null component, match all
+					topics = nil
+					break
+				}
+				str, ok := rawTopic.(string)
+				if !ok {
+					return fmt.Errorf("invalid topic(s) at index %d", i)
+				}
+				hash, err := decodeTopic(str)
+				if err != nil {
+					return err
+				}
+				topics = append(topics, hash)
+			}
+			args.Topics = append(args.Topics, topics)
+		default:
+			return fmt.Errorf("invalid topic(s) at index %d", i)
+		}
+	}
+	return nil
+}
+
+func decodeTopic(s string) (common.Hash, error) {
+	var hash common.Hash
+	if err := hash.UnmarshalText([]byte(s)); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid topic %v: %v", s, err)
+	}
+	return hash, nil
+}
+
+// Filter can be used to retrieve and filter logs.
+type Filter struct {
+	b Backend
+
+	names  []common.Name
+	topics [][]common.Hash
+
+	block      common.Hash // Block hash if filtering a single block
+	begin, end int64       // Range interval if filtering multiple blocks
+
+	matcher *bloombits.Matcher
+}
+
+// NewRangeFilter creates a new filter which uses the bloom bits sections to
+// figure out whether a particular block is interesting or not.
+func NewRangeFilter(b Backend, begin, end int64, names []common.Name, topics [][]common.Hash) *Filter {
+	// Flatten the name and topic filter clauses into a single bloombits filter
+	// system. Since the bloombits are not positional, nil topics are permitted,
+	// which get flattened into a nil byte slice.
+	var filters [][][]byte
+	if len(names) > 0 {
+		filter := make([][]byte, len(names))
+		for i, name := range names {
+			filter[i] = []byte(name)
+		}
+		filters = append(filters, filter)
+	}
+	for _, topicList := range topics {
+		filter := make([][]byte, len(topicList))
+		for i, topic := range topicList {
+			filter[i] = topic.Bytes()
+		}
+		filters = append(filters, filter)
+	}
+	size, _ := b.BloomStatus()
+
+	return &Filter{
+		b:       b,
+		names:   names,
+		topics:  topics,
+		begin:   begin,
+		end:     end,
+		matcher: bloombits.NewMatcher(size, filters),
+	}
+}
+
+// NewBlockFilter creates a new filter which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func NewBlockFilter(b Backend, block common.Hash, names []common.Name, topics [][]common.Hash) *Filter {
+	return &Filter{
+		b:      b,
+		block:  block,
+		names:  names,
+		topics: topics,
+	}
+}
+
+// Logs searches the blockchain for matching log entries, returning all from the
+// first block that contains matches, updating the start of the filter accordingly.
+func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	// If we're doing singleton block filtering, execute and return
+	if f.block != (common.Hash{}) {
+		block, err := f.b.GetBlock(ctx, f.block)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errors.New("unknown block")
+		}
+		return f.blockLogs(ctx, block.Header())
+	}
+	// Figure out the limits of the filter range
+	header, err := f.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, nil
+	}
+	head := header.Number.Int64()
+	if f.begin < 0 {
+		f.begin = head
+	}
+	end := f.end
+	if end < 0 || end > head {
+		end = head
+	}
+	// Gather all indexed logs, and finish with non indexed ones
+	var logs []*types.Log
+	size, sections := f.b.BloomStatus()
+	if indexed := int64(sections * size); indexed > f.begin {
+		if indexed > end+1 {
+			indexed = end + 1
+		}
+		found, err := f.indexedLogs(ctx, uint64(indexed-1))
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+	}
+	found, err := f.unindexedLogs(ctx, uint64(end))
+	logs = append(logs, found...)
+	return logs, err
+}
+
+// indexedLogs returns the logs matching the filter criteria based on the bloom
+// bits indexed available locally.
+func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+	var (
+		logs []*types.Log
+		db   = f.b.ChainDb()
+	)
+	size, _ := f.b.BloomStatus()
+	retrieve := func(bit uint, section uint64) ([]byte, error) {
+		head := rawdb.ReadBloomBitsSectionHead(db, section)
+		compressed, err := rawdb.ReadBloomBits(db, bit, section, head)
+		if err != nil {
+			return nil, err
+		}
+		return bitutil.DecompressBytes(compressed, int(size/8))
+	}
+
+	for section := uint64(f.begin) / size; section <= end/size; section++ {
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		default:
+		}
+		numbers, err := f.matcher.Match(section, retrieve)
+		if err != nil {
+			return logs, err
+		}
+		for _, number := range numbers {
+			if number < uint64(f.begin) || number > end {
+				continue
+			}
+			header, err := f.b.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return logs, err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return logs, err
+			}
+			logs = append(logs, found...)
+		}
+	}
+	f.begin = int64(end) + 1
+	return logs, nil
+}
+
+// unindexedLogs returns the logs matching the filter criteria based on raw block
+// iteration and bloom matching.
+func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+	var logs []*types.Log
+
+	for ; f.begin <= int64(end); f.begin++ {
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		default:
+		}
+		header, err := f.b.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
+		if header == nil || err != nil {
+			return logs, err
+		}
+		found, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+	}
+	return logs, nil
+}
+
+// blockLogs returns the logs matching the filter criteria within a single block.
+func (f *Filter) blockLogs(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	if bloomFilter(header.Bloom, f.names, f.topics) {
+		return f.checkMatches(ctx, header)
+	}
+	return nil, nil
+}
+
+// checkMatches checks if the receipts belonging to the given header contain any log events that
+// match the filter criteria. This function is called when the bloom filter signals a potential match.
+func (f *Filter) checkMatches(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	logsList, err := f.b.GetLogs(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, logs := range logsList {
+		for _, log := range logs {
+			// the logs are recorded before the block is sealed
+			log.BlockHash = header.Hash()
+			log.BlockNumber = header.Number.Uint64()
+			unfiltered = append(unfiltered, log)
+		}
+	}
+	return filterLogs(unfiltered, f.names, f.topics), nil
+}
+
+func includes(names []common.Name, name common.Name) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLogs creates a slice of logs matching the given criteria.
+func filterLogs(logs []*types.Log, names []common.Name, topics [][]common.Hash) []*types.Log {
+	var ret []*types.Log
+Logs:
+	for _, log := range logs {
+		if len(names) > 0 && !includes(names, log.Name) {
+			continue
+		}
+		// If the to filtered topics is greater than the amount of topics in logs, skip.
+		if len(topics) > len(log.Topics) {
+			continue Logs
+		}
+		for i, sub := range topics {
+			match := len(sub) == 0 // empty rule set == wildcard
+			for _, topic := range sub {
+				if log.Topics[i] == topic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue Logs
+			}
+		}
+		ret = append(ret, log)
+	}
+	return ret
+}
+
+func bloomFilter(bloom types.Bloom, names []common.Name, topics [][]common.Hash) bool {
+	if len(names) > 0 {
+		var included bool
+		for _, name := range names {
+			if bloom.TestBytes([]byte(name)) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, sub := range topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
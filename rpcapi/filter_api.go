@@ -0,0 +1,309 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/event"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// filterTimeout is the time after which an unpolled filter is uninstalled.
+const filterTimeout = 5 * time.Minute
+
+// filter is a log filter installed by ft_newFilter, polled by ft_getFilterChanges.
+type filter struct {
+	crit     FilterCriteria
+	next     uint64 // first block not yet returned by ft_getFilterChanges
+	deadline *time.Timer
+}
+
+// PublicFilterAPI offers log filtering over the contract logs of the chain.
+type PublicFilterAPI struct {
+	b       Backend
+	mu      sync.Mutex
+	filters map[rpc.ID]*filter
+	timeout time.Duration
+}
+
+// NewPublicFilterAPI returns a new PublicFilterAPI instance.
+func NewPublicFilterAPI(b Backend) *PublicFilterAPI {
+	api := &PublicFilterAPI{
+		b:       b,
+		filters: make(map[rpc.ID]*filter),
+		timeout: filterTimeout,
+	}
+	go api.timeoutLoop()
+	return api
+}
+
+// timeoutLoop runs every 5 minutes and deletes filters that have not been
+// recently used.
+func (api *PublicFilterAPI) timeoutLoop() {
+	ticker := time.NewTicker(api.timeout)
+	defer ticker.Stop()
+	for range ticker.C {
+		api.mu.Lock()
+		for id, f := range api.filters {
+			select {
+			case <-f.deadline.C:
+				delete(api.filters, id)
+			default:
+				continue
+			}
+		}
+		api.mu.Unlock()
+	}
+}
+
+// GetLogs returns logs matching the given argument that are stored within
+// the chain.
+func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.RPCLog, error) {
+	var f *Filter
+	if crit.BlockHash != nil {
+		f = NewBlockFilter(api.b, *crit.BlockHash, crit.Names, crit.Topics)
+	} else {
+		f = NewRangeFilter(api.b, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit.Names, crit.Topics)
+	}
+	logs, err := f.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), nil
+}
+
+// NewFilter creates a new filter and returns the filter id. It can be used to
+// retrieve the logs of the blocks inserted after its creation with
+// ft_getFilterChanges. A filter is uninstalled if it is not polled for 5 minutes.
+func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
+	if crit.BlockHash != nil {
+		return "", fmt.Errorf("blockHash is not supported by filters")
+	}
+	next := api.b.CurrentBlock().NumberU64() + 1
+	if crit.FromBlock >= 0 && uint64(crit.FromBlock) > next {
+		next = uint64(crit.FromBlock)
+	}
+
+	id := rpc.NewID()
+	api.mu.Lock()
+	api.filters[id] = &filter{crit: crit, next: next, deadline: time.NewTimer(api.timeout)}
+	api.mu.Unlock()
+	return id, nil
+}
+
+// GetFilterChanges returns the logs of the filter since the last poll.
+func (api *PublicFilterAPI) GetFilterChanges(ctx context.Context, id rpc.ID) ([]*types.RPCLog, error) {
+	api.mu.Lock()
+	f, found := api.filters[id]
+	if !found {
+		api.mu.Unlock()
+		return nil, fmt.Errorf("filter not found")
+	}
+	// receive timer value and reset timer
+	if !f.deadline.Stop() {
+		<-f.deadline.C
+	}
+	f.deadline.Reset(api.timeout)
+
+	begin, end := f.next, api.b.CurrentBlock().NumberU64()
+	if f.crit.ToBlock >= 0 && uint64(f.crit.ToBlock) < end {
+		end = uint64(f.crit.ToBlock)
+	}
+	if begin > end {
+		api.mu.Unlock()
+		return []*types.RPCLog{}, nil
+	}
+	f.next = end + 1
+	api.mu.Unlock()
+
+	logs, err := NewRangeFilter(api.b, int64(begin), int64(end), f.crit.Names, f.crit.Topics).Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), nil
+}
+
+// GetFilterLogs returns the logs for the filter with the given id.
+func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.RPCLog, error) {
+	api.mu.Lock()
+	f, found := api.filters[id]
+	api.mu.Unlock()
+	if !found {
+		return nil, fmt.Errorf("filter not found")
+	}
+	return api.GetLogs(ctx, f.crit)
+}
+
+// UninstallFilter removes the filter with the given filter id.
+func (api *PublicFilterAPI) UninstallFilter(id rpc.ID) bool {
+	api.mu.Lock()
+	f, found := api.filters[id]
+	if found {
+		delete(api.filters, id)
+		f.deadline.Stop()
+	}
+	api.mu.Unlock()
+	return found
+}
+
+// returnLogs is a helper that will return an empty log array in case the
+// given logs array is nil, otherwise the given logs array is returned.
+func returnLogs(logs []*types.Log) []*types.RPCLog {
+	result := make([]*types.RPCLog, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, log.NewRPCLog())
+	}
+	return result
+}
+
+// subscriptionChanSize is the buffer size of the channels listening to the
+// chain and txpool events of a subscription.
+const subscriptionChanSize = 64
+
+// subscriptionQueueSize is the number of events a subscription buffers for
+// its client, a client falling further behind is unsubscribed.
+const subscriptionQueueSize = 256
+
+// IrreversibleEvent is notified when the irreversible number of the chain
+// advances.
+type IrreversibleEvent struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// subscribe creates a subscription notified by handle for every event of the
+// given type, until the client unsubscribes. The events are queued for the
+// client so a slow client never blocks the event dispatch, it is dropped
+// when its queue is full.
+func (api *PublicFilterAPI) subscribe(ctx context.Context, typecode int, data interface{}, handle func(notify func(interface{}) error, ev *event.Event) error) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	ch := make(chan *event.Event, subscriptionChanSize)
+	sub := event.Subscribe(nil, ch, typecode, data)
+	queue := make(chan *event.Event, subscriptionQueueSize)
+
+	go func() {
+		defer close(queue)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-ch:
+				select {
+				case queue <- ev:
+				default:
+					log.Warn("Subscription dropped, client too slow", "id", rpcSub.ID)
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		notify := func(data interface{}) error {
+			return notifier.Notify(rpcSub.ID, data)
+		}
+		for ev := range queue {
+			if err := handle(notify, ev); err != nil {
+				log.Debug("Subscription notify failed", "id", rpcSub.ID, "err", err)
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewHeads sends a notification each time a new block is appended to the chain.
+func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, event.ChainHeadEv, &types.Block{}, func(notify func(interface{}) error, ev *event.Event) error {
+		block := ev.Data.(*types.Block)
+		return notify(RPCMarshalBlock(api.b.ChainConfig().ChainID, block, false, false))
+	})
+}
+
+// NewPendingTransactions sends a notification with the hash of each
+// transaction added to the transaction pool.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, event.NewTxs, []*types.Transaction{}, func(notify func(interface{}) error, ev *event.Event) error {
+		for _, tx := range ev.Data.([]*types.Transaction) {
+			if err := notify(tx.Hash()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Logs sends a notification for each log of the new blocks matching the
+// given filter criteria.
+func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+	if crit.BlockHash != nil {
+		return nil, fmt.Errorf("blockHash is not supported by subscriptions")
+	}
+	return api.subscribe(ctx, event.ChainHeadEv, &types.Block{}, func(notify func(interface{}) error, ev *event.Event) error {
+		block := ev.Data.(*types.Block)
+		number := block.Number().Int64()
+		if (crit.FromBlock >= 0 && number < crit.FromBlock.Int64()) || (crit.ToBlock >= 0 && number > crit.ToBlock.Int64()) {
+			return nil
+		}
+		logs, err := NewBlockFilter(api.b, block.Hash(), crit.Names, crit.Topics).Logs(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := notify(log.NewRPCLog()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Irreversible sends a notification each time the irreversible number of
+// the chain advances.
+func (api *PublicFilterAPI) Irreversible(ctx context.Context) (*rpc.Subscription, error) {
+	last := api.b.IrreversibleNumber()
+	return api.subscribe(ctx, event.ChainHeadEv, &types.Block{}, func(notify func(interface{}) error, ev *event.Event) error {
+		number := api.b.IrreversibleNumber()
+		if number <= last {
+			return nil
+		}
+		last = number
+		header, err := api.b.HeaderByNumber(context.Background(), rpc.BlockNumber(number))
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return fmt.Errorf("irreversible block #%d not found", number)
+		}
+		return notify(&IrreversibleEvent{Number: number, Hash: header.Hash()})
+	})
+}
@@ -0,0 +1,287 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/event"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// testBackend serves the blocks and logs the filter api reads.
+type testBackend struct {
+	Backend
+
+	mu           sync.Mutex
+	blocks       []*types.Block
+	logs         map[common.Hash][][]*types.Log
+	irreversible uint64
+}
+
+func newTestBackend() *testBackend {
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), Time: big.NewInt(0)}, nil, nil)
+	return &testBackend{
+		blocks: []*types.Block{genesis},
+		logs:   make(map[common.Hash][][]*types.Log),
+	}
+}
+
+// addBlock appends a block holding the logs and announces it as the new head.
+func (b *testBackend) addBlock(logs ...*types.Log) *types.Block {
+	b.mu.Lock()
+	parent := b.blocks[len(b.blocks)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), big.NewInt(1)),
+		Time:       new(big.Int).Add(parent.Time(), big.NewInt(1)),
+	}
+	block := types.NewBlock(header, nil, nil)
+	block.Head.Bloom = types.BytesToBloom(types.LogsBloom(logs).Bytes())
+	b.blocks = append(b.blocks, block)
+	b.logs[block.Hash()] = [][]*types.Log{logs}
+	b.mu.Unlock()
+
+	event.SendEvent(&event.Event{Typecode: event.ChainHeadEv, Data: block})
+	return block
+}
+
+func (b *testBackend) setIrreversible(number uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.irreversible = number
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.DefaultChainconfig
+}
+
+func (b *testBackend) CurrentBlock() *types.Block {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.blocks[len(b.blocks)-1]
+}
+
+func (b *testBackend) IrreversibleNumber() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.irreversible
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if blockNr == rpc.LatestBlockNumber {
+		blockNr = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	if int(blockNr) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[blockNr].Header(), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.logs[hash], nil
+}
+
+// newTestClient serves the services over websocket, subscriptions need a
+// connection based transport.
+func newTestClient(t *testing.T, services map[string]interface{}) (*rpc.Client, func()) {
+	server := rpc.NewServer()
+	for namespace, service := range services {
+		if err := server.RegisterName(namespace, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hs := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	client, err := rpc.DialWebsocket(context.Background(), "ws://"+hs.Listener.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() {
+		client.Close()
+		hs.Close()
+		server.Stop()
+	}
+}
+
+func TestNewHeadsSubscription(t *testing.T) {
+	backend := newTestBackend()
+	client, closeFn := newTestClient(t, map[string]interface{}{"ft": NewPublicFilterAPI(backend)})
+	defer closeFn()
+
+	heads := make(chan map[string]interface{}, 10)
+	sub, err := client.FtSubscribe(context.Background(), heads, "newHeads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		block := backend.addBlock()
+		select {
+		case head := <-heads:
+			if head["hash"] != block.Hash().Hex() {
+				t.Fatalf("head %v mismatch %v", head["hash"], block.Hash().Hex())
+			}
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(time.Second):
+			t.Fatalf("head %d not notified", block.NumberU64())
+		}
+	}
+}
+
+func TestLogsSubscription(t *testing.T) {
+	backend := newTestBackend()
+	client, closeFn := newTestClient(t, map[string]interface{}{"ft": NewPublicFilterAPI(backend)})
+	defer closeFn()
+
+	topic, other := common.BytesToHash([]byte("topic")), common.BytesToHash([]byte("other"))
+	crit := map[string]interface{}{
+		"fromBlock": 2,
+		"names":     "logcontract",
+		"topics":    []interface{}{topic},
+	}
+	logs := make(chan *types.RPCLog, 10)
+	sub, err := client.FtSubscribe(context.Background(), logs, "logs", crit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	match := func() *types.Log {
+		return &types.Log{Name: "logcontract", Topics: []common.Hash{topic}}
+	}
+	// block 1 is before fromBlock
+	backend.addBlock(match())
+	block := backend.addBlock(
+		&types.Log{Name: "othercontract", Topics: []common.Hash{topic}},
+		&types.Log{Name: "logcontract", Topics: []common.Hash{other}},
+		match(),
+	)
+	select {
+	case log := <-logs:
+		if log.BlockNumber != block.NumberU64() || log.BlockHash != block.Hash() || log.Name != "logcontract" || log.Topics[0] != topic {
+			t.Fatalf("log %+v mismatch", log)
+		}
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("log not notified")
+	}
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log %+v", log)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIrreversibleSubscription(t *testing.T) {
+	backend := newTestBackend()
+	client, closeFn := newTestClient(t, map[string]interface{}{"ft": NewPublicFilterAPI(backend)})
+	defer closeFn()
+
+	events := make(chan *IrreversibleEvent, 10)
+	sub, err := client.FtSubscribe(context.Background(), events, "irreversible")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	// the irreversible number does not move
+	first := backend.addBlock()
+	backend.addBlock()
+	backend.setIrreversible(first.NumberU64())
+	backend.addBlock()
+	select {
+	case ev := <-events:
+		if ev.Number != first.NumberU64() || ev.Hash != first.Hash() {
+			t.Fatalf("irreversible %+v mismatch", ev)
+		}
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("irreversible not notified")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected irreversible %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// SlowService subscribes a client whose notifications never complete.
+type SlowService struct {
+	api     *PublicFilterAPI
+	release chan struct{}
+}
+
+func (s *SlowService) Slow(ctx context.Context) (*rpc.Subscription, error) {
+	return s.api.subscribe(ctx, event.ChainHeadEv, &types.Block{}, func(notify func(interface{}) error, ev *event.Event) error {
+		<-s.release
+		return nil
+	})
+}
+
+func TestSlowSubscriptionDoesNotBlock(t *testing.T) {
+	backend := newTestBackend()
+	slow := &SlowService{api: NewPublicFilterAPI(backend), release: make(chan struct{})}
+	defer close(slow.release)
+	client, closeFn := newTestClient(t, map[string]interface{}{"test": slow})
+	defer closeFn()
+
+	sub, err := client.Subscribe(context.Background(), "test", make(chan interface{}), "slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*(subscriptionChanSize+subscriptionQueueSize); i++ {
+			backend.addBlock()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event dispatch blocked by a slow subscription")
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// SignKey selects a keystore key and the author index path it signs for.
+type SignKey struct {
+	PubKey common.PubKey `json:"pubKey"`
+	Index  []uint64      `json:"index"`
+}
+
+// SendTxArgs represents the arguments to sign and submit a transaction
+// with a single action.
+type SendTxArgs struct {
+	ActionType  types.ActionType `json:"actionType"`
+	From        common.Name      `json:"from"`
+	To          common.Name      `json:"to"`
+	Nonce       *uint64          `json:"nonce"`
+	AssetID     uint64           `json:"assetID"`
+	Gas         uint64           `json:"gas"`
+	Amount      *big.Int         `json:"amount"`
+	Payload     hexutil.Bytes    `json:"payload"`
+	Remark      hexutil.Bytes    `json:"remark"`
+	GasAssetID  uint64           `json:"gasAssetID"`
+	GasPrice    *big.Int         `json:"gasPrice"`
+	ParentIndex uint64           `json:"parentIndex"`
+	Keys        []SignKey        `json:"keys"`
+}
+
+// SignTransactionResult is the result of a signed transaction.
+type SignTransactionResult struct {
+	Raw  hexutil.Bytes `json:"raw"`
+	Hash common.Hash   `json:"hash"`
+}
+
+// PrivatePersonalAPI provides an API to access the keys of the node keystore.
+type PrivatePersonalAPI struct {
+	b Backend
+}
+
+// NewPrivatePersonalAPI creates a new personal API.
+func NewPrivatePersonalAPI(b Backend) *PrivatePersonalAPI {
+	return &PrivatePersonalAPI{b}
+}
+
+// ListAccounts returns the keys of the keystore.
+func (api *PrivatePersonalAPI) ListAccounts() []keystore.Account {
+	return api.b.KeyStore().Accounts()
+}
+
+// NewAccount generates a new key bound to the account name and stores it
+// encrypted with the passphrase.
+func (api *PrivatePersonalAPI) NewAccount(name common.Name, passphrase string) (common.PubKey, error) {
+	account, err := api.b.KeyStore().NewAccount(name, passphrase)
+	if err != nil {
+		return common.PubKey{}, err
+	}
+	return account.PublicKey, nil
+}
+
+// ImportRawKey stores the given hex encoded private key into the keystore,
+// encrypted with the passphrase.
+func (api *PrivatePersonalAPI) ImportRawKey(privkey hexutil.Bytes, name common.Name, passphrase string) (common.PubKey, error) {
+	priv, err := crypto.ToECDSA(privkey)
+	if err != nil {
+		return common.PubKey{}, err
+	}
+	account, err := api.b.KeyStore().ImportECDSA(priv, name, passphrase)
+	if err != nil {
+		return common.PubKey{}, err
+	}
+	return account.PublicKey, nil
+}
+
+// SetAccountName binds the key of the public key to the account name.
+func (api *PrivatePersonalAPI) SetAccountName(pubKey common.PubKey, name common.Name, passphrase string) error {
+	return api.b.KeyStore().SetName(pubKey, name, passphrase)
+}
+
+// UnlockAccount unlocks the key of the public key for duration seconds, or
+// 300 seconds if duration is nil. A duration of 0 unlocks the key until the
+// node exits.
+func (api *PrivatePersonalAPI) UnlockAccount(pubKey common.PubKey, passphrase string, duration *uint64) (bool, error) {
+	const max = uint64(time.Duration(1<<63-1) / time.Second)
+	var d time.Duration
+	if duration == nil {
+		d = 300 * time.Second
+	} else if *duration > max {
+		return false, errors.New("unlock duration too large")
+	} else {
+		d = time.Duration(*duration) * time.Second
+	}
+	if err := api.b.KeyStore().TimedUnlock(pubKey, passphrase, d); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LockAccount removes the private key of the public key from memory.
+func (api *PrivatePersonalAPI) LockAccount(pubKey common.PubKey) bool {
+	return api.b.KeyStore().Lock(pubKey) == nil
+}
+
+// newTransaction builds an unsigned transaction from the arguments, the
+// nonce is filled in from the account manager if it is missing.
+func newTransaction(b Backend, args SendTxArgs) (*types.Transaction, error) {
+	if args.GasPrice == nil {
+		return nil, errors.New("gas price not specified")
+	}
+	if args.Amount == nil {
+		args.Amount = big.NewInt(0)
+	}
+	if args.Nonce == nil {
+		am, err := b.GetAccountManager()
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := am.GetNonce(args.From)
+		if err != nil {
+			return nil, err
+		}
+		args.Nonce = &nonce
+	}
+	action := types.NewAction(args.ActionType, args.From, args.To, *args.Nonce, args.AssetID, args.Gas, args.Amount, args.Payload, args.Remark)
+	return types.NewTransaction(args.GasAssetID, args.GasPrice, action), nil
+}
+
+// SignTransaction signs the transaction with the unlocked keys of the
+// keystore and returns the RLP encoded transaction without submitting it.
+func (api *PrivatePersonalAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
+	tx, err := api.signTransaction(args)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{Raw: raw, Hash: tx.Hash()}, nil
+}
+
+// SendTransaction signs the transaction with the unlocked keys of the
+// keystore and submits it to the transaction pool.
+func (api *PrivatePersonalAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
+	tx, err := api.signTransaction(args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, api.b, tx)
+}
+
+// signTransaction builds the transaction from the arguments and signs it
+// with the selected keys, or all the unlocked author keys of the sender.
+func (api *PrivatePersonalAPI) signTransaction(args SendTxArgs) (*types.Transaction, error) {
+	tx, err := newTransaction(api.b, args)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := api.keyPairs(args.From, args.Keys)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.NewSigner(api.b.ChainConfig().ChainID)
+	if err := types.SignActionWithMultiKey(tx.GetActions()[0], tx, signer, args.ParentIndex, pairs); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// keyPairs returns the unlocked private keys of the sign keys. If no key is
+// selected, the unlocked keys that are authors of the account are used.
+func (api *PrivatePersonalAPI) keyPairs(name common.Name, keys []SignKey) ([]*types.KeyPair, error) {
+	if len(keys) == 0 {
+		var err error
+		if keys, err = api.authorKeys(name); err != nil {
+			return nil, err
+		}
+	}
+	pairs := make([]*types.KeyPair, 0, len(keys))
+	for _, key := range keys {
+		priv, err := api.b.KeyStore().UnlockedKey(key.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %v", key.PubKey.String(), err)
+		}
+		pairs = append(pairs, types.MakeKeyPair(priv, key.Index))
+	}
+	return pairs, nil
+}
+
+// authorKeys returns the unlocked keystore keys that are direct public key
+// authors of the account, with their author index.
+func (api *PrivatePersonalAPI) authorKeys(name common.Name) ([]SignKey, error) {
+	am, err := api.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	acct, err := am.GetAccountByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if acct == nil {
+		return nil, fmt.Errorf("account %v not exist", name)
+	}
+	var keys []SignKey
+	for i, author := range acct.Authors {
+		pubKey, ok := author.Owner.(common.PubKey)
+		if !ok {
+			continue
+		}
+		if _, err := api.b.KeyStore().UnlockedKey(pubKey); err != nil {
+			continue
+		}
+		keys = append(keys, SignKey{PubKey: pubKey, Index: []uint64{uint64(i)}})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no unlocked key for account %v", name)
+	}
+	return keys, nil
+}
+
+// SignPSTX appends the signatures of the selected keys, or of all the
+// unlocked author keys of the sender, to the action of the partially signed
+// transaction.
+func (api *PrivatePersonalAPI) SignPSTX(ctx context.Context, pstx hexutil.Bytes, actionIndex int, parentIndex uint64, keys []SignKey) (hexutil.Bytes, error) {
+	p, err := types.DecodePSTX(pstx)
+	if err != nil {
+		return nil, err
+	}
+	actions := p.Tx.GetActions()
+	if actionIndex < 0 || actionIndex >= len(actions) {
+		return nil, fmt.Errorf("invalid action index %d", actionIndex)
+	}
+	pairs, err := api.keyPairs(actions[actionIndex].Sender(), keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Sign(types.NewSigner(api.b.ChainConfig().ChainID), actionIndex, parentIndex, pairs); err != nil {
+		return nil, err
+	}
+	return p.Encode()
+}
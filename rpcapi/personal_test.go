@@ -0,0 +1,251 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/params"
+	"github.com/fractalplatform/fractal/state"
+	"github.com/fractalplatform/fractal/types"
+	memdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// personalBackend serves the account state and the keystore the personal
+// api signs with.
+type personalBackend struct {
+	*testBackend
+
+	am   *accountmanager.AccountManager
+	ks   *keystore.KeyStore
+	sent []*types.Transaction
+}
+
+func (b *personalBackend) GetAccountManager() (*accountmanager.AccountManager, error) {
+	return b.am, nil
+}
+
+func (b *personalBackend) KeyStore() *keystore.KeyStore {
+	return b.ks
+}
+
+func (b *personalBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	b.sent = append(b.sent, signedTx)
+	return nil
+}
+
+const (
+	personalAccount = common.Name("personalsender")
+	personalAuthor  = common.Name("personalauthor")
+)
+
+// newPersonalBackend creates the sender account with an unlocked key as
+// author 0 and the author account as author 1, the key of the author
+// account is imported but locked.
+func newPersonalBackend(t *testing.T, dir string) (b *personalBackend, unlocked, locked common.PubKey) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(memdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := accountmanager.NewAccountManager(statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = &personalBackend{testBackend: newTestBackend(), am: am, ks: ks}
+
+	senderKey, err := ks.NewAccount(personalAccount, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(senderKey.PublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	authorKey, err := ks.NewAccount(personalAuthor, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CreateAccount("fractal.founder", personalAuthor, "", 0, params.ForkID1, authorKey.PublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CreateAccount("fractal.founder", personalAccount, "", 0, params.ForkID1, senderKey.PublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	acct, err := am.GetAccountByName(personalAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := acct.AddAuthor(&common.Author{Owner: personalAuthor, Weight: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.SetAccount(acct); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.SetNonce(personalAccount, 7); err != nil {
+		t.Fatal(err)
+	}
+	return b, senderKey.PublicKey, authorKey.PublicKey
+}
+
+func personalTxArgs() SendTxArgs {
+	return SendTxArgs{
+		ActionType: types.Transfer,
+		From:       personalAccount,
+		To:         personalAuthor,
+		Gas:        30000,
+		Amount:     big.NewInt(1),
+		GasPrice:   big.NewInt(1),
+	}
+}
+
+// checkSigned checks that the action of the transaction recovers to the
+// keys, signed for the index paths.
+func checkSigned(t *testing.T, tx *types.Transaction, keys []SignKey) {
+	action := tx.GetActions()[0]
+	pubKeys, err := types.RecoverMultiKey(types.NewSigner(params.DefaultChainconfig.ChainID), action, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pubKeys) != len(keys) {
+		t.Fatalf("recovered %d keys, want %d", len(pubKeys), len(keys))
+	}
+	for i, key := range keys {
+		if pubKeys[i] != key.PubKey {
+			t.Fatalf("key %d recovered %v, want %v", i, pubKeys[i].String(), key.PubKey.String())
+		}
+		if index := action.GetSignIndex(uint64(i)); !reflect.DeepEqual(index, key.Index) {
+			t.Fatalf("key %d index %v, want %v", i, index, key.Index)
+		}
+	}
+}
+
+func TestPersonalSignTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fractal-personal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b, unlocked, locked := newPersonalBackend(t, dir)
+	api := NewPrivatePersonalAPI(b)
+
+	// the nonce is filled in from the account manager, the unlocked author
+	// keys sign when no key is selected
+	result, err := api.SignTransaction(context.Background(), personalTxArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(result.Raw, tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash() != result.Hash {
+		t.Fatalf("hash %v mismatch %v", result.Hash.Hex(), tx.Hash().Hex())
+	}
+	if nonce := tx.GetActions()[0].Nonce(); nonce != 7 {
+		t.Fatalf("nonce %v mismatch", nonce)
+	}
+	checkSigned(t, tx, []SignKey{{PubKey: unlocked, Index: []uint64{0}}})
+
+	// an explicit nonce is kept
+	args := personalTxArgs()
+	nonce := uint64(9)
+	args.Nonce = &nonce
+	if result, err = api.SignTransaction(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if err := rlp.DecodeBytes(result.Raw, tx); err != nil {
+		t.Fatal(err)
+	}
+	if n := tx.GetActions()[0].Nonce(); n != nonce {
+		t.Fatalf("nonce %v mismatch %v", n, nonce)
+	}
+
+	// the key of the author account signs for its index path once unlocked
+	keys := []SignKey{
+		{PubKey: unlocked, Index: []uint64{0}},
+		{PubKey: locked, Index: []uint64{1, 0}},
+	}
+	args = personalTxArgs()
+	args.Keys = keys
+	if _, err := api.SignTransaction(context.Background(), args); err == nil || !strings.Contains(err.Error(), keystore.ErrLocked.Error()) {
+		t.Fatalf("want locked key error, got %v", err)
+	}
+	if err := b.ks.Unlock(locked, ""); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = api.SignTransaction(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	tx = new(types.Transaction)
+	if err := rlp.DecodeBytes(result.Raw, tx); err != nil {
+		t.Fatal(err)
+	}
+	checkSigned(t, tx, keys)
+
+	// no unlocked author key
+	b.ks.Lock(unlocked)
+	if _, err := api.SignTransaction(context.Background(), personalTxArgs()); err == nil {
+		t.Fatal("signed without an unlocked author key")
+	}
+	if len(b.sent) != 0 {
+		t.Fatalf("signed transactions submitted: %d", len(b.sent))
+	}
+}
+
+func TestPersonalSendTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fractal-personal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b, unlocked, locked := newPersonalBackend(t, dir)
+	api := NewPrivatePersonalAPI(b)
+
+	args := personalTxArgs()
+	args.Keys = []SignKey{{PubKey: locked, Index: []uint64{1, 0}}}
+	if _, err := api.SendTransaction(context.Background(), args); err == nil || !strings.Contains(err.Error(), keystore.ErrLocked.Error()) {
+		t.Fatalf("want locked key error, got %v", err)
+	}
+	if len(b.sent) != 0 {
+		t.Fatalf("unsigned transaction submitted")
+	}
+
+	hash, err := api.SendTransaction(context.Background(), personalTxArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.sent) != 1 || b.sent[0].Hash() != hash {
+		t.Fatalf("transaction %v not submitted", hash.Hex())
+	}
+	if nonce := b.sent[0].GetActions()[0].Nonce(); nonce != 7 {
+		t.Fatalf("nonce %v mismatch", nonce)
+	}
+	checkSigned(t, b.sent[0], []SignKey{{PubKey: unlocked, Index: []uint64{0}}})
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// PSTXActionStatus is the signature status of an action of a partially
+// signed transaction.
+type PSTXActionStatus struct {
+	Index    int                            `json:"index"`
+	From     common.Name                    `json:"from"`
+	Signs    int                            `json:"signs"`
+	Weights  []*accountmanager.AuthorWeight `json:"weights"`
+	Complete bool                           `json:"complete"`
+}
+
+// PSTXStatus is the signature status of a partially signed transaction.
+type PSTXStatus struct {
+	Hash     common.Hash         `json:"hash"`
+	Actions  []*PSTXActionStatus `json:"actions"`
+	Complete bool                `json:"complete"`
+}
+
+// NewPSTX creates an unsigned partially signed transaction, the nonce is
+// filled in from the account manager if it is missing.
+func (s *PublicFractalAPI) NewPSTX(ctx context.Context, args SendTxArgs) (hexutil.Bytes, error) {
+	tx, err := newTransaction(s.b, args)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPSTX(tx).Encode()
+}
+
+// GetPSTXStatus returns the weight reached by the signatures of every action
+// of the partially signed transaction relative to the thresholds.
+func (s *PublicFractalAPI) GetPSTXStatus(ctx context.Context, pstx hexutil.Bytes) (*PSTXStatus, error) {
+	p, err := types.DecodePSTX(pstx)
+	if err != nil {
+		return nil, err
+	}
+	am, err := s.b.GetAccountManager()
+	if err != nil {
+		return nil, err
+	}
+	signer := types.NewSigner(s.b.ChainConfig().ChainID)
+	status := &PSTXStatus{Hash: p.Tx.Hash(), Complete: true}
+	for i, action := range p.Tx.GetActions() {
+		weights, err := am.SignWeights(signer, action, p.Tx)
+		if err != nil {
+			return nil, err
+		}
+		complete := len(action.GetSign()) > 0
+		for _, w := range weights {
+			if w.Weight < w.Threshold {
+				complete = false
+			}
+		}
+		status.Actions = append(status.Actions, &PSTXActionStatus{
+			Index:    i,
+			From:     action.Sender(),
+			Signs:    len(action.GetSign()),
+			Weights:  weights,
+			Complete: complete,
+		})
+		status.Complete = status.Complete && complete
+	}
+	return status, nil
+}
+
+// MergePSTX combines the signatures of partially signed transactions of the
+// same transaction, signed in parallel by different authors.
+func (s *PublicFractalAPI) MergePSTX(ctx context.Context, pstxs []hexutil.Bytes) (hexutil.Bytes, error) {
+	if len(pstxs) == 0 {
+		return nil, errors.New("no pstx to merge")
+	}
+	p, err := types.DecodePSTX(pstxs[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, data := range pstxs[1:] {
+		other, err := types.DecodePSTX(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Merge(other); err != nil {
+			return nil, err
+		}
+	}
+	return p.Encode()
+}
+
+// SendPSTX submits the partially signed transaction once the signatures of
+// every action reach the thresholds.
+func (s *PublicFractalAPI) SendPSTX(ctx context.Context, pstx hexutil.Bytes) (common.Hash, error) {
+	status, err := s.GetPSTXStatus(ctx, pstx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !status.Complete {
+		return common.Hash{}, errors.New("pstx signatures below threshold")
+	}
+	p, err := types.DecodePSTX(pstx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, p.Tx)
+}
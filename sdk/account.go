@@ -224,6 +224,355 @@ func (acc *Account) UpdateAccountAuthor(to common.Name, value *big.Int, id uint6
 	return
 }
 
+// DeleteAccount delete the account, its balances are swept to the beneficiary
+func (acc *Account) DeleteAccount(to common.Name, value *big.Int, id uint64, gas uint64, delacct *accountmanager.DeleteAccountAction) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(delacct)
+	action := types.NewAction(types.DeleteAccount, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkDeleteAccount(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// SetSponsorship set the limits of the transactions whose gas is paid by the account
+func (acc *Account) SetSponsorship(to common.Name, value *big.Int, id uint64, gas uint64, sponsorship *accountmanager.SponsorshipAction) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(sponsorship)
+	action := types.NewAction(types.SetSponsorship, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkSetSponsorship(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// SetRecovery set the recovery guardians of the account
+func (acc *Account) SetRecovery(to common.Name, value *big.Int, id uint64, gas uint64, config *accountmanager.RecoveryConfig) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(config)
+	action := types.NewAction(types.SetRecovery, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkSetRecovery(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// ProposeRecovery propose the new authors of an account as its guardian
+func (acc *Account) ProposeRecovery(to common.Name, value *big.Int, id uint64, gas uint64, proposal *accountmanager.RecoveryAction) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(proposal)
+	action := types.NewAction(types.ProposeRecovery, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkProposeRecovery(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// CancelRecovery cancel the pending recovery of the account
+func (acc *Account) CancelRecovery(to common.Name, value *big.Int, id uint64, gas uint64) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	action := types.NewAction(types.CancelRecovery, acc.name, to, nonce, id, gas, value, nil, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkCancelRecovery(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// ExecuteRecovery apply the recovery of an account once its delay is over
+func (acc *Account) ExecuteRecovery(to common.Name, value *big.Int, id uint64, gas uint64, execute *accountmanager.ExecuteRecoveryAction) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(execute)
+	action := types.NewAction(types.ExecuteRecovery, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkExecuteRecovery(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// TransferLocked transfer tokens locked by a vesting schedule
+func (acc *Account) TransferLocked(to common.Name, value *big.Int, id uint64, gas uint64, lock *accountmanager.TransferLockedAction) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	bts, _ := rlp.EncodeToBytes(lock)
+	action := types.NewAction(types.TransferLocked, acc.name, to, nonce, id, gas, value, bts, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkTransferLocked(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
 // Transfer transfer tokens
 func (acc *Account) Transfer(to common.Name, value *big.Int, id uint64, gas uint64) (hash common.Hash, err error) {
 	nonce := acc.nonce
@@ -234,10 +583,165 @@ func (acc *Account) Transfer(to common.Name, value *big.Int, id uint64, gas uint
 		}
 	}
 
-	// transfer
-	action := types.NewAction(types.Transfer, acc.name, to, nonce, id, gas, value, nil, nil)
+	// transfer
+	action := types.NewAction(types.Transfer, acc.name, to, nonce, id, gas, value, nil, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		//before
+		checkedfunc, err = acc.checkTranfer(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// IssueAsset new asset
+func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.IssueAsset) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(asset)
+	action := types.NewAction(types.IssueAsset, acc.name, to, nonce, id, gas, value, payload, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkIssueAsset(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// UpdateAsset update asset
+func (acc *Account) UpdateAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.UpdateAsset) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(asset)
+	action := types.NewAction(types.UpdateAsset, acc.name, to, nonce, id, gas, value, payload, nil)
+	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		return
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkUpdateAsset(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		//after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// IncreaseAsset update asset
+func (acc *Account) IncreaseAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.IncAsset) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(asset)
+	action := types.NewAction(types.IncreaseAsset, acc.name, to, nonce, id, gas, value, payload, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
 	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
 	if err != nil {
 		return
@@ -246,8 +750,8 @@ func (acc *Account) Transfer(to common.Name, value *big.Int, id uint64, gas uint
 	checked := acc.checked || acc.nonce == math.MaxUint64
 	var checkedfunc func() error
 	if checked {
-		//before
-		checkedfunc, err = acc.checkTranfer(action)
+		// before
+		checkedfunc, err = acc.checkIncreaseAsset(action)
 		if err != nil {
 			return
 		}
@@ -274,8 +778,8 @@ func (acc *Account) Transfer(to common.Name, value *big.Int, id uint64, gas uint
 	return
 }
 
-// IssueAsset new asset
-func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.IssueAsset) (hash common.Hash, err error) {
+// DestroyAsset destory asset
+func (acc *Account) DestroyAsset(to common.Name, value *big.Int, id uint64, gas uint64) (hash common.Hash, err error) {
 	nonce := acc.nonce
 	if nonce == math.MaxUint64 {
 		nonce, err = acc.api.AccountNonce(acc.name.String())
@@ -284,10 +788,10 @@ func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas ui
 		}
 	}
 
-	payload, _ := rlp.EncodeToBytes(asset)
-	action := types.NewAction(types.IssueAsset, acc.name, to, nonce, id, gas, value, payload, nil)
+	action := types.NewAction(types.DestroyAsset, acc.name, to, nonce, id, gas, value, nil, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
 	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
 	if err != nil {
 		return
@@ -297,7 +801,7 @@ func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas ui
 	var checkedfunc func() error
 	if checked {
 		// before
-		checkedfunc, err = acc.checkIssueAsset(action)
+		checkedfunc, err = acc.checkDestroyAsset(action)
 		if err != nil {
 			return
 		}
@@ -317,9 +821,6 @@ func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas ui
 			return
 		}
 	}
-	if err != nil {
-		return
-	}
 
 	if acc.nonce != math.MaxUint64 {
 		acc.nonce++
@@ -327,8 +828,8 @@ func (acc *Account) IssueAsset(to common.Name, value *big.Int, id uint64, gas ui
 	return
 }
 
-// UpdateAsset update asset
-func (acc *Account) UpdateAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.UpdateAsset) (hash common.Hash, err error) {
+// SetAssetOwner update asset owner
+func (acc *Account) SetAssetOwner(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.UpdateAssetOwner) (hash common.Hash, err error) {
 	nonce := acc.nonce
 	if nonce == math.MaxUint64 {
 		nonce, err = acc.api.AccountNonce(acc.name.String())
@@ -338,7 +839,7 @@ func (acc *Account) UpdateAsset(to common.Name, value *big.Int, id uint64, gas u
 	}
 
 	payload, _ := rlp.EncodeToBytes(asset)
-	action := types.NewAction(types.UpdateAsset, acc.name, to, nonce, id, gas, value, payload, nil)
+	action := types.NewAction(types.SetAssetOwner, acc.name, to, nonce, id, gas, value, payload, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
 
@@ -351,7 +852,7 @@ func (acc *Account) UpdateAsset(to common.Name, value *big.Int, id uint64, gas u
 	var checkedfunc func() error
 	if checked {
 		// before
-		checkedfunc, err = acc.checkUpdateAsset(action)
+		checkedfunc, err = acc.checkSetAssetOwner(action)
 		if err != nil {
 			return
 		}
@@ -378,8 +879,8 @@ func (acc *Account) UpdateAsset(to common.Name, value *big.Int, id uint64, gas u
 	return
 }
 
-// IncreaseAsset update asset
-func (acc *Account) IncreaseAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.IncAsset) (hash common.Hash, err error) {
+// FreezeAsset freeze or unfreeze the asset balance of an account
+func (acc *Account) FreezeAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.FreezeAsset) (hash common.Hash, err error) {
 	nonce := acc.nonce
 	if nonce == math.MaxUint64 {
 		nonce, err = acc.api.AccountNonce(acc.name.String())
@@ -389,7 +890,7 @@ func (acc *Account) IncreaseAsset(to common.Name, value *big.Int, id uint64, gas
 	}
 
 	payload, _ := rlp.EncodeToBytes(asset)
-	action := types.NewAction(types.IncreaseAsset, acc.name, to, nonce, id, gas, value, payload, nil)
+	action := types.NewAction(types.FreezeAsset, acc.name, to, nonce, id, gas, value, payload, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
 
@@ -402,7 +903,7 @@ func (acc *Account) IncreaseAsset(to common.Name, value *big.Int, id uint64, gas
 	var checkedfunc func() error
 	if checked {
 		// before
-		checkedfunc, err = acc.checkIncreaseAsset(action)
+		checkedfunc, err = acc.checkFreezeAsset(action)
 		if err != nil {
 			return
 		}
@@ -429,8 +930,8 @@ func (acc *Account) IncreaseAsset(to common.Name, value *big.Int, id uint64, gas
 	return
 }
 
-// DestroyAsset destory asset
-func (acc *Account) DestroyAsset(to common.Name, value *big.Int, id uint64, gas uint64) (hash common.Hash, err error) {
+// PauseAsset pause or resume the transfers of an asset
+func (acc *Account) PauseAsset(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.PauseAsset) (hash common.Hash, err error) {
 	nonce := acc.nonce
 	if nonce == math.MaxUint64 {
 		nonce, err = acc.api.AccountNonce(acc.name.String())
@@ -439,7 +940,8 @@ func (acc *Account) DestroyAsset(to common.Name, value *big.Int, id uint64, gas
 		}
 	}
 
-	action := types.NewAction(types.DestroyAsset, acc.name, to, nonce, id, gas, value, nil, nil)
+	payload, _ := rlp.EncodeToBytes(asset)
+	action := types.NewAction(types.PauseAsset, acc.name, to, nonce, id, gas, value, payload, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
 
@@ -452,7 +954,7 @@ func (acc *Account) DestroyAsset(to common.Name, value *big.Int, id uint64, gas
 	var checkedfunc func() error
 	if checked {
 		// before
-		checkedfunc, err = acc.checkDestroyAsset(action)
+		checkedfunc, err = acc.checkPauseAsset(action)
 		if err != nil {
 			return
 		}
@@ -479,8 +981,8 @@ func (acc *Account) DestroyAsset(to common.Name, value *big.Int, id uint64, gas
 	return
 }
 
-// SetAssetOwner update asset owner
-func (acc *Account) SetAssetOwner(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.UpdateAssetOwner) (hash common.Hash, err error) {
+// SetAssetWhitelist update the whitelist mode and whitelisted accounts of an asset
+func (acc *Account) SetAssetWhitelist(to common.Name, value *big.Int, id uint64, gas uint64, asset *accountmanager.AssetWhitelist) (hash common.Hash, err error) {
 	nonce := acc.nonce
 	if nonce == math.MaxUint64 {
 		nonce, err = acc.api.AccountNonce(acc.name.String())
@@ -490,7 +992,7 @@ func (acc *Account) SetAssetOwner(to common.Name, value *big.Int, id uint64, gas
 	}
 
 	payload, _ := rlp.EncodeToBytes(asset)
-	action := types.NewAction(types.SetAssetOwner, acc.name, to, nonce, id, gas, value, payload, nil)
+	action := types.NewAction(types.SetAssetWhitelist, acc.name, to, nonce, id, gas, value, payload, nil)
 	tx := types.NewTransaction(acc.feeid, acc.gasprice, []*types.Action{action}...)
 	key := types.MakeKeyPair(acc.priv, []uint64{0})
 
@@ -503,7 +1005,7 @@ func (acc *Account) SetAssetOwner(to common.Name, value *big.Int, id uint64, gas
 	var checkedfunc func() error
 	if checked {
 		// before
-		checkedfunc, err = acc.checkSetAssetOwner(action)
+		checkedfunc, err = acc.checkSetAssetWhitelist(action)
 		if err != nil {
 			return
 		}
@@ -783,6 +1285,209 @@ func (acc *Account) VoteCandidate(to common.Name, value *big.Int, id uint64, gas
 	return
 }
 
+// UnvoteCandidate unvote candidate
+func (acc *Account) UnvoteCandidate(to common.Name, value *big.Int, id uint64, gas uint64, arg *dpos.UnvoteCandidate) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(arg)
+	action := types.NewAction(types.UnvoteCandidate, acc.name, to, nonce, id, gas, value, payload, nil)
+	tx := types.NewTransaction(acc.feeid, big.NewInt(1e10), []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		panic(err)
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkUnvoteCandidate(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		// after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// ChangeVote change vote to another candidate
+func (acc *Account) ChangeVote(to common.Name, value *big.Int, id uint64, gas uint64, arg *dpos.ChangeVote) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(arg)
+	action := types.NewAction(types.ChangeVote, acc.name, to, nonce, id, gas, value, payload, nil)
+	tx := types.NewTransaction(acc.feeid, big.NewInt(1e10), []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		panic(err)
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkChangeVote(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		// after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// ClaimReward claim voter reward
+func (acc *Account) ClaimReward(to common.Name, value *big.Int, id uint64, gas uint64, arg *dpos.ClaimReward) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	payload, _ := rlp.EncodeToBytes(arg)
+	action := types.NewAction(types.ClaimReward, acc.name, to, nonce, id, gas, value, payload, nil)
+	tx := types.NewTransaction(acc.feeid, big.NewInt(1e10), []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		panic(err)
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkClaimReward(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		// after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
+// UnjailCandidate unjail candidate
+func (acc *Account) UnjailCandidate(to common.Name, value *big.Int, id uint64, gas uint64) (hash common.Hash, err error) {
+	nonce := acc.nonce
+	if nonce == math.MaxUint64 {
+		nonce, err = acc.api.AccountNonce(acc.name.String())
+		if err != nil {
+			return
+		}
+	}
+
+	action := types.NewAction(types.UnjailCandidate, acc.name, to, nonce, id, gas, value, nil, nil)
+	tx := types.NewTransaction(acc.feeid, big.NewInt(1e10), []*types.Action{action}...)
+	key := types.MakeKeyPair(acc.priv, []uint64{0})
+
+	err = types.SignActionWithMultiKey(action, tx, types.NewSigner(acc.chainID), 0, []*types.KeyPair{key})
+	if err != nil {
+		panic(err)
+	}
+	rawtx, _ := rlp.EncodeToBytes(tx)
+	checked := acc.checked || acc.nonce == math.MaxUint64
+	var checkedfunc func() error
+	if checked {
+		// before
+		checkedfunc, err = acc.checkUnjailCandidate(action)
+		if err != nil {
+			return
+		}
+	}
+	hash, err = acc.api.SendRawTransaction(rawtx)
+	if err != nil {
+		return
+	}
+	if checked {
+		// after
+		err = acc.utilReceipt(hash, timeout)
+		if err != nil {
+			return
+		}
+		err = checkedfunc()
+		if err != nil {
+			return
+		}
+	}
+
+	if acc.nonce != math.MaxUint64 {
+		acc.nonce++
+	}
+	return
+}
+
 // KickedCandidate kicked candidates
 func (acc *Account) KickedCandidate(to common.Name, value *big.Int, id uint64, gas uint64, arg *dpos.KickedCandidate) (hash common.Hash, err error) {
 	nonce := acc.nonce
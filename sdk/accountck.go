@@ -60,6 +60,20 @@ func (acc *Account) checkUpdateAccount(action *types.Action) (func() error, erro
 	return function, nil
 }
 
+func (acc *Account) checkDeleteAccount(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkSetSponsorship(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
 func (acc *Account) checkTranfer(action *types.Action) (func() error, error) {
 	// TODO
 	function := func() error {
@@ -68,6 +82,41 @@ func (acc *Account) checkTranfer(action *types.Action) (func() error, error) {
 	return function, nil
 }
 
+func (acc *Account) checkSetRecovery(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkProposeRecovery(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkCancelRecovery(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkExecuteRecovery(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkTransferLocked(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
 func (acc *Account) checkIssueAsset(action *types.Action) (func() error, error) {
 	// TODO
 	function := func() error {
@@ -108,6 +157,27 @@ func (acc *Account) checkSetAssetOwner(action *types.Action) (func() error, erro
 	return function, nil
 }
 
+func (acc *Account) checkFreezeAsset(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkPauseAsset(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkSetAssetWhitelist(action *types.Action) (func() error, error) {
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
 func (acc *Account) chekRegProdoucer(action *types.Action) (func() error, error) {
 	// TODO
 	function := func() error {
@@ -148,6 +218,38 @@ func (acc *Account) chekVoteProdoucer(action *types.Action) (func() error, error
 	return function, nil
 }
 
+func (acc *Account) checkUnvoteCandidate(action *types.Action) (func() error, error) {
+	// TODO
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkChangeVote(action *types.Action) (func() error, error) {
+	// TODO
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkClaimReward(action *types.Action) (func() error, error) {
+	// TODO
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
+func (acc *Account) checkUnjailCandidate(action *types.Action) (func() error, error) {
+	// TODO
+	function := func() error {
+		return nil
+	}
+	return function, nil
+}
+
 func (acc *Account) chekKickedCandidate(action *types.Action) (func() error, error) {
 	// TODO
 	function := func() error {
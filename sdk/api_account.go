@@ -78,3 +78,31 @@ func (api *API) BalanceByAssetID(name string, id uint64, typeID uint64) (*big.In
 	err := api.client.Call(balance, "account_getAccountBalanceByID", name, id, typeID)
 	return balance, err
 }
+
+// VestingSchedules get the vesting schedules of the account in the asset
+func (api *API) VestingSchedules(name string, id uint64) ([]*accountmanager.VestingSchedule, error) {
+	var schedules []*accountmanager.VestingSchedule
+	err := api.client.Call(&schedules, "account_getVestingSchedules", name, id)
+	return schedules, err
+}
+
+// LockedBalance get the balance of the account in the asset not released yet
+func (api *API) LockedBalance(name string, id uint64) (*big.Int, error) {
+	balance := big.NewInt(0)
+	err := api.client.Call(balance, "account_getLockedBalance", name, id)
+	return balance, err
+}
+
+// RecoveryConfig get the recovery guardians of the account
+func (api *API) RecoveryConfig(name string) (*accountmanager.RecoveryConfig, error) {
+	var config *accountmanager.RecoveryConfig
+	err := api.client.Call(&config, "account_getRecoveryConfig", name)
+	return config, err
+}
+
+// RecoveryRequest get the pending recovery request of the account
+func (api *API) RecoveryRequest(name string) (*accountmanager.RecoveryRequest, error) {
+	var request *accountmanager.RecoveryRequest
+	err := api.client.Call(&request, "account_getRecoveryRequest", name)
+	return request, err
+}
@@ -115,3 +115,10 @@ func (api *API) DposSnapShotTime(epoch uint64) (map[string]interface{}, error) {
 	err := api.client.Call(&info, "dpos_snapShotTime", epoch)
 	return info, err
 }
+
+// DposVoterReward get the reward the voter can claim
+func (api *API) DposVoterReward(epoch uint64, voter string) (*big.Int, error) {
+	reward := big.NewInt(0)
+	err := api.client.Call(&reward, "dpos_voterReward", epoch, voter)
+	return reward, err
+}
@@ -89,3 +89,10 @@ func (api *API) GetChainConfig() (*params.ChainConfig, error) {
 // 	err := api.client.Call(cfg, "ft_getGenesis")
 // 	return cfg, err
 // }
+
+// GetProof get the merkle proofs of the account and of its storage slots
+func (api *API) GetProof(name string, keys []common.Hash, number int64) (*AccountProof, error) {
+	proof := &AccountProof{}
+	err := api.client.Call(proof, "ft_getProof", common.Name(name), keys, rpc.BlockNumber(number))
+	return proof, err
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sdk
+
+import (
+	"fmt"
+
+	"github.com/fractalplatform/fractal/accountmanager"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// AccountProof is the merkle proof of an account returned by ft_getProof.
+type AccountProof struct {
+	BlockHash common.Hash `json:"blockHash"`
+	Number    uint64      `json:"number"`
+	StateRoot common.Hash `json:"stateRoot"`
+	*accountmanager.AccountProof
+}
+
+// Verify checks the proof against the state root of a trusted header, such
+// as the header of an irreversible block. accountManagerName is the account
+// manager name of the chain config. It returns the proved account with its
+// balances, nil if the proof shows the account doesn't exist.
+func (p *AccountProof) Verify(header *types.Header, accountManagerName string) (*accountmanager.Account, error) {
+	if hash := header.Hash(); hash != p.BlockHash {
+		return nil, fmt.Errorf("proof of block %v, header %v", p.BlockHash.Hex(), hash.Hex())
+	}
+	if p.AccountProof == nil {
+		return nil, fmt.Errorf("empty proof")
+	}
+	return accountmanager.VerifyAccountProof(header.Root, accountManagerName, p.AccountProof)
+}
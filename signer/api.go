@@ -0,0 +1,100 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/rpc"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// API is the signer rpc service, the header is passed rlp encoded.
+type API struct {
+	s *Signer
+}
+
+// NewAPI creates the signer rpc service.
+func NewAPI(s *Signer) *API {
+	return &API{s}
+}
+
+// APIs returns the rpc apis of the signer.
+func (s *Signer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "signer",
+			Version:   "1.0",
+			Service:   NewAPI(s),
+			Public:    true,
+		},
+	}
+}
+
+// PubKeys returns the keys able to sign.
+func (api *API) PubKeys() []common.PubKey {
+	return api.s.PubKeys()
+}
+
+// SignHeader returns the seal of the rlp encoded header.
+func (api *API) SignHeader(pubKey common.PubKey, data hexutil.Bytes) (hexutil.Bytes, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		return nil, err
+	}
+	return api.s.SignHeader(pubKey, header)
+}
+
+// Client is a connection to a remote signer.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects to the signer at endpoint, the unix socket path of the signer.
+func Dial(endpoint string) (*Client, error) {
+	c, err := rpc.DialIPC(context.Background(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c}, nil
+}
+
+// PubKeys returns the keys the remote signer is able to sign with.
+func (c *Client) PubKeys() ([]common.PubKey, error) {
+	var pubKeys []common.PubKey
+	err := c.c.CallContext(context.Background(), &pubKeys, "signer_pubKeys")
+	return pubKeys, err
+}
+
+// SignHeader asks the remote signer for the seal of the header.
+func (c *Client) SignHeader(pubKey common.PubKey, header *types.Header) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	var sign hexutil.Bytes
+	err = c.c.CallContext(context.Background(), &sign, "signer_signHeader", pubKey, hexutil.Bytes(data))
+	return sign, err
+}
+
+// Close closes the connection.
+func (c *Client) Close() {
+	c.c.Close()
+}
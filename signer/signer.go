@@ -0,0 +1,170 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package signer implements a block signing service holding the producer keys
+// outside of the networked node.
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/types"
+)
+
+var (
+	// ErrDoubleSign is returned when signing a header conflicting with a
+	// header already signed by the same key.
+	ErrDoubleSign = errors.New("refuse to double sign")
+	// ErrInvalidHeader is returned when the header has no room for the seal.
+	ErrInvalidHeader = errors.New("invalid header extra")
+	// ErrInvalidSlot is returned when the header isn't at the current slot,
+	// or is higher than the slots passed since the last signed header allow.
+	ErrInvalidSlot = errors.New("header not at the current slot")
+)
+
+// extraSeal is the length of the seal appended to the header extra.
+const extraSeal = 65
+
+// SignedBlock is the last block signed by a key.
+type SignedBlock struct {
+	Number uint64      `json:"number"`
+	Time   uint64      `json:"time"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// Signer signs block headers with the unlocked keys of a keystore. It keeps
+// the last block signed by every key on disk and refuses to sign another
+// block at the same or a lower height or slot. As a signed header raises
+// that mark, the header must also be at the current slot of the signer
+// clock, and at most one block higher per slot than the last signed one.
+type Signer struct {
+	mu       sync.Mutex
+	ks       *keystore.KeyStore
+	path     string
+	interval uint64
+	now      func() uint64
+	signed   map[common.PubKey]*SignedBlock
+}
+
+// New creates a signer persisting the signed blocks to the file at path.
+// blockInterval is the dpos block interval in nanoseconds, zero disables
+// the slot checks for chains off the wall clock.
+func New(ks *keystore.KeyStore, path string, blockInterval uint64) (*Signer, error) {
+	s := &Signer{
+		ks:       ks,
+		path:     path,
+		interval: blockInterval,
+		now:      func() uint64 { return uint64(time.Now().UnixNano()) },
+		signed:   make(map[common.PubKey]*SignedBlock),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.signed); err != nil {
+		return nil, fmt.Errorf("invalid signed blocks file %v: %v", path, err)
+	}
+	return s, nil
+}
+
+// PubKeys returns the unlocked keys that are able to sign.
+func (s *Signer) PubKeys() []common.PubKey {
+	var pubKeys []common.PubKey
+	for _, account := range s.ks.Accounts() {
+		if s.ks.Unlocked(account.PublicKey) {
+			pubKeys = append(pubKeys, account.PublicKey)
+		}
+	}
+	return pubKeys
+}
+
+// SignHeader returns the seal of the header signed with the key of pubKey.
+func (s *Signer) SignHeader(pubKey common.PubKey, header *types.Header) ([]byte, error) {
+	if len(header.Extra) < extraSeal {
+		return nil, ErrInvalidHeader
+	}
+	block := &SignedBlock{
+		Number: header.Number.Uint64(),
+		Time:   header.Time.Uint64(),
+		Hash:   dpos.SealHash(header),
+	}
+
+	if now := s.now(); s.interval != 0 && (block.Time+s.interval < now || block.Time > now+s.interval) {
+		return nil, fmt.Errorf("%v: time %v, now %v", ErrInvalidSlot, block.Time, now)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.signed[pubKey]; ok && last.Hash != block.Hash {
+		if block.Number <= last.Number || block.Time <= last.Time {
+			return nil, fmt.Errorf("%v: number %v time %v, last signed number %v time %v", ErrDoubleSign, block.Number, block.Time, last.Number, last.Time)
+		}
+		if s.interval != 0 && block.Number-last.Number > (block.Time-last.Time)/s.interval {
+			return nil, fmt.Errorf("%v: number %v time %v, last signed number %v time %v", ErrInvalidSlot, block.Number, block.Time, last.Number, last.Time)
+		}
+	}
+	sign, err := s.ks.SignHash(pubKey, block.Hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// the signature is only released once the signed block is persisted
+	prev := s.signed[pubKey]
+	s.signed[pubKey] = block
+	if err := s.save(); err != nil {
+		if prev != nil {
+			s.signed[pubKey] = prev
+		} else {
+			delete(s.signed, pubKey)
+		}
+		return nil, err
+	}
+	return sign, nil
+}
+
+func (s *Signer) save() error {
+	data, err := json.MarshalIndent(s.signed, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/consensus/dpos"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/fractalplatform/fractal/keystore"
+	"github.com/fractalplatform/fractal/types"
+	"github.com/fractalplatform/fractal/utils/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func testHeader(number, time uint64, root byte) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Time:       new(big.Int).SetUint64(time),
+		Difficulty: big.NewInt(1),
+		Root:       common.BytesToHash([]byte{root}),
+		Extra:      make([]byte, extraSeal),
+	}
+}
+
+func TestSignHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fractal-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks, err := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	assert.NoError(t, err)
+	account, err := ks.NewAccount("testproducer", "foo")
+	assert.NoError(t, err)
+
+	// the clock of the signer, with a block interval of 3000
+	now := uint64(3000)
+	clock := func() uint64 { return now }
+	path := filepath.Join(dir, "signed_blocks.json")
+	s, err := New(ks, path, 3000)
+	assert.NoError(t, err)
+	s.now = clock
+	assert.Empty(t, s.PubKeys())
+
+	header := testHeader(10, 3000, 1)
+	_, err = s.SignHeader(account.PublicKey, header)
+	assert.Equal(t, keystore.ErrLocked, err)
+
+	assert.NoError(t, ks.Unlock(account.PublicKey, "foo"))
+	assert.Equal(t, []common.PubKey{account.PublicKey}, s.PubKeys())
+
+	// the header travels rlp encoded to the signer
+	data, err := rlp.EncodeToBytes(header)
+	assert.NoError(t, err)
+	sign, err := NewAPI(s).SignHeader(account.PublicKey, data)
+	assert.NoError(t, err)
+	pubKey, err := crypto.Ecrecover(dpos.SealHash(header).Bytes(), sign)
+	assert.NoError(t, err)
+	assert.Equal(t, account.PublicKey, common.BytesToPubKey(pubKey))
+
+	// signing the same block again is allowed
+	_, err = s.SignHeader(account.PublicKey, header)
+	assert.NoError(t, err)
+
+	// another block at the same height, at the same slot or below is refused
+	_, err = s.SignHeader(account.PublicKey, testHeader(10, 6000, 2))
+	assert.Error(t, err)
+	_, err = s.SignHeader(account.PublicKey, testHeader(11, 3000, 2))
+	assert.Error(t, err)
+	_, err = s.SignHeader(account.PublicKey, testHeader(9, 0, 2))
+	assert.Error(t, err)
+
+	// the guard survives a restart
+	now = 6000
+	reopened, err := New(ks, path, 3000)
+	assert.NoError(t, err)
+	reopened.now = clock
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(10, 6000, 2))
+	assert.Error(t, err)
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(11, 6000, 2))
+	assert.NoError(t, err)
+
+	_, err = reopened.SignHeader(account.PublicKey, &types.Header{Number: big.NewInt(12), Time: big.NewInt(9000)})
+	assert.Equal(t, ErrInvalidHeader, err)
+
+	// a header off the current slot, or higher than the slots passed allow,
+	// is refused and doesn't raise the mark
+	now = 9000
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(12, 60000, 3))
+	assert.Error(t, err)
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(12, 3000, 3))
+	assert.Error(t, err)
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(20, 9000, 3))
+	assert.Error(t, err)
+	_, err = reopened.SignHeader(account.PublicKey, testHeader(12, 9000, 3))
+	assert.NoError(t, err)
+}
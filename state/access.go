@@ -0,0 +1,132 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/types"
+)
+
+// AccessSet records the keys read and written by an execution against a
+// StateDB, the value of every read key is the one first seen, before any
+// write of the execution itself.
+type AccessSet struct {
+	reads  map[string][]byte
+	writes map[string]struct{}
+}
+
+// NewAccessSet returns an empty access set.
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		reads:  make(map[string][]byte),
+		writes: make(map[string]struct{}),
+	}
+}
+
+func (a *AccessSet) read(key string, value []byte) {
+	if a == nil {
+		return
+	}
+	if _, exist := a.reads[key]; !exist {
+		a.reads[key] = common.CopyBytes(value)
+	}
+}
+
+// Overlap returns the reads of the set on the keys also read by b.
+func (a *AccessSet) Overlap(b *AccessSet) *AccessSet {
+	overlap := NewAccessSet()
+	for key, value := range a.reads {
+		if _, exist := b.reads[key]; exist {
+			overlap.reads[key] = value
+		}
+	}
+	return overlap
+}
+
+// TrackAccess records in a the keys accessed from now on, nil stops the
+// recording.
+func (s *StateDB) TrackAccess(a *AccessSet) {
+	s.access = a
+}
+
+// Access returns the access set being recorded, nil if untracked.
+func (s *StateDB) Access() *AccessSet {
+	return s.access
+}
+
+// Validate reports whether every key read in a still has the value
+// recorded, so that an execution against it would read the same state.
+func (s *StateDB) Validate(a *AccessSet) bool {
+	for key, value := range a.reads {
+		current, _ := s.get(key)
+		if !bytes.Equal(current, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Speculate returns a copy of the state for the speculative execution of a
+// transaction, possibly on another goroutine while no one modifies s. The
+// copy has its own trie and records its accesses, its changes are applied
+// to s with Merge.
+func (s *StateDB) Speculate() *StateDB {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := &StateDB{
+		db:         s.db,
+		trie:       s.db.CopyTrie(s.trie),
+		readSet:    make(map[string][]byte),
+		writeSet:   make(map[string][]byte, len(s.writeSet)),
+		dirtySet:   make(map[string]struct{}),
+		logs:       make(map[common.Hash][]*types.Log),
+		preimages:  make(map[common.Hash][]byte),
+		journal:    newJournal(),
+		stateTrace: s.stateTrace,
+		access:     NewAccessSet(),
+	}
+	for key, value := range s.writeSet {
+		state.writeSet[key] = value
+	}
+	return state
+}
+
+// Merge applies to s the reads, writes, logs and preimages of the
+// speculative state spec, whose reads must have been validated against s.
+// The logs are added to the transaction prepared in s.
+func (s *StateDB) Merge(spec *StateDB) {
+	for key, value := range spec.readSet {
+		if _, exist := s.writeSet[key]; !exist {
+			s.readSet[key] = common.CopyBytes(value)
+			s.writeSet[key] = common.CopyBytes(value)
+		}
+	}
+	for key := range spec.access.writes {
+		s.put(key, spec.writeSet[key])
+	}
+	for _, log := range spec.logs[spec.thash] {
+		s.AddLog(log)
+	}
+	for hash, preimage := range spec.preimages {
+		if _, exist := s.preimages[hash]; !exist {
+			s.AddPreimage(hash, preimage)
+		}
+	}
+}
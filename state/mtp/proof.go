@@ -95,7 +95,7 @@ func (t *Trie) Prove(key []byte, fromLevel uint, proofDb fdb.Putter) error {
 // nodes of the longest existing prefix of the key (at least the root node), ending
 // with the node that proves the absence of the key.
 func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb fdb.Putter) error {
-	return t.trie.Prove(key, fromLevel, proofDb)
+	return t.trie.Prove(t.hashKey(key), fromLevel, proofDb)
 }
 
 // VerifyProof checks merkle proofs. The given proof must contain the value for
@@ -23,9 +23,12 @@ import (
 	"sync"
 
 	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
 	"github.com/fractalplatform/fractal/rawdb"
+	trie "github.com/fractalplatform/fractal/state/mtp"
 	"github.com/fractalplatform/fractal/types"
 	"github.com/fractalplatform/fractal/utils/fdb"
+	memdb "github.com/fractalplatform/fractal/utils/fdb/memdb"
 )
 
 type revision struct {
@@ -65,6 +68,8 @@ type StateDB struct {
 
 	stateTrace bool // replay transaction, true is replayed , false is not replayed
 
+	access *AccessSet // keys accessed by a tracked execution, nil if untracked
+
 	lock sync.Mutex
 }
 
@@ -201,6 +206,9 @@ func (s *StateDB) set(key string, value []byte) {
 
 func (s *StateDB) put(key string, value []byte) {
 	oldValue, _ := s.get(key)
+	if s.access != nil {
+		s.access.writes[key] = struct{}{}
+	}
 	s.journal.append(stateChange{key: &key,
 		prevalue: oldValue})
 	s.set(key, value)
@@ -209,6 +217,7 @@ func (s *StateDB) put(key string, value []byte) {
 //get return nil when key not exsit
 func (s *StateDB) get(key string) ([]byte, error) {
 	if value, exsit := s.writeSet[key]; exsit {
+		s.access.read(key, value)
 		return common.CopyBytes(value), nil
 	}
 
@@ -221,6 +230,7 @@ func (s *StateDB) get(key string) ([]byte, error) {
 	}
 
 	value, err := s.trie.TryGet([]byte(key))
+	s.access.read(key, value)
 	if len(value) == 0 {
 		s.setError(err)
 		return nil, err
@@ -334,6 +344,46 @@ func (s *StateDB) Delete(account string, key string) {
 	s.put(optKey, nil)
 }
 
+// DataKey returns the trie key of the account data stored with Put.
+func DataKey(account string, key string) []byte {
+	return []byte(acctDataPrefix + linkSymbol + account + linkSymbol + key)
+}
+
+// StateKey returns the trie key of the contract variable stored with SetState.
+func StateKey(account string, key common.Hash) []byte {
+	return []byte(statePrefix + linkSymbol + account + linkSymbol + key.String())
+}
+
+// ProofList collects the encoded trie nodes of a merkle proof.
+type ProofList [][]byte
+
+// Put implements fdb.Putter.
+func (n *ProofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// GetProof returns the merkle proof of the trie key against the committed
+// state root, the proof of an absent key ends with the node proving it.
+func (s *StateDB) GetProof(key []byte) ([][]byte, error) {
+	var proof ProofList
+	if err := s.trie.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// VerifyProof checks the merkle proof of the trie key against the state root
+// and returns the proved value, nil if the key is absent.
+func VerifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db := memdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, _, err := trie.VerifyProof(root, crypto.Keccak256(key), db)
+	return value, err
+}
+
 // ReceiptRoot compute one tx‘ receipt hash
 func (s *StateDB) ReceiptRoot() common.Hash {
 	s.Finalise()
@@ -374,3 +374,44 @@ func TestStateDB_IntermediateRoot(t *testing.T) {
 	state.IntermediateRoot()
 	fmt.Println("time: ", time.Since(st))
 }
+
+func TestSpeculate(t *testing.T) {
+	newState := func() *StateDB {
+		state, err := New(common.Hash{}, NewDatabase(mdb.NewMemDatabase()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.Put("a", "k", []byte("a0"))
+		state.Put("b", "k", []byte("b0"))
+		state.IntermediateRoot()
+		return state
+	}
+	state := newState()
+
+	// a and b access disjoint keys, c reads the key written by a
+	specA, specB, specC := state.Speculate(), state.Speculate(), state.Speculate()
+	if v, _ := specA.Get("a", "k"); !bytes.Equal(v, []byte("a0")) {
+		t.Fatalf("speculative get a %s", v)
+	}
+	specA.Put("a", "k", []byte("a1"))
+	specB.Get("b", "k")
+	specB.Put("c", "k", []byte("c1"))
+	specC.Get("a", "k")
+
+	for _, spec := range []*StateDB{specA, specB} {
+		if !state.Validate(spec.Access()) {
+			t.Fatal("disjoint speculation invalid")
+		}
+		state.Merge(spec)
+	}
+	if state.Validate(specC.Access()) {
+		t.Fatal("conflicting speculation valid")
+	}
+
+	want := newState()
+	want.Put("a", "k", []byte("a1"))
+	want.Put("c", "k", []byte("c1"))
+	if have, want := state.IntermediateRoot(), want.IntermediateRoot(); have != want {
+		t.Fatalf("merged root mismatch, have %x want %x", have, want)
+	}
+}
@@ -55,4 +55,8 @@ var (
 	// ErrNegativeValue is a sanity error to ensure noone is able to specify a
 	// transaction with a negative value.
 	ErrNegativeValue = errors.New("negative value")
+
+	// ErrBindingNotSupported is returned if a transaction is bound to an
+	// expiration or a reference block before the fork supporting it.
+	ErrBindingNotSupported = errors.New("transaction binding not supported")
 )
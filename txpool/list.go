@@ -119,8 +119,22 @@ func (l *txList) Filter(costLimit *big.Int, gasLimit uint64, signer types.Signer
 			return true
 		}
 
+		// the gas of a sponsored transaction is paid by its payer
+		cost := tx.Cost()
+		if payer := tx.Payer(); payer != "" {
+			payerBalance, err := getBalance(payer, tx.GasAssetID(), 0)
+			if err != nil {
+				log.Warn("txpool filter get payer balance failed", "err", err)
+				return true
+			}
+			if cost.Cmp(payerBalance) > 0 {
+				return true
+			}
+			cost = new(big.Int)
+		}
+
 		// todo change action
-		return act.Value().Cmp(balance) > 0 || tx.Cost().Cmp(costLimit) > 0 || act.Gas() > gasLimit
+		return act.Value().Cmp(balance) > 0 || cost.Cmp(costLimit) > 0 || act.Gas() > gasLimit
 	})
 
 	// If the list was strict, filter anything above the lowest nonce
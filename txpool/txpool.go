@@ -59,6 +59,7 @@ const (
 type blockChain interface {
 	CurrentBlock() *types.Block
 	GetBlock(hash common.Hash, number uint64) *types.Block
+	GetHeaderByNumber(number uint64) *types.Header
 	StateAt(root common.Hash) (*state.StateDB, error)
 	Config() *params.ChainConfig
 }
@@ -76,6 +77,7 @@ type TxPool struct {
 	curAccountManager     *am.AccountManager // Current state in the blockchain head
 	pendingAccountManager *am.AccountManager // Pending state tracking virtual nonces
 	currentMaxGas         uint64             // Current gas limit for transaction caps
+	currentHead           *types.Header      // Current head of the blockchain
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -416,6 +418,42 @@ func (tp *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirtyA
 	}
 }
 
+// checkBinding checks the expiration and the reference block of the
+// transaction against the next block of the current head. The binding is
+// rejected until the head reaches ForkID4, as the blocks reject it.
+func (tp *TxPool) checkBinding(tx *types.Transaction) error {
+	if tx.Binding() == nil {
+		return nil
+	}
+	head := tp.currentHead
+	if head.CurForkID() < params.ForkID4 {
+		return ErrBindingNotSupported
+	}
+	next := head.Time.Uint64() + tp.chain.Config().DposCfg.BlockInterval*uint64(time.Millisecond)
+	return tx.CheckBinding(next, head.Number.Uint64()+1, func(number uint64) common.Hash {
+		if header := tp.chain.GetHeaderByNumber(number); header != nil {
+			return header.Hash()
+		}
+		return common.Hash{}
+	})
+}
+
+// dropUnboundTxs removes the transactions which can't be included any more
+// after their expiration or the reorg of their reference block.
+func (tp *TxPool) dropUnboundTxs() {
+	var drops []common.Hash
+	tp.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		if tp.checkBinding(tx) != nil {
+			drops = append(drops, hash)
+		}
+		return true
+	})
+	for _, hash := range drops {
+		log.Trace("Removed unbound transaction", "hash", hash)
+		tp.removeTx(hash, true)
+	}
+}
+
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (tp *TxPool) reset(oldHead, newHead *types.Header) {
@@ -504,6 +542,11 @@ func (tp *TxPool) reset(oldHead, newHead *types.Header) {
 		return
 	}
 	tp.currentMaxGas = newHead.GasLimit
+	tp.currentHead = newHead
+
+	// Drop the transactions expired or bound to another fork of the chain
+	tp.dropUnboundTxs()
+
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	SenderCacher.recover(tp.signer, reinject)
@@ -639,8 +682,10 @@ func (tp *TxPool) validateTx(tx *types.Transaction, local bool) error {
 			return ErrNonceTooLow
 		}
 
-		// Transactor should have enough funds to cover the gas costs
-		balance, err := tp.curAccountManager.GetAccountBalanceByID(from, tx.GasAssetID(), 0)
+		// Transactor or the payer sponsoring the transaction should have
+		// enough funds to cover the gas costs
+		payer := tx.GasPayer(action)
+		balance, err := tp.curAccountManager.GetAccountBalanceByID(payer, tx.GasAssetID(), 0)
 		if err != nil {
 			return err
 		}
@@ -657,7 +702,7 @@ func (tp *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		}
 
 		value := action.Value()
-		if tp.config.GasAssetID == action.AssetID() {
+		if tp.config.GasAssetID == action.AssetID() && payer == from {
 			value.Add(value, gascost)
 		}
 
@@ -682,6 +727,16 @@ func (tp *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInvalidSender
 	}
 
+	// Make sure the transaction isn't expired nor bound to another fork
+	if err := tp.checkBinding(tx); err != nil {
+		return err
+	}
+
+	// Make sure the payer agreed to sponsor the transaction
+	if err := tp.curAccountManager.CheckSponsorship(tx); err != nil {
+		return err
+	}
+
 	// Transaction action  value can't be negative.
 	var allgas uint64
 	for _, a := range tx.GetActions() {
@@ -1872,3 +1872,37 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.addRemotesSync(batch)
 	}
 }
+
+func TestTransactionBinding(t *testing.T) {
+	pool, _ := setupTxPool("")
+	defer pool.Stop()
+
+	interval := pool.chain.Config().DposCfg.BlockInterval * uint64(time.Millisecond)
+	head := &types.Header{Number: big.NewInt(10), Time: new(big.Int).SetUint64(100 * interval)}
+	pool.currentHead = head
+
+	key, _ := crypto.GenerateKey()
+	plain := transaction(0, "bindingfrom", "bindingto", 100000, key)
+	tx := newTx(big.NewInt(1), newAction(0, "bindingfrom", "bindingto", big.NewInt(100), 100000, nil))
+	// expires between the head and the next block
+	tx.WithExpiration(head.Time.Uint64() + interval/2)
+
+	// the binding is rejected before the fork, plain transactions are not
+	head.WithForkID(params.ForkID3, params.ForkID3)
+	if err := pool.checkBinding(tx); err != ErrBindingNotSupported {
+		t.Fatalf("want %v, got %v", ErrBindingNotSupported, err)
+	}
+	if err := pool.checkBinding(plain); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expiration is checked against the time of the next block
+	head.WithForkID(params.ForkID4, params.ForkID4)
+	if err := pool.checkBinding(tx); err != types.ErrTxExpired {
+		t.Fatalf("want %v, got %v", types.ErrTxExpired, err)
+	}
+	tx.WithExpiration(head.Time.Uint64() + interval)
+	if err := pool.checkBinding(tx); err != nil {
+		t.Fatal(err)
+	}
+}
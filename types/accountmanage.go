@@ -25,6 +25,7 @@ type AccountManagerContext struct {
 	Action           *Action
 	ChainConfig      *params.ChainConfig
 	Number           uint64
+	Time             uint64
 	CurForkID        uint64
 	FromAccountExtra []common.Name
 }
@@ -51,6 +51,16 @@ const (
 	DeleteAccount
 	// UpdateAccountAuthor represents the update account author.
 	UpdateAccountAuthor
+	// SetSponsorship represents the set gas sponsorship of the account.
+	SetSponsorship
+	// SetRecovery represents the set recovery guardians of the account.
+	SetRecovery
+	// ProposeRecovery represents the guardian propose new account authors.
+	ProposeRecovery
+	// CancelRecovery represents the cancel pending account recovery.
+	CancelRecovery
+	// ExecuteRecovery represents the apply account recovery after delay.
+	ExecuteRecovery
 )
 
 const (
@@ -67,6 +77,14 @@ const (
 	// Transfer repesents transfer asset action.
 	Transfer
 	UpdateAssetContract
+	// FreezeAsset repesents freeze or unfreeze account asset balance action.
+	FreezeAsset
+	// PauseAsset repesents pause or resume asset transfers action.
+	PauseAsset
+	// SetAssetWhitelist repesents update asset whitelist action.
+	SetAssetWhitelist
+	// TransferLocked repesents transfer asset with vesting schedule action.
+	TransferLocked
 )
 
 const (
@@ -80,6 +98,14 @@ const (
 	RefundCandidate
 	// VoteCandidate repesents voter vote candidate action.
 	VoteCandidate
+	// UnjailCandidate repesents unjail candidate action.
+	UnjailCandidate
+	// UnvoteCandidate repesents voter withdraw vote action.
+	UnvoteCandidate
+	// ChangeVote repesents voter move vote to another candidate action.
+	ChangeVote
+	// ClaimReward repesents voter claim reward action.
+	ClaimReward
 )
 
 const (
@@ -183,6 +209,16 @@ func (a *Action) Check(conf *params.ChainConfig) error {
 	case DeleteAccount:
 		fallthrough
 	case UpdateAccountAuthor:
+		fallthrough
+	case SetSponsorship:
+		fallthrough
+	case SetRecovery:
+		fallthrough
+	case ProposeRecovery:
+		fallthrough
+	case CancelRecovery:
+		fallthrough
+	case ExecuteRecovery:
 		if a.data.To.String() != conf.AccountName {
 			return fmt.Errorf("Receipt should is %v", conf.AccountName)
 		}
@@ -197,11 +233,18 @@ func (a *Action) Check(conf *params.ChainConfig) error {
 		fallthrough
 	case UpdateAssetContract:
 		fallthrough
+	case FreezeAsset:
+		fallthrough
+	case PauseAsset:
+		fallthrough
+	case SetAssetWhitelist:
+		fallthrough
 	case UpdateAsset:
 		if a.data.To.String() != conf.AssetName {
 			return fmt.Errorf("Receipt should is %v", conf.AssetName)
 		}
 	case Transfer:
+	case TransferLocked:
 		//dpos
 	case RegCandidate:
 		fallthrough
@@ -213,6 +256,14 @@ func (a *Action) Check(conf *params.ChainConfig) error {
 		fallthrough
 	case RefundCandidate:
 		fallthrough
+	case UnjailCandidate:
+		fallthrough
+	case UnvoteCandidate:
+		fallthrough
+	case ChangeVote:
+		fallthrough
+	case ClaimReward:
+		fallthrough
 	case KickedCandidate:
 		fallthrough
 	case RemoveKickedCandidate:
@@ -236,6 +287,8 @@ func (a *Action) Check(conf *params.ChainConfig) error {
 		fallthrough
 	case Transfer:
 		fallthrough
+	case TransferLocked:
+		fallthrough
 	case CreateAccount:
 		fallthrough
 	case DestroyAsset:
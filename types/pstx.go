@@ -0,0 +1,148 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fractalplatform/fractal/utils/rlp"
+)
+
+// PSTXVersion is the version of the partially signed transaction encoding.
+const PSTXVersion = 1
+
+var (
+	// ErrPSTXVersion is returned when decoding a PSTX of an unknown version.
+	ErrPSTXVersion = errors.New("unsupported pstx version")
+
+	// ErrPSTXMismatch is returned when merging PSTXs of different transactions.
+	ErrPSTXMismatch = errors.New("pstx transactions mismatch")
+)
+
+// PSTX is a partially signed transaction. It is passed around between the
+// authors of an account, each of them appending its signatures, until the
+// signatures of every action reach the threshold of the account.
+type PSTX struct {
+	Version uint64
+	Tx      *Transaction
+}
+
+// NewPSTX creates a partially signed transaction from the transaction.
+func NewPSTX(tx *Transaction) *PSTX {
+	return &PSTX{Version: PSTXVersion, Tx: tx}
+}
+
+// DecodePSTX decodes a RLP encoded partially signed transaction.
+func DecodePSTX(data []byte) (*PSTX, error) {
+	p := new(PSTX)
+	if err := rlp.DecodeBytes(data, p); err != nil {
+		return nil, err
+	}
+	if p.Version != PSTXVersion {
+		return nil, ErrPSTXVersion
+	}
+	if p.Tx == nil || len(p.Tx.GetActions()) == 0 {
+		return nil, ErrEmptyActions
+	}
+	return p, nil
+}
+
+// Encode returns the RLP encoding of the partially signed transaction.
+func (p *PSTX) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// Sign appends the signatures of the keys to the action at actionIndex.
+// The parent index must match the one of the signatures already present.
+func (p *PSTX) Sign(s Signer, actionIndex int, parentIndex uint64, keys []*KeyPair) error {
+	actions := p.Tx.GetActions()
+	if actionIndex < 0 || actionIndex >= len(actions) {
+		return fmt.Errorf("invalid action index %d", actionIndex)
+	}
+	action := actions[actionIndex]
+	if len(action.GetSign()) > 0 && action.GetSignParent() != parentIndex {
+		return fmt.Errorf("parent index %d mismatch, signed with %d", parentIndex, action.GetSignParent())
+	}
+	for _, key := range keys {
+		if hasSignIndex(action, key.index) {
+			return fmt.Errorf("index %v already signed", key.index)
+		}
+	}
+	if err := SignActionWithMultiKey(action, p.Tx, s, parentIndex, keys); err != nil {
+		return err
+	}
+	return p.refresh()
+}
+
+// Merge appends the signatures of other that are not present yet. Both
+// must carry the same transaction.
+func (p *PSTX) Merge(other *PSTX) error {
+	actions, others := p.Tx.GetActions(), other.Tx.GetActions()
+	if len(actions) != len(others) || NewSigner(nil).Hash(p.Tx) != NewSigner(nil).Hash(other.Tx) {
+		return ErrPSTXMismatch
+	}
+	for i, action := range actions {
+		if len(others[i].GetSign()) == 0 {
+			continue
+		}
+		if len(action.GetSign()) > 0 && action.GetSignParent() != others[i].GetSignParent() {
+			return fmt.Errorf("action %d: %v", i, ErrPSTXMismatch)
+		}
+		for _, sign := range others[i].GetSign() {
+			if !hasSignIndex(action, sign.Index) {
+				action.data.Sign.SignData = append(action.data.Sign.SignData, sign)
+			}
+		}
+		action.WithParentIndex(others[i].GetSignParent())
+	}
+	return p.refresh()
+}
+
+// refresh re-creates the transaction to drop the caches of the hash and
+// the recovered public keys, which change with the signatures.
+func (p *PSTX) refresh() error {
+	data, err := rlp.EncodeToBytes(p.Tx)
+	if err != nil {
+		return err
+	}
+	tx := new(Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return err
+	}
+	p.Tx = tx
+	return nil
+}
+
+func hasSignIndex(a *Action, index []uint64) bool {
+	for _, sign := range a.GetSign() {
+		if len(sign.Index) != len(index) {
+			continue
+		}
+		equal := true
+		for i := range index {
+			if sign.Index[i] != index[i] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 The Fractal Team Authors
+// This file is part of the fractal project.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPSTXSignAndMerge(t *testing.T) {
+	var (
+		signer = NewSigner(big.NewInt(1))
+		keys   = make([]*KeyPair, 3)
+		pubs   = make([]common.PubKey, 3)
+	)
+	for i := range keys {
+		key, _ := crypto.GenerateKey()
+		keys[i] = MakeKeyPair(key, []uint64{uint64(i)})
+		pubs[i] = common.BytesToPubKey(crypto.FromECDSAPub(&key.PublicKey))
+	}
+
+	action := NewAction(Transfer, common.Name("treasury"), common.Name("receiver"), 3, 0, 21000, big.NewInt(100), nil, nil)
+	data, err := NewPSTX(NewTransaction(0, big.NewInt(10), action)).Encode()
+	assert.NoError(t, err)
+
+	// two authors sign the same unsigned pstx in parallel
+	first, err := DecodePSTX(data)
+	assert.NoError(t, err)
+	assert.NoError(t, first.Sign(signer, 0, 0, keys[:1]))
+	assert.Error(t, first.Sign(signer, 0, 0, keys[:1]), "index signed twice")
+	assert.Error(t, first.Sign(signer, 0, 1, keys[1:2]), "parent index mismatch")
+
+	second, err := DecodePSTX(data)
+	assert.NoError(t, err)
+	assert.NoError(t, second.Sign(signer, 0, 0, keys[2:]))
+
+	assert.NoError(t, first.Merge(second))
+	assert.NoError(t, first.Merge(second))
+	encoded, err := first.Encode()
+	assert.NoError(t, err)
+	merged, err := DecodePSTX(encoded)
+	assert.NoError(t, err)
+
+	recovered, err := RecoverMultiKey(signer, merged.Tx.GetActions()[0], merged.Tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.PubKey{pubs[0], pubs[2]}, recovered)
+	assert.Equal(t, []uint64{2}, merged.Tx.GetActions()[0].GetSignIndex(1))
+
+	other := NewPSTX(NewTransaction(0, big.NewInt(11), action))
+	assert.Equal(t, ErrPSTXMismatch, merged.Merge(other))
+}
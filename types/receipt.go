@@ -28,6 +28,11 @@ const (
 
 	// ReceiptStatusSuccessful is the status code of a action if execution succeeded.
 	ReceiptStatusSuccessful = uint64(1)
+
+	// ReceiptStatusReverted is the status code of a action of an atomic
+	// transaction whose state changes were reverted because another action
+	// failed, or which wasn't executed at all.
+	ReceiptStatusReverted = uint64(2)
 )
 
 // ActionResult represents the results the transaction action.
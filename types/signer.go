@@ -72,6 +72,44 @@ func SignActionWithMultiKey(a *Action, tx *Transaction, s Signer, parentIndex ui
 	return nil
 }
 
+// SignPayerWithMultiKey signs the transaction on behalf of its payer.
+func SignPayerWithMultiKey(tx *Transaction, s Signer, parentIndex uint64, keys []*KeyPair) error {
+	if tx.payer == nil {
+		return errors.New("transaction has no payer")
+	}
+	h := s.Hash(tx)
+	for _, key := range keys {
+		sig, err := crypto.Sign(h[:], key.priv)
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithPayerSignature(s, sig, key.index)
+		if err != nil {
+			return err
+		}
+	}
+	tx.payer.Sign.ParentIndex = parentIndex
+	return nil
+}
+
+// RecoverPayerMultiKey recovers the public keys of the payer signatures.
+func RecoverPayerMultiKey(signer Signer, tx *Transaction) ([]common.PubKey, error) {
+	if sc := tx.payerPubkeys.Load(); sc != nil {
+		sigCache := sc.(sigCache)
+		if sigCache.signer.Equal(signer) {
+			return sigCache.pubKeys, nil
+		}
+	}
+
+	pubKeys, err := signer.PayerPubKeys(tx)
+	if err != nil {
+		return []common.PubKey{}, err
+	}
+	tx.payerPubkeys.Store(sigCache{signer: signer, pubKeys: pubKeys})
+	return pubKeys, nil
+}
+
 func RecoverMultiKey(signer Signer, a *Action, tx *Transaction) ([]common.PubKey, error) {
 	if sc := a.senderPubkeys.Load(); sc != nil {
 		sigCache := sc.(sigCache)
@@ -133,8 +171,24 @@ func (s Signer) PubKeys(a *Action, tx *Transaction) ([]common.PubKey, error) {
 	if a.ChainID().Cmp(s.chainID) != 0 {
 		return nil, ErrInvalidchainID
 	}
+	return s.recoverSigns(a.data.Sign.SignData, tx)
+}
+
+// PayerPubKeys returns the public keys of the payer signatures.
+func (s Signer) PayerPubKeys(tx *Transaction) ([]common.PubKey, error) {
+	signs := tx.GetPayerSign()
+	if len(signs) == 0 {
+		return nil, ErrPayerSignEmpty
+	}
+	if deriveChainID(signs[0].V).Cmp(s.chainID) != 0 {
+		return nil, ErrInvalidchainID
+	}
+	return s.recoverSigns(signs, tx)
+}
+
+func (s Signer) recoverSigns(signs []*SignData, tx *Transaction) ([]common.PubKey, error) {
 	var pubKeys []common.PubKey
-	for _, sign := range a.data.Sign.SignData {
+	for _, sign := range signs {
 		V := new(big.Int).Sub(sign.V, s.chainIDMul)
 		V.Sub(V, big8)
 		data, err := recoverPlain(s.Hash(tx), sign.R, sign.S, V)
@@ -183,11 +237,18 @@ func (s Signer) Hash(tx *Transaction) common.Hash {
 		actionHashs[i] = hash
 	}
 
-	return RlpHash([]interface{}{
+	fields := []interface{}{
 		common.MerkleRoot(actionHashs),
 		tx.gasAssetID,
 		tx.gasPrice,
-	})
+	}
+	// the optional fields are covered the same way they are encoded, the
+	// payer by its name.
+	extra := tx.extraFields()
+	if len(extra) > 0 {
+		extra[0] = tx.Payer()
+	}
+	return RlpHash(append(fields, extra...))
 }
 
 func recoverPlain(sighash common.Hash, R, S, Vb *big.Int) ([]byte, error) {
@@ -17,7 +17,9 @@
 package types
 
 import (
+	"bytes"
 	"container/heap"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -37,16 +39,65 @@ var (
 
 	// ErrEmptyActions transaction no actions
 	ErrEmptyActions = errors.New("transaction no actions")
+
+	// ErrTooManyTxFields is returned if a transaction carries unknown optional
+	// fields.
+	ErrTooManyTxFields = errors.New("transaction too many fields")
+
+	// ErrPayerSignEmpty is returned if the payer of a transaction didn't sign it.
+	ErrPayerSignEmpty = errors.New("transaction payer signature is nil")
+
+	// ErrNonCanonicalTx is returned if the optional fields of a transaction are
+	// encoded while they hold their default values.
+	ErrNonCanonicalTx = errors.New("transaction non canonical encoding")
+
+	// ErrTxExpired is returned if the expiration of a transaction is older
+	// than the block time.
+	ErrTxExpired = errors.New("transaction expired")
+
+	// ErrTxRefBlock is returned if the reference block of a transaction isn't
+	// an ancestor of the block.
+	ErrTxRefBlock = errors.New("transaction reference block mismatch")
 )
 
+// Payer is the account sponsoring the gas of all the actions of a
+// transaction, it signs the same hash as the senders of the actions.
+type Payer struct {
+	Name common.Name
+	Sign *Signature
+}
+
+// TxBinding binds a transaction to a time window and to a chain, so that it
+// can't be replayed long after its signing or on another fork of the chain.
+type TxBinding struct {
+	// Expiration is the block time after which the transaction is invalid,
+	// zero for no expiration.
+	Expiration uint64
+	// RefBlockNumber is the number of a block which must be an ancestor of the
+	// block including the transaction, zero for no reference block.
+	RefBlockNumber uint64
+	// RefBlockHashPrefix is the prefix of the hash of the reference block.
+	RefBlockHashPrefix uint32
+}
+
+// RefBlockHashPrefix returns the prefix of the block hash a transaction
+// referencing the block is bound to.
+func RefBlockHashPrefix(hash common.Hash) uint32 {
+	return binary.BigEndian.Uint32(hash[:4])
+}
+
 // Transaction represents an entire transaction in the block.
 type Transaction struct {
 	actions    []*Action
 	gasAssetID uint64
 	gasPrice   *big.Int
+	payer      *Payer
+	atomic     bool
+	binding    *TxBinding
 	// caches
-	hash atomic.Value
-	size atomic.Value
+	hash         atomic.Value
+	size         atomic.Value
+	payerPubkeys atomic.Value
 }
 
 // NewTransaction initialize a transaction.
@@ -82,9 +133,143 @@ func (tx *Transaction) GetActions() []*Action {
 	return tx.actions
 }
 
+// WithPayer sets the account paying the gas of the transaction, the payer is
+// covered by the signatures so it must be set before signing.
+func (tx *Transaction) WithPayer(name common.Name) {
+	tx.payer = &Payer{Name: name, Sign: &Signature{}}
+}
+
+// Payer returns the account paying the gas of the transaction, empty if the
+// gas is paid by the senders of the actions.
+func (tx *Transaction) Payer() common.Name {
+	if tx.payer == nil {
+		return ""
+	}
+	return tx.payer.Name
+}
+
+// GasPayer returns the account paying the gas of the action.
+func (tx *Transaction) GasPayer(a *Action) common.Name {
+	if tx.payer == nil {
+		return a.Sender()
+	}
+	return tx.payer.Name
+}
+
+// GetPayerSign returns the signatures of the payer.
+func (tx *Transaction) GetPayerSign() []*SignData {
+	if tx.payer == nil || tx.payer.Sign == nil {
+		return nil
+	}
+	return tx.payer.Sign.SignData
+}
+
+// GetPayerSignIndex returns the author index path of the i-th payer signature.
+func (tx *Transaction) GetPayerSignIndex(i uint64) []uint64 {
+	return tx.payer.Sign.SignData[i].Index
+}
+
+// GetPayerSignParent returns the parent index of the payer signatures.
+func (tx *Transaction) GetPayerSignParent() uint64 {
+	if tx.payer == nil || tx.payer.Sign == nil {
+		return 0
+	}
+	return tx.payer.Sign.ParentIndex
+}
+
+// WithPayerSignature appends a signature of the payer.
+func (tx *Transaction) WithPayerSignature(signer Signer, sig []byte, index []uint64) error {
+	if tx.payer == nil {
+		return errors.New("transaction has no payer")
+	}
+	r, s, v, err := signer.SignatureValues(sig)
+	if err != nil {
+		return err
+	}
+	if tx.payer.Sign == nil {
+		tx.payer.Sign = &Signature{}
+	}
+	tx.payer.Sign.SignData = append(tx.payer.Sign.SignData, &SignData{R: r, S: s, V: v, Index: index})
+	return nil
+}
+
+// WithAtomic makes the actions of the transaction all or nothing, the state
+// changes of every action are reverted if any of them fails. The flag is
+// covered by the signatures so it must be set before signing.
+func (tx *Transaction) WithAtomic() {
+	tx.atomic = true
+}
+
+// Atomic returns whether the actions of the transaction are all or nothing.
+func (tx *Transaction) Atomic() bool { return tx.atomic }
+
+// WithExpiration sets the block time after which the transaction is invalid.
+// It is covered by the signatures so it must be set before signing.
+func (tx *Transaction) WithExpiration(expiration uint64) {
+	if tx.binding == nil {
+		tx.binding = &TxBinding{}
+	}
+	tx.binding.Expiration = expiration
+}
+
+// WithRefBlock binds the transaction to the chain containing the block. It is
+// covered by the signatures so it must be set before signing.
+func (tx *Transaction) WithRefBlock(number uint64, hash common.Hash) {
+	if tx.binding == nil {
+		tx.binding = &TxBinding{}
+	}
+	tx.binding.RefBlockNumber = number
+	tx.binding.RefBlockHashPrefix = RefBlockHashPrefix(hash)
+}
+
+// Binding returns the expiration and the reference block of the transaction,
+// nil if the transaction isn't bound.
+func (tx *Transaction) Binding() *TxBinding {
+	if tx.binding == nil {
+		return nil
+	}
+	binding := *tx.binding
+	return &binding
+}
+
+// CheckBinding checks the transaction against the time and the number of the
+// block including it, getHash returns the hash of the block of the given
+// number in the chain of that block.
+func (tx *Transaction) CheckBinding(time, number uint64, getHash func(number uint64) common.Hash) error {
+	if tx.binding == nil {
+		return nil
+	}
+	if expiration := tx.binding.Expiration; expiration != 0 && time > expiration {
+		return ErrTxExpired
+	}
+	if ref := tx.binding.RefBlockNumber; ref != 0 {
+		if ref >= number || RefBlockHashPrefix(getHash(ref)) != tx.binding.RefBlockHashPrefix {
+			return ErrTxRefBlock
+		}
+	}
+	return nil
+}
+
+// extraFields returns the optional fields of the transaction, they are
+// appended in order up to the last one set so that the encoding of the plain
+// transactions is unchanged. A nil pointer is encoded as an empty list.
+func (tx *Transaction) extraFields() []interface{} {
+	extra := []interface{}{tx.payer, tx.atomic, tx.binding}
+	switch {
+	case tx.binding != nil:
+		return extra
+	case tx.atomic:
+		return extra[:2]
+	case tx.payer != nil:
+		return extra[:1]
+	}
+	return nil
+}
+
 // EncodeRLP implements rlp.Encoder
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{tx.gasAssetID, tx.gasPrice, tx.actions})
+	fields := []interface{}{tx.gasAssetID, tx.gasPrice, tx.actions}
+	return rlp.Encode(w, append(fields, tx.extraFields()...))
 }
 
 // DecodeRLP implements rlp.Decoder
@@ -93,17 +278,53 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 		AssetID  uint64
 		GasPrice *big.Int
 		Actions  []*Action
+		Extra    []rlp.RawValue `rlp:"tail"` // optional payer, atomic flag and binding
 	}
 
 	_, size, _ := s.Kind()
-	err := s.Decode(&tmpTx)
-	if err == nil {
-		tx.gasAssetID = tmpTx.AssetID
-		tx.gasPrice = tmpTx.GasPrice
-		tx.actions = tmpTx.Actions
-		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+	if err := s.Decode(&tmpTx); err != nil {
+		return err
+	}
+	if len(tmpTx.Extra) > 3 {
+		return ErrTooManyTxFields
+	}
+	var (
+		payer   *Payer
+		atomic  bool
+		binding *TxBinding
+	)
+	for i, raw := range tmpTx.Extra {
+		var err error
+		switch i {
+		case 0:
+			if !bytes.Equal(raw, rlp.EmptyList) {
+				payer = new(Payer)
+				err = rlp.DecodeBytes(raw, payer)
+			}
+		case 1:
+			err = rlp.DecodeBytes(raw, &atomic)
+		case 2:
+			if !bytes.Equal(raw, rlp.EmptyList) {
+				binding = new(TxBinding)
+				err = rlp.DecodeBytes(raw, binding)
+			}
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	tx.gasAssetID = tmpTx.AssetID
+	tx.gasPrice = tmpTx.GasPrice
+	tx.actions = tmpTx.Actions
+	tx.payer = payer
+	tx.atomic = atomic
+	tx.binding = binding
+	// the optional fields must not be encoded with their default values
+	if len(tx.extraFields()) != len(tmpTx.Extra) {
+		return ErrNonCanonicalTx
+	}
+	tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+	return nil
 }
 
 // Hash hashes the RLP encoding of tx.
@@ -160,6 +381,9 @@ type RPCTransaction struct {
 	GasAssetID       uint64       `json:"gasAssetID"`
 	GasPrice         *big.Int     `json:"gasPrice"`
 	GasCost          *big.Int     `json:"gasCost"`
+	Payer            common.Name  `json:"payer,omitempty"`
+	Atomic           bool         `json:"atomic,omitempty"`
+	Binding          *TxBinding   `json:"binding,omitempty"`
 }
 
 // NewRPCTransaction returns a transaction that will serialize to the RPC.
@@ -179,6 +403,9 @@ func (tx *Transaction) NewRPCTransaction(blockHash common.Hash, blockNumber uint
 	result.GasAssetID = tx.gasAssetID
 	result.GasPrice = tx.gasPrice
 	result.GasCost = tx.Cost()
+	result.Payer = tx.Payer()
+	result.Atomic = tx.atomic
+	result.Binding = tx.Binding()
 	return result
 }
 
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/fractalplatform/fractal/common"
+	"github.com/fractalplatform/fractal/crypto"
 	"github.com/fractalplatform/fractal/utils/rlp"
 	"github.com/stretchr/testify/assert"
 )
@@ -56,3 +57,127 @@ func TestTransactionEncodeAndDecode(t *testing.T) {
 
 	assert.Equal(t, newrpctxbytes, testrpctxbytes)
 }
+
+func TestPayerEncodeAndDecode(t *testing.T) {
+	action := NewAction(Transfer, common.Name("fromname"), common.Name("tonamexxx"), 0, 1, 21000, big.NewInt(10), nil, nil)
+	tx := NewTransaction(uint64(1), big.NewInt(1000), action)
+	signer := NewSigner(big.NewInt(1))
+	unsponsored := signer.Hash(tx)
+
+	tx.WithPayer(common.Name("payername"))
+	if signer.Hash(tx) == unsponsored {
+		t.Fatal("payer not covered by the signed hash")
+	}
+
+	key, _ := crypto.GenerateKey()
+	if err := SignPayerWithMultiKey(tx, signer, 0, []*KeyPair{MakeKeyPair(key, []uint64{0})}); err != nil {
+		t.Fatal(err)
+	}
+
+	txbytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newtx := &Transaction{}
+	if err := rlp.DecodeBytes(txbytes, newtx); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.Name("payername"), newtx.Payer())
+	assert.Equal(t, common.Name("payername"), newtx.GasPayer(action))
+	assert.Equal(t, tx.Hash(), newtx.Hash())
+
+	pubs, err := RecoverPayerMultiKey(signer, newtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.BytesToPubKey(crypto.FromECDSAPub(&key.PublicKey)), pubs[0])
+
+	// a transaction without payer keeps the original encoding
+	plain := NewTransaction(uint64(1), big.NewInt(1000), action)
+	plainbytes, _ := rlp.EncodeToBytes(plain)
+	if err := rlp.DecodeBytes(plainbytes, newtx); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, common.Name(""), newtx.Payer())
+	assert.Equal(t, action.Sender(), newtx.GasPayer(action))
+	assert.Equal(t, unsponsored, signer.Hash(newtx))
+}
+
+func TestAtomicEncodeAndDecode(t *testing.T) {
+	action := NewAction(Transfer, common.Name("fromname"), common.Name("tonamexxx"), 0, 1, 21000, big.NewInt(10), nil, nil)
+	signer := NewSigner(big.NewInt(1))
+	for _, payer := range []common.Name{"", "payername"} {
+		tx := NewTransaction(uint64(1), big.NewInt(1000), action)
+		if payer != "" {
+			tx.WithPayer(payer)
+		}
+		hash := signer.Hash(tx)
+		tx.WithAtomic()
+		if signer.Hash(tx) == hash {
+			t.Fatal("atomic flag not covered by the signed hash")
+		}
+
+		txbytes, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newtx := &Transaction{}
+		if err := rlp.DecodeBytes(txbytes, newtx); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, newtx.Atomic())
+		assert.Equal(t, payer, newtx.Payer())
+		assert.Equal(t, tx.Hash(), newtx.Hash())
+	}
+
+	// the optional fields must not be encoded with their default values
+	for _, fields := range [][]interface{}{
+		{uint64(1), big.NewInt(1000), []*Action{action}, (*Payer)(nil)},
+		{uint64(1), big.NewInt(1000), []*Action{action}, (*Payer)(nil), false},
+	} {
+		txbytes, _ := rlp.EncodeToBytes(fields)
+		if err := rlp.DecodeBytes(txbytes, &Transaction{}); err != ErrNonCanonicalTx {
+			t.Fatalf("want %v, got %v", ErrNonCanonicalTx, err)
+		}
+	}
+}
+
+func TestTxBinding(t *testing.T) {
+	action := NewAction(Transfer, common.Name("fromname"), common.Name("tonamexxx"), 0, 1, 21000, big.NewInt(10), nil, nil)
+	signer := NewSigner(big.NewInt(1))
+	refHash := common.HexToHash("0x1234567800000000000000000000000000000000000000000000000000000000")
+	getHash := func(number uint64) common.Hash {
+		if number == 10 {
+			return refHash
+		}
+		return common.Hash{}
+	}
+
+	tx := NewTransaction(uint64(1), big.NewInt(1000), action)
+	hash := signer.Hash(tx)
+	tx.WithExpiration(100)
+	tx.WithRefBlock(10, refHash)
+	if signer.Hash(tx) == hash {
+		t.Fatal("binding not covered by the signed hash")
+	}
+	assert.Equal(t, uint32(0x12345678), tx.Binding().RefBlockHashPrefix)
+
+	txbytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newtx := &Transaction{}
+	if err := rlp.DecodeBytes(txbytes, newtx); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, tx.Binding(), newtx.Binding())
+	assert.Equal(t, tx.Hash(), newtx.Hash())
+
+	assert.NoError(t, newtx.CheckBinding(100, 11, getHash))
+	assert.Equal(t, ErrTxExpired, newtx.CheckBinding(101, 11, getHash))
+	assert.Equal(t, ErrTxRefBlock, newtx.CheckBinding(100, 10, getHash))
+	assert.Equal(t, ErrTxRefBlock, newtx.CheckBinding(100, 11, func(uint64) common.Hash { return common.Hash{} }))
+
+	// a transaction without binding is always valid
+	assert.NoError(t, NewTransaction(uint64(1), big.NewInt(1000), action).CheckBinding(101, 0, getHash))
+}